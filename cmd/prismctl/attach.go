@@ -0,0 +1,248 @@
+// attach.go serves the attach socket behind `shine attach`. Each attached
+// client is an extra sink of the mirror and, unless read-only, a second
+// source of input for the foreground prism.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/paths"
+	"github.com/starbased-co/shine/pkg/rpc"
+	"github.com/starbased-co/shine/pkg/screen"
+)
+
+// attachHandshakeTimeout bounds how long a client has to send its request
+const attachHandshakeTimeout = 5 * time.Second
+
+// attachQueue is how many output chunks an attached client may fall behind
+// before it is disconnected, so a stalled client never blocks the panel
+const attachQueue = 256
+
+type attachServer struct {
+	sockPath   string
+	listener   net.Listener
+	supervisor *supervisor
+
+	mu      sync.Mutex
+	conns   map[net.Conn]struct{}
+	closing bool
+}
+
+func startAttachServer(instance string, sup *supervisor) (*attachServer, error) {
+	sockPath := paths.PrismAttachSocket(instance)
+
+	if err := os.Remove(sockPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", sockPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", sockPath, err)
+	}
+
+	if err := os.Chmod(sockPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	a := &attachServer{
+		sockPath:   sockPath,
+		listener:   listener,
+		supervisor: sup,
+		conns:      make(map[net.Conn]struct{}),
+	}
+	go a.acceptLoop()
+
+	log.Printf("Attach server listening on: %s", sockPath)
+	return a, nil
+}
+
+// stop closes the listener and disconnects every attached client
+func (a *attachServer) stop() {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	a.closing = true
+	for conn := range a.conns {
+		conn.Close()
+	}
+	a.mu.Unlock()
+
+	a.listener.Close()
+	os.Remove(a.sockPath)
+}
+
+func (a *attachServer) acceptLoop() {
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			a.mu.Lock()
+			closing := a.closing
+			a.mu.Unlock()
+			if closing {
+				return
+			}
+			log.Printf("Attach: accept error: %v", err)
+			continue
+		}
+
+		a.mu.Lock()
+		if a.closing {
+			a.mu.Unlock()
+			conn.Close()
+			return
+		}
+		a.conns[conn] = struct{}{}
+		a.mu.Unlock()
+
+		go func() {
+			a.serveConn(conn)
+			a.mu.Lock()
+			delete(a.conns, conn)
+			a.mu.Unlock()
+		}()
+	}
+}
+
+func (a *attachServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(attachHandshakeTimeout))
+	var req rpc.AttachRequest
+	if err := rpc.ReadAttachMessage(r, &req); err != nil {
+		log.Printf("Attach: %v", err)
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	sink := newAttachSink(conn)
+	defer sink.close()
+
+	// The response and repaint are written under the sink lock, so they
+	// describe the prism whose output follows even across a foreground switch
+	var name string
+	paint := func(w io.Writer, prism string, sc *screen.Screen) error {
+		name = prism
+		resp := rpc.AttachResponse{Prism: prism}
+		if cols, rows, err := a.supervisor.termState.size(); err == nil {
+			resp.Cols, resp.Rows = cols, rows
+		} else if sc != nil {
+			resp.Cols, resp.Rows = sc.Size()
+		}
+		if err := rpc.WriteAttachMessage(w, &resp); err != nil {
+			return err
+		}
+		_, err := w.Write(paintScreen(sc))
+		return err
+	}
+	if err := a.supervisor.sinks.add(sink, paint); err != nil {
+		log.Printf("Attach: initial paint failed: %v", err)
+		return
+	}
+	defer a.supervisor.sinks.remove(sink)
+
+	log.Printf("Attach: client connected (read-only=%v, foreground=%q)", req.ReadOnly, name)
+
+	// Read until the client goes away; read-only clients' input is discarded
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 && !req.ReadOnly {
+			if err := a.supervisor.sendForeground(buf[:n]); err != nil {
+				log.Printf("Attach: failed to forward input: %v", err)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	log.Printf("Attach: client disconnected")
+}
+
+// attachSink queues output for one client and writes it from its own
+// goroutine. A client that falls attachQueue chunks behind is disconnected.
+type attachSink struct {
+	conn net.Conn
+	ch   chan []byte
+	done chan struct{}
+	once sync.Once
+}
+
+func newAttachSink(conn net.Conn) *attachSink {
+	s := &attachSink{
+		conn: conn,
+		ch:   make(chan []byte, attachQueue),
+		done: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *attachSink) Write(p []byte) (int, error) {
+	buf := append([]byte(nil), p...)
+	select {
+	case <-s.done:
+		return 0, net.ErrClosed
+	case s.ch <- buf:
+		return len(p), nil
+	default:
+		s.close()
+		return 0, fmt.Errorf("attached client fell %d writes behind", attachQueue)
+	}
+}
+
+func (s *attachSink) run() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case buf := <-s.ch:
+			if _, err := s.conn.Write(buf); err != nil {
+				s.close()
+				return
+			}
+		}
+	}
+}
+
+func (s *attachSink) close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.conn.Close()
+	})
+}
+
+// paintScreen redraws a prism's screen from scratch for a newly attached
+// client, or clears it when there is no foreground prism
+func paintScreen(sc *screen.Screen) []byte {
+	var b bytes.Buffer
+	b.WriteString("\x1b[0m\x1b[2J\x1b[H")
+	if sc == nil {
+		return b.Bytes()
+	}
+
+	content, err := sc.Render(screen.FormatANSI, false)
+	if err != nil {
+		return b.Bytes()
+	}
+	// The client's terminal is in raw mode, so lines need a carriage return
+	b.WriteString(strings.ReplaceAll(strings.TrimSuffix(content, "\n"), "\n", "\r\n"))
+
+	x, y := sc.Cursor()
+	fmt.Fprintf(&b, "\x1b[%d;%dH", y+1, x+1)
+	return b.Bytes()
+}
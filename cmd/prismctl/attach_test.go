@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+	"github.com/starbased-co/shine/pkg/screen"
+)
+
+// attachClient runs serveConn on one end of a pipe and completes the
+// handshake on the other
+func attachClient(t *testing.T, sup *supervisor, readOnly bool) (net.Conn, *bufio.Reader, *rpc.AttachResponse) {
+	t.Helper()
+
+	client, server := net.Pipe()
+	a := &attachServer{supervisor: sup, conns: make(map[net.Conn]struct{})}
+	go a.serveConn(server)
+
+	client.SetDeadline(time.Now().Add(2 * time.Second))
+	if err := rpc.WriteAttachMessage(client, &rpc.AttachRequest{ReadOnly: readOnly}); err != nil {
+		t.Fatalf("WriteAttachMessage() error: %v", err)
+	}
+
+	r := bufio.NewReader(client)
+	var resp rpc.AttachResponse
+	if err := rpc.ReadAttachMessage(r, &resp); err != nil {
+		t.Fatalf("ReadAttachMessage() error: %v", err)
+	}
+	return client, r, &resp
+}
+
+// readUntil reads from r until the output contains want
+func readUntil(t *testing.T, r io.Reader, want string) string {
+	t.Helper()
+	var got bytes.Buffer
+	buf := make([]byte, 256)
+	for !strings.Contains(got.String(), want) {
+		n, err := r.Read(buf)
+		got.Write(buf[:n])
+		if err != nil {
+			t.Fatalf("read %q, want %q: %v", got.String(), want, err)
+		}
+	}
+	return got.String()
+}
+
+func TestAttach_PaintsAndStreams(t *testing.T) {
+	master, slave, err := allocatePTY()
+	if err != nil {
+		t.Fatalf("allocatePTY() error: %v", err)
+	}
+	defer master.Close()
+	defer slave.Close()
+
+	sc := screen.New(20, 2, 0)
+	sc.Write([]byte("12:00"))
+
+	sup := newSupervisor(&terminalState{}, nil, nil)
+	sup.sinks = newSinkSet(nil)
+	sup.prismList = []prismInstance{{name: "clock", pid: 1, state: prismForeground, ptyMaster: master, screen: sc}}
+	sup.sinks.setForeground("clock", sc)
+
+	conn, r, resp := attachClient(t, sup, false)
+	defer conn.Close()
+
+	if resp.Prism != "clock" {
+		t.Errorf("response prism = %q, want %q", resp.Prism, "clock")
+	}
+
+	// The first bytes repaint the current screen, cursor included
+	readUntil(t, r, "12:00\x1b[1;6H")
+
+	// Later output reaches every sink
+	deadline := time.Now().Add(time.Second)
+	for sup.sinks.count() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("attached client was not added as a sink")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	sup.sinks.Write([]byte("12:01"))
+	readUntil(t, r, "12:01")
+
+	// Input goes to the foreground prism
+	if _, err := conn.Write([]byte("q\r")); err != nil {
+		t.Fatalf("client Write() error: %v", err)
+	}
+	slave.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 16)
+	n, err := slave.Read(buf)
+	if err != nil {
+		t.Fatalf("slave Read() error: %v", err)
+	}
+	if got := string(buf[:n]); got != "q\n" {
+		t.Errorf("prism read %q, want %q", got, "q\n")
+	}
+
+	// Disconnecting removes the sink
+	conn.Close()
+	deadline = time.Now().Add(time.Second)
+	for sup.sinks.count() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("sink not removed after disconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAttach_ReadOnlyDropsInput(t *testing.T) {
+	master, slave, err := allocatePTY()
+	if err != nil {
+		t.Fatalf("allocatePTY() error: %v", err)
+	}
+	defer master.Close()
+	defer slave.Close()
+
+	sup := newSupervisor(&terminalState{}, nil, nil)
+	sup.sinks = newSinkSet(nil)
+	sup.prismList = []prismInstance{{name: "clock", pid: 1, state: prismForeground, ptyMaster: master}}
+	sup.sinks.setForeground("clock", nil)
+
+	conn, r, _ := attachClient(t, sup, true)
+	defer conn.Close()
+	readUntil(t, r, "\x1b[H")
+
+	conn.Write([]byte("q\r"))
+
+	slave.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	buf := make([]byte, 16)
+	if n, _ := slave.Read(buf); n > 0 {
+		t.Errorf("read-only input reached the prism: %q", buf[:n])
+	}
+}
+
+func TestAttach_ForwardsResize(t *testing.T) {
+	ts, err := newHeadlessTerminalState(20, 2)
+	if err != nil {
+		t.Fatalf("newHeadlessTerminalState() error: %v", err)
+	}
+	defer ts.close()
+
+	sc := screen.New(20, 2, 0)
+	sc.Write([]byte("12:00"))
+
+	sup := newSupervisor(ts, nil, nil)
+	sup.sinks = newSinkSet(nil)
+	sup.sinks.setForeground("clock", sc)
+
+	conn, r, resp := attachClient(t, sup, true)
+	defer conn.Close()
+	if resp.Cols != 20 || resp.Rows != 2 {
+		t.Errorf("response size = %dx%d, want 20x2", resp.Cols, resp.Rows)
+	}
+	readUntil(t, r, "12:00")
+
+	deadline := time.Now().Add(time.Second)
+	for sup.sinks.count() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("attached client was not added as a sink")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sc.Resize(30, 3)
+	h := &rpcHandlers{supervisor: sup}
+	if _, err := h.handleResize(context.Background(), &rpc.ResizeRequest{Cols: 30, Rows: 3}); err != nil {
+		t.Fatalf("handleResize() error: %v", err)
+	}
+
+	// The notice is followed by a repaint of the resized screen
+	notice := rpc.AttachResizeNotice(30, 3)
+	got := readUntil(t, r, "12:00\x1b[1;6H")
+	if !strings.HasPrefix(got, notice) {
+		t.Errorf("after resize read %q, want %q then a repaint", got, notice)
+	}
+}
+
+func TestSinkSet_DropsFailedClient(t *testing.T) {
+	var good bytes.Buffer
+	client, server := net.Pipe()
+	server.Close()
+
+	// The terminal stays even when its writes fail
+	terminal, _ := net.Pipe()
+	terminal.Close()
+
+	sinks := newSinkSet(terminal)
+	sinks.add(&good, nil)
+	sinks.add(client, nil)
+	sinks.Write([]byte("a"))
+	sinks.Write([]byte("b"))
+
+	if good.String() != "ab" {
+		t.Errorf("good sink = %q, want %q", good.String(), "ab")
+	}
+	if sinks.count() != 1 {
+		t.Errorf("count() = %d, want 1 after a failed write", sinks.count())
+	}
+	if sinks.terminal != terminal {
+		t.Error("terminal sink was dropped after a failed write")
+	}
+}
+
+func TestAttachSink_DisconnectsWhenBehind(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	// Nobody reads client, so the sink's writer goroutine blocks and the queue fills
+	sink := newAttachSink(server)
+	var err error
+	for i := 0; i <= attachQueue+1 && err == nil; i++ {
+		_, err = sink.Write([]byte("x"))
+	}
+	if err == nil {
+		t.Fatal("Write() to a stalled client should fail once the queue is full")
+	}
+	if _, err := sink.Write([]byte("x")); err == nil {
+		t.Error("Write() after disconnect should fail")
+	}
+}
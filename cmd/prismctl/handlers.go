@@ -1,15 +1,20 @@
 // handlers.go implements the prism control plane RPC methods.
 // Receives app configuration from shined and starts the apps.
-// Exposes up/down/fg/bg operations for prism lifecycle management.
+// Exposes up/down/fg/bg/restart/signal operations for prism lifecycle management
+// plus prism/send and prism/capture for input injection and screen capture.
 
 package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/creachadair/jrpc2/handler"
 	"github.com/starbased-co/shine/pkg/rpc"
+	"github.com/starbased-co/shine/pkg/screen"
+	"golang.org/x/sys/unix"
 )
 
 type rpcHandlers struct {
@@ -23,45 +28,31 @@ func newRPCHandlers(sup *supervisor, stateMgr *StateManager) handler.Map {
 		stateManager: stateMgr,
 	}
 
-	return handler.Map{
+	return rpc.Instrument(handler.Map{
 		"prism/configure":  handler.New(h.handleConfigure),
 		"prism/up":         handler.New(h.handleUp),
 		"prism/down":       handler.New(h.handleDown),
 		"prism/fg":         handler.New(h.handleFg),
 		"prism/bg":         handler.New(h.handleBg),
+		"prism/restart":    handler.New(h.handleRestart),
+		"prism/signal":     handler.New(h.handleSignal),
+		"prism/send":       handler.New(h.handleSend),
+		"prism/capture":    handler.New(h.handleCapture),
 		"prism/list":       handler.New(h.handleList),
 		"service/health":   handler.New(h.handleHealth),
 		"service/shutdown": handler.New(h.handleShutdown),
-	}
+		"service/resize":   handler.New(h.handleResize),
+		"service/notice":   handler.New(h.handleNotice),
+		"service/metrics":  handler.New(h.handleMetrics),
+	}, observeRPC)
 }
 
 func (h *rpcHandlers) handleConfigure(ctx context.Context, req *rpc.ConfigureRequest) (*rpc.ConfigureResult, error) {
 	log.Printf("RPC: prism/configure with %d apps", len(req.Apps))
 
-	result := &rpc.ConfigureResult{
-		Started: make([]string, 0),
-		Failed:  make([]string, 0),
-	}
-
-	for _, app := range req.Apps {
-		if !app.Enabled {
-			continue
-		}
-
-		// Register the resolved path for this app
-		h.supervisor.registerApp(app.Name, app.Path)
-
-		// Start the app (first one becomes foreground, rest background)
-		if err := h.supervisor.start(app.Name); err != nil {
-			log.Printf("Failed to start app %s: %v", app.Name, err)
-			result.Failed = append(result.Failed, app.Name)
-			continue
-		}
+	h.supervisor.setIdleSpec(req.Idle)
 
-		result.Started = append(result.Started, app.Name)
-	}
-
-	return result, nil
+	return &rpc.ConfigureResult{Apps: h.supervisor.reconcile(ctx, req.Apps, req.Default)}, nil
 }
 
 func (h *rpcHandlers) handleUp(ctx context.Context, req *rpc.UpRequest) (*rpc.UpResult, error) {
@@ -85,7 +76,7 @@ func (h *rpcHandlers) handleUp(ctx context.Context, req *rpc.UpRequest) (*rpc.Up
 
 	prism := h.supervisor.prismList[idx]
 	state := "bg"
-	if idx == 0 {
+	if prism.state == prismForeground {
 		state = "fg"
 	}
 	h.supervisor.mu.Unlock()
@@ -122,13 +113,13 @@ func (h *rpcHandlers) handleFg(ctx context.Context, req *rpc.FgRequest) (*rpc.Fg
 	h.supervisor.mu.Lock()
 	idx := h.supervisor.findPrism(req.Name)
 
-	if idx == -1 {
+	if idx == -1 && !h.supervisor.isLazy(req.Name) {
 		h.supervisor.mu.Unlock()
 		return nil, rpc.ErrPrismNotFound(req.Name)
 	}
 
 	// Already foreground?
-	if idx == 0 {
+	if idx == 0 && h.supervisor.hasForeground() {
 		h.supervisor.mu.Unlock()
 		return &rpc.FgResult{
 			OK:    true,
@@ -136,7 +127,7 @@ func (h *rpcHandlers) handleFg(ctx context.Context, req *rpc.FgRequest) (*rpc.Fg
 		}, nil
 	}
 
-	// Resume to foreground
+	// Resume to foreground, launching a lazy app that is not running yet
 	h.supervisor.mu.Unlock()
 	if err := h.supervisor.start(req.Name); err != nil {
 		return nil, rpc.ErrOperationFailed("foreground", err)
@@ -153,7 +144,7 @@ func (h *rpcHandlers) handleBg(ctx context.Context, req *rpc.BgRequest) (*rpc.Bg
 		return nil, rpc.ErrInvalidParams("name is required")
 	}
 
-	log.Printf("RPC: prism/bg %s (not implemented - all non-foreground prisms are background)", req.Name)
+	log.Printf("RPC: prism/bg %s", req.Name)
 
 	h.supervisor.mu.Lock()
 	defer h.supervisor.mu.Unlock()
@@ -164,21 +155,169 @@ func (h *rpcHandlers) handleBg(ctx context.Context, req *rpc.BgRequest) (*rpc.Bg
 	}
 
 	// If not foreground, already in background
-	if idx != 0 {
+	if idx != 0 || !h.supervisor.hasForeground() {
 		return &rpc.BgResult{
 			OK:    true,
 			WasBg: true,
 		}, nil
 	}
 
-	// Cannot send foreground to background without bringing another forward
-	// This would require "suspend foreground" semantics which we don't have yet
+	// Leave the panel on its idle screen until a prism is brought forward
+	h.supervisor.suspendForeground()
+
 	return &rpc.BgResult{
-		OK:    false,
+		OK:    true,
 		WasBg: false,
 	}, nil
 }
 
+func (h *rpcHandlers) handleRestart(ctx context.Context, req *rpc.RestartRequest) (*rpc.RestartResult, error) {
+	if req.Name == "" {
+		return nil, rpc.ErrInvalidParams("name is required")
+	}
+
+	log.Printf("RPC: prism/restart %s", req.Name)
+
+	h.supervisor.mu.Lock()
+	idx := h.supervisor.findPrism(req.Name)
+	if idx == -1 {
+		h.supervisor.mu.Unlock()
+		return nil, rpc.ErrPrismNotFound(req.Name)
+	}
+	h.supervisor.mu.Unlock()
+
+	prism, err := h.supervisor.restartAndWait(ctx, req.Name)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, rpc.ErrOperationFailed("restart", err)
+	}
+
+	return &rpc.RestartResult{PID: prism.pid, Restarts: prism.restarts}, nil
+}
+
+func (h *rpcHandlers) handleSignal(ctx context.Context, req *rpc.SignalRequest) (*rpc.SignalResult, error) {
+	if req.Name == "" {
+		return nil, rpc.ErrInvalidParams("name is required")
+	}
+
+	sig, err := parseSignal(req.Signal)
+	if err != nil {
+		return nil, rpc.ErrInvalidParams(err.Error())
+	}
+
+	// The supervisor owns SIGSTOP/SIGCONT for foreground switching
+	if sig == unix.SIGSTOP || sig == unix.SIGCONT {
+		return nil, rpc.ErrInvalidParams(fmt.Sprintf("%s is reserved for foreground switching", unix.SignalName(sig)))
+	}
+
+	log.Printf("RPC: prism/signal %s %s", req.Name, unix.SignalName(sig))
+
+	h.supervisor.mu.Lock()
+	found := h.supervisor.findPrism(req.Name) != -1
+	h.supervisor.mu.Unlock()
+	if !found {
+		return nil, rpc.ErrPrismNotFound(req.Name)
+	}
+
+	pid, err := h.supervisor.signalPrism(req.Name, sig)
+	if err != nil {
+		return nil, rpc.ErrOperationFailed("signal", err)
+	}
+
+	return &rpc.SignalResult{
+		PID:    pid,
+		Signal: unix.SignalName(sig),
+	}, nil
+}
+
+func (h *rpcHandlers) handleSend(ctx context.Context, req *rpc.SendRequest) (*rpc.SendResult, error) {
+	if req.Name == "" {
+		return nil, rpc.ErrInvalidParams("name is required")
+	}
+
+	var data []byte
+	if req.Text != "" {
+		if req.Paste {
+			data = append(data, pasteStart...)
+			data = append(data, req.Text...)
+			data = append(data, pasteEnd...)
+		} else {
+			data = append(data, req.Text...)
+		}
+	}
+	data = append(data, encodeKeys(req.Keys)...)
+	data = append(data, req.Data...)
+
+	if len(data) == 0 {
+		return nil, rpc.ErrInvalidParams("nothing to send")
+	}
+
+	log.Printf("RPC: prism/send %s (%d bytes)", req.Name, len(data))
+
+	h.supervisor.mu.Lock()
+	found := h.supervisor.findPrism(req.Name) != -1
+	h.supervisor.mu.Unlock()
+	if !found {
+		return nil, rpc.ErrPrismNotFound(req.Name)
+	}
+
+	n, err := h.supervisor.sendInput(req.Name, data)
+	if err != nil {
+		return nil, rpc.ErrOperationFailed("send", err)
+	}
+
+	return &rpc.SendResult{
+		Bytes: n,
+	}, nil
+}
+
+func (h *rpcHandlers) handleCapture(ctx context.Context, req *rpc.CaptureRequest) (*rpc.CaptureResult, error) {
+	format := req.Format
+	if format == "" {
+		format = screen.FormatText
+	}
+
+	log.Printf("RPC: prism/capture %s (%s)", req.Name, format)
+
+	h.supervisor.mu.Lock()
+	var prism prismInstance
+	switch {
+	case req.Name != "":
+		idx := h.supervisor.findPrism(req.Name)
+		if idx == -1 {
+			h.supervisor.mu.Unlock()
+			return nil, rpc.ErrPrismNotFound(req.Name)
+		}
+		prism = h.supervisor.prismList[idx]
+	case h.supervisor.hasForeground():
+		prism = h.supervisor.prismList[0]
+	default:
+		h.supervisor.mu.Unlock()
+		return nil, rpc.ErrInvalidParams("no foreground prism, name is required")
+	}
+	h.supervisor.mu.Unlock()
+
+	if prism.screen == nil {
+		return nil, rpc.ErrOperationFailed("capture", fmt.Errorf("%s has no screen", prism.name))
+	}
+
+	content, err := prism.screen.Render(format, req.Scrollback)
+	if err != nil {
+		return nil, rpc.ErrInvalidParams(err.Error())
+	}
+
+	cols, rows := prism.screen.Size()
+	return &rpc.CaptureResult{
+		Name:    prism.name,
+		Format:  format,
+		Cols:    cols,
+		Rows:    rows,
+		Content: content,
+	}, nil
+}
+
 func (h *rpcHandlers) handleList(ctx context.Context) (*rpc.ListResult, error) {
 	log.Printf("RPC: prism/list")
 
@@ -186,18 +325,24 @@ func (h *rpcHandlers) handleList(ctx context.Context) (*rpc.ListResult, error) {
 	defer h.supervisor.mu.Unlock()
 
 	prisms := make([]rpc.PrismInfo, 0, len(h.supervisor.prismList))
-	for i, p := range h.supervisor.prismList {
+	for _, p := range h.supervisor.prismList {
 		state := "bg"
-		if i == 0 {
+		if p.state == prismForeground {
 			state = "fg"
 		}
 
+		health, healthMessage := p.health.snapshot()
+
 		prisms = append(prisms, rpc.PrismInfo{
-			Name:     p.name,
-			PID:      p.pid,
-			State:    state,
-			UptimeMs: 0, // TODO: track start time
-			Restarts: 0, // TODO: track restarts
+			Name:          p.name,
+			PID:           p.pid,
+			State:         state,
+			UptimeMs:      time.Since(p.startTime).Milliseconds(),
+			Restarts:      p.restarts,
+			Starting:      p.ready.pending(),
+			Health:        health,
+			HealthMessage: healthMessage,
+			Usage:         h.supervisor.usageInfo(p.pid),
 		})
 	}
 
@@ -215,14 +360,47 @@ func (h *rpcHandlers) handleHealth(ctx context.Context) (*rpc.HealthResult, erro
 	return &rpc.HealthResult{
 		Healthy:    !h.supervisor.shuttingDown,
 		PrismCount: len(h.supervisor.prismList),
+		Headless:   h.supervisor.termState.headless(),
 	}, nil
 }
 
+// handleResize sets the size of a headless prismctl's virtual terminal and
+// resizes every prism to match, as SIGWINCH does for a kitty panel
+func (h *rpcHandlers) handleResize(ctx context.Context, req *rpc.ResizeRequest) (*rpc.ResizeResult, error) {
+	log.Printf("RPC: service/resize %dx%d", req.Cols, req.Rows)
+
+	if req.Cols <= 0 || req.Rows <= 0 || req.Cols > 0xffff || req.Rows > 0xffff {
+		return nil, rpc.ErrInvalidParams(fmt.Sprintf("invalid size: %dx%d", req.Cols, req.Rows))
+	}
+
+	if err := h.supervisor.termState.setSize(req.Cols, req.Rows); err != nil {
+		return nil, rpc.ErrOperationFailed("resize", err)
+	}
+	h.supervisor.propagateResize()
+
+	return &rpc.ResizeResult{Cols: req.Cols, Rows: req.Rows}, nil
+}
+
+func (h *rpcHandlers) handleMetrics(ctx context.Context) (*rpc.MetricsResult, error) {
+	return &rpc.MetricsResult{Families: metricsRegistry.Gather()}, nil
+}
+
+// handleNotice replaces the panel contents with text until a prism is
+// brought forward, e.g. the build errors shine dev reports
+func (h *rpcHandlers) handleNotice(ctx context.Context, req *rpc.NoticeRequest) (*rpc.NoticeResult, error) {
+	log.Printf("RPC: service/notice (%d bytes)", len(req.Text))
+
+	h.supervisor.mu.Lock()
+	defer h.supervisor.mu.Unlock()
+
+	return &rpc.NoticeResult{Background: h.supervisor.showNotice(req.Text)}, nil
+}
+
 func (h *rpcHandlers) handleShutdown(ctx context.Context, req *rpc.ShutdownRequest) (*rpc.ShutdownResult, error) {
 	log.Printf("RPC: service/shutdown (graceful=%v)", req.Graceful)
 
 	// Trigger shutdown in background
-	go h.supervisor.shutdown()
+	go h.supervisor.shutdown(rpc.PanelExitRequested)
 
 	return &rpc.ShutdownResult{
 		ShuttingDown: true,
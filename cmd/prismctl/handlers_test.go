@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+func TestHandleConfigure_OrderDefaultLazy(t *testing.T) {
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+
+	ts, err := newHeadlessTerminalState(80, 24)
+	if err != nil {
+		t.Fatalf("newHeadlessTerminalState() error: %v", err)
+	}
+	defer ts.close()
+
+	sup := newSupervisor(ts, nil, nil)
+	defer sup.shutdown(rpc.PanelExitRequested)
+	h := &rpcHandlers{supervisor: sup}
+	ctx := context.Background()
+
+	app := func(name string, lazy bool) rpc.AppInfo {
+		return rpc.AppInfo{Name: name, Path: sleepPath, Args: []string{"30"}, Enabled: true, Lazy: lazy}
+	}
+	result, err := h.handleConfigure(ctx, &rpc.ConfigureRequest{
+		Apps:    []rpc.AppInfo{app("mail", false), app("chat", false), app("music", true), app("news", false)},
+		Default: "chat",
+	})
+	if err != nil {
+		t.Fatalf("handleConfigure() error: %v", err)
+	}
+
+	want := []string{"mail:started", "chat:started", "music:registered", "news:started"}
+	if got := appActions(result.Apps); !slices.Equal(got, want) {
+		t.Errorf("Apps = %v, want %v", got, want)
+	}
+
+	// The default app is in the foreground, not the last one launched
+	list, _ := h.handleList(ctx)
+	if len(list.Prisms) != 3 || list.Prisms[0].Name != "chat" || list.Prisms[0].State != "fg" {
+		t.Fatalf("prisms after configure = %+v, want chat in the foreground", list.Prisms)
+	}
+
+	// A lazy app is launched by its first prism/fg; an unknown one is not
+	if _, err := h.handleFg(ctx, &rpc.FgRequest{Name: "weather"}); err == nil {
+		t.Error("handleFg() on an unknown app should fail")
+	}
+	if _, err := h.handleFg(ctx, &rpc.FgRequest{Name: "music"}); err != nil {
+		t.Fatalf("handleFg(music) error: %v", err)
+	}
+	list, _ = h.handleList(ctx)
+	if len(list.Prisms) != 4 || list.Prisms[0].Name != "music" || list.Prisms[0].State != "fg" {
+		t.Errorf("prisms after fg = %+v, want music launched in the foreground", list.Prisms)
+	}
+}
+
+func TestHandleConfigure_Reconcile(t *testing.T) {
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+
+	ts, err := newHeadlessTerminalState(80, 24)
+	if err != nil {
+		t.Fatalf("newHeadlessTerminalState() error: %v", err)
+	}
+	defer ts.close()
+
+	sup := newSupervisor(ts, nil, nil)
+	defer sup.shutdown(rpc.PanelExitRequested)
+	h := &rpcHandlers{supervisor: sup}
+	ctx := context.Background()
+
+	// Reap exited prisms so restarts relaunch and removed prisms disappear
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		reaper := &signalHandler{supervisor: sup}
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				reaper.handleSIGCHLD()
+			}
+		}
+	}()
+
+	app := func(name, arg string) rpc.AppInfo {
+		return rpc.AppInfo{Name: name, Path: sleepPath, Args: []string{arg}, Enabled: true}
+	}
+	if _, err := h.handleConfigure(ctx, &rpc.ConfigureRequest{
+		Apps:    []rpc.AppInfo{app("mail", "30"), app("chat", "30"), app("news", "30")},
+		Default: "mail",
+	}); err != nil {
+		t.Fatalf("handleConfigure() error: %v", err)
+	}
+	if _, err := h.handleFg(ctx, &rpc.FgRequest{Name: "chat"}); err != nil {
+		t.Fatalf("handleFg(chat) error: %v", err)
+	}
+
+	sup.mu.Lock()
+	mailPID := sup.prismList[sup.findPrism("mail")].pid
+	sup.mu.Unlock()
+
+	result, err := h.handleConfigure(ctx, &rpc.ConfigureRequest{
+		Apps:    []rpc.AppInfo{app("mail", "30"), app("chat", "31"), app("music", "30")},
+		Default: "mail",
+	})
+	if err != nil {
+		t.Fatalf("handleConfigure() error: %v", err)
+	}
+
+	want := []rpc.AppResult{
+		{Name: "mail", Action: rpc.AppUnchanged},
+		{Name: "chat", Action: rpc.AppRestarted, Reason: "args changed"},
+		{Name: "music", Action: rpc.AppStarted, Reason: "new"},
+		{Name: "news", Action: rpc.AppStopped, Reason: "removed"},
+	}
+	if !slices.Equal(result.Apps, want) {
+		t.Errorf("Apps = %+v, want %+v", result.Apps, want)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		list, _ := h.handleList(ctx)
+		names := make([]string, 0, len(list.Prisms))
+		for _, p := range list.Prisms {
+			names = append(names, p.Name)
+		}
+		slices.Sort(names)
+		if slices.Equal(names, []string{"chat", "mail", "music"}) {
+			// The foreground survives the reconcile; unchanged apps keep running
+			if list.Prisms[0].Name != "chat" || list.Prisms[0].State != "fg" {
+				t.Errorf("prisms after reconcile = %+v, want chat in the foreground", list.Prisms)
+			}
+			for _, p := range list.Prisms {
+				if p.Name == "mail" && p.PID != mailPID {
+					t.Errorf("mail PID = %d, want unchanged %d", p.PID, mailPID)
+				}
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("prisms after reconcile = %v, want chat, mail and music", names)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// appActions flattens configure results to name:action pairs
+func appActions(results []rpc.AppResult) []string {
+	actions := make([]string, 0, len(results))
+	for _, r := range results {
+		actions = append(actions, r.Name+":"+r.Action)
+	}
+	return actions
+}
+
+func TestHandleNotice(t *testing.T) {
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+
+	ts, err := newHeadlessTerminalState(80, 24)
+	if err != nil {
+		t.Fatalf("newHeadlessTerminalState() error: %v", err)
+	}
+	defer ts.close()
+
+	sup := newSupervisor(ts, nil, nil)
+	defer sup.shutdown(rpc.PanelExitRequested)
+	h := &rpcHandlers{supervisor: sup}
+	ctx := context.Background()
+
+	sup.registerApp("clock", &appSpec{path: sleepPath, args: []string{"30"}})
+	if _, err := h.handleUp(ctx, &rpc.UpRequest{Name: "clock"}); err != nil {
+		t.Fatalf("handleUp() error: %v", err)
+	}
+
+	result, err := h.handleNotice(ctx, &rpc.NoticeRequest{Text: "build failed\nmain.go:1: oops"})
+	if err != nil {
+		t.Fatalf("handleNotice() error: %v", err)
+	}
+	if result.Background != "clock" {
+		t.Errorf("Background = %q, want clock", result.Background)
+	}
+
+	list, _ := h.handleList(ctx)
+	if len(list.Prisms) != 1 || list.Prisms[0].State != "bg" {
+		t.Fatalf("prisms after notice = %+v, want clock in the background", list.Prisms)
+	}
+
+	// A second notice replaces the first without anything left to background
+	if result, _ := h.handleNotice(ctx, &rpc.NoticeRequest{Text: "still failing"}); result.Background != "" {
+		t.Errorf("Background = %q, want none", result.Background)
+	}
+
+	if _, err := h.handleFg(ctx, &rpc.FgRequest{Name: "clock"}); err != nil {
+		t.Fatalf("handleFg() error: %v", err)
+	}
+	list, _ = h.handleList(ctx)
+	if list.Prisms[0].State != "fg" {
+		t.Errorf("prisms after fg = %+v, want clock in the foreground", list.Prisms)
+	}
+}
+
+func TestHandleMetrics(t *testing.T) {
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+
+	ts, err := newHeadlessTerminalState(80, 24)
+	if err != nil {
+		t.Fatalf("newHeadlessTerminalState() error: %v", err)
+	}
+	defer ts.close()
+
+	sup := newSupervisor(ts, nil, nil)
+	defer sup.shutdown(rpc.PanelExitRequested)
+	h := &rpcHandlers{supervisor: sup}
+	ctx := context.Background()
+
+	sup.registerApp("clock", &appSpec{path: sleepPath, args: []string{"30"}})
+	if _, err := h.handleUp(ctx, &rpc.UpRequest{Name: "clock"}); err != nil {
+		t.Fatalf("handleUp() error: %v", err)
+	}
+	sup.sampleUsage()
+
+	result, err := h.handleMetrics(ctx)
+	if err != nil {
+		t.Fatalf("handleMetrics() error: %v", err)
+	}
+
+	var sampled bool
+	for _, family := range result.Families {
+		if family.Name != "prismctl_prism_rss_bytes" {
+			continue
+		}
+		for _, sample := range family.Samples {
+			if sample.Labels["prism"] == "clock" && sample.Value > 0 {
+				sampled = true
+			}
+		}
+	}
+	if !sampled {
+		t.Errorf("no RSS sample for clock in %+v", result.Families)
+	}
+}
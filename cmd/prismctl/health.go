@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+// healthMonitor periodically runs the health checks configured for a prism.
+// A prism becomes unhealthy after spec.Retries consecutive failed checks and
+// healthy again after the first passing one.
+type healthMonitor struct {
+	sup        *supervisor
+	name       string
+	pid        int
+	spec       *rpc.HealthCheckSpec
+	lastOutput *atomic.Int64
+	cancel     context.CancelFunc
+
+	mu       sync.Mutex
+	status   string
+	message  string
+	failures int
+}
+
+// startHealthMonitor starts checking a freshly launched prism
+func startHealthMonitor(sup *supervisor, name string, pid int, spec *rpc.HealthCheckSpec, lastOutput *atomic.Int64) *healthMonitor {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	hm := &healthMonitor{
+		sup:        sup,
+		name:       name,
+		pid:        pid,
+		spec:       spec,
+		lastOutput: lastOutput,
+		cancel:     cancel,
+		status:     rpc.HealthStarting,
+	}
+
+	go hm.run(ctx)
+
+	return hm
+}
+
+// stop cancels the monitor; safe to call on nil
+func (hm *healthMonitor) stop() {
+	if hm == nil {
+		return
+	}
+	hm.cancel()
+}
+
+// snapshot returns the current status and message; safe to call on nil
+func (hm *healthMonitor) snapshot() (string, string) {
+	if hm == nil {
+		return "", ""
+	}
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	return hm.status, hm.message
+}
+
+func (hm *healthMonitor) run(ctx context.Context) {
+	interval := time.Duration(hm.spec.IntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Background prisms are SIGSTOPped, so every check would fail
+		if !hm.sup.isForegroundPID(hm.pid) {
+			continue
+		}
+
+		err := hm.check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if status, message, changed := hm.record(err); changed {
+			log.Printf("Prism %s health: %s %s", hm.name, status, message)
+			hm.sup.onHealthChanged(hm.name, hm.pid, status, message, hm.spec)
+		}
+	}
+}
+
+// record folds a check result into the monitor's state and reports whether
+// the status changed
+func (hm *healthMonitor) record(checkErr error) (status, message string, changed bool) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	prev := hm.status
+
+	if checkErr == nil {
+		hm.failures = 0
+		hm.status = rpc.HealthHealthy
+		hm.message = ""
+	} else {
+		hm.failures++
+		hm.message = checkErr.Error()
+		healthCheckFailures.Inc(hm.name)
+		if hm.failures >= hm.spec.Retries {
+			hm.status = rpc.HealthUnhealthy
+		}
+	}
+
+	return hm.status, hm.message, hm.status != prev
+}
+
+// check runs every configured check and returns the first failure
+func (hm *healthMonitor) check(ctx context.Context) error {
+	if stopped, err := processStopped(hm.pid); err == nil && stopped {
+		return fmt.Errorf("process is stopped")
+	}
+
+	if hm.spec.OutputWithinMs > 0 {
+		if err := checkOutputWithin(hm.lastOutput, time.Duration(hm.spec.OutputWithinMs)*time.Millisecond, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if hm.spec.File != "" {
+		if err := checkFileAge(hm.spec.File, time.Duration(hm.spec.MaxAgeMs)*time.Millisecond, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if hm.spec.Command != "" {
+		if err := hm.runCommand(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (hm *healthMonitor) runCommand(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(hm.spec.TimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hm.spec.Command)
+	cmd.Env = append(os.Environ(),
+		"SHINE_PRISM="+hm.name,
+		fmt.Sprintf("SHINE_PRISM_PID=%d", hm.pid),
+	)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("health command timed out")
+		}
+		if msg := strings.TrimSpace(out.String()); msg != "" {
+			return fmt.Errorf("health command failed: %s", firstLine(msg))
+		}
+		return fmt.Errorf("health command failed: %w", err)
+	}
+
+	return nil
+}
+
+// checkOutputWithin fails when the prism has not written to its PTY within window
+func checkOutputWithin(lastOutput *atomic.Int64, window time.Duration, now time.Time) error {
+	if lastOutput == nil {
+		return nil
+	}
+	idle := now.Sub(time.UnixMilli(lastOutput.Load()))
+	if idle > window {
+		return fmt.Errorf("no output for %s", idle.Truncate(time.Second))
+	}
+	return nil
+}
+
+// checkFileAge fails when path is missing or was last modified more than maxAge ago
+func checkFileAge(path string, maxAge time.Duration, now time.Time) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("heartbeat file: %w", err)
+	}
+	if age := now.Sub(info.ModTime()); age > maxAge {
+		return fmt.Errorf("heartbeat file is %s old", age.Truncate(time.Second))
+	}
+	return nil
+}
+
+// processStopped reports whether pid is in the stopped state according to /proc
+func processStopped(pid int) (bool, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false, err
+	}
+	state, err := parseProcState(string(data))
+	if err != nil {
+		return false, err
+	}
+	return state == 'T' || state == 't', nil
+}
+
+// parseProcState extracts the state field from a /proc/<pid>/stat line.
+// The command name may contain spaces and parentheses, so parse from the
+// last closing parenthesis.
+func parseProcState(stat string) (byte, error) {
+	end := strings.LastIndexByte(stat, ')')
+	if end == -1 || end+2 >= len(stat) {
+		return 0, fmt.Errorf("malformed stat line")
+	}
+	return stat[end+2], nil
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i != -1 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+func TestHealthMonitor_Record(t *testing.T) {
+	hm := &healthMonitor{
+		spec:   &rpc.HealthCheckSpec{Retries: 2},
+		status: rpc.HealthStarting,
+	}
+
+	status, _, changed := hm.record(nil)
+	if status != rpc.HealthHealthy || !changed {
+		t.Fatalf("record(nil) = %q changed=%v, want healthy changed", status, changed)
+	}
+
+	status, msg, changed := hm.record(errors.New("boom"))
+	if status != rpc.HealthHealthy || changed {
+		t.Errorf("first failure = %q changed=%v, want healthy unchanged", status, changed)
+	}
+	if msg != "boom" {
+		t.Errorf("message = %q, want boom", msg)
+	}
+
+	status, _, changed = hm.record(errors.New("boom"))
+	if status != rpc.HealthUnhealthy || !changed {
+		t.Errorf("second failure = %q changed=%v, want unhealthy changed", status, changed)
+	}
+
+	status, msg, changed = hm.record(nil)
+	if status != rpc.HealthHealthy || !changed || msg != "" {
+		t.Errorf("recovery = %q %q changed=%v, want healthy with empty message", status, msg, changed)
+	}
+}
+
+func TestHealthMonitor_NilSafe(t *testing.T) {
+	var hm *healthMonitor
+	hm.stop()
+	if status, msg := hm.snapshot(); status != "" || msg != "" {
+		t.Errorf("nil snapshot() = %q, %q, want empty", status, msg)
+	}
+}
+
+func TestCheckOutputWithin(t *testing.T) {
+	now := time.Now()
+	last := new(atomic.Int64)
+
+	last.Store(now.Add(-2 * time.Second).UnixMilli())
+	if err := checkOutputWithin(last, 5*time.Second, now); err != nil {
+		t.Errorf("recent output: unexpected error %v", err)
+	}
+
+	last.Store(now.Add(-10 * time.Second).UnixMilli())
+	if err := checkOutputWithin(last, 5*time.Second, now); err == nil {
+		t.Error("stale output: expected error")
+	}
+}
+
+func TestCheckFileAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heartbeat")
+	now := time.Now()
+
+	if err := checkFileAge(path, time.Minute, now); err == nil {
+		t.Error("missing file: expected error")
+	}
+
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkFileAge(path, time.Minute, now); err != nil {
+		t.Errorf("fresh file: unexpected error %v", err)
+	}
+
+	old := now.Add(-2 * time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkFileAge(path, time.Minute, now); err == nil {
+		t.Error("stale file: expected error")
+	}
+}
+
+func TestParseProcState(t *testing.T) {
+	tests := []struct {
+		stat string
+		want byte
+	}{
+		{"1234 (clock) S 1 1234 1234 0", 'S'},
+		{"1234 (my (odd) app) T 1 1234", 'T'},
+	}
+
+	for _, tt := range tests {
+		got, err := parseProcState(tt.stat)
+		if err != nil {
+			t.Errorf("parseProcState(%q) error: %v", tt.stat, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseProcState(%q) = %c, want %c", tt.stat, got, tt.want)
+		}
+	}
+
+	if _, err := parseProcState("garbage"); err == nil {
+		t.Error("parseProcState(garbage): expected error")
+	}
+}
+
+func TestForegroundResetsOutputActivity(t *testing.T) {
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+
+	ts, err := newHeadlessTerminalState(80, 24)
+	if err != nil {
+		t.Fatalf("newHeadlessTerminalState() error: %v", err)
+	}
+	defer ts.close()
+
+	sup := newSupervisor(ts, nil, nil)
+	defer sup.shutdown(rpc.PanelExitRequested)
+	h := &rpcHandlers{supervisor: sup}
+	ctx := context.Background()
+
+	sup.registerApp("clock", &appSpec{path: sleepPath, args: []string{"30"}})
+	sup.registerApp("bar", &appSpec{path: sleepPath, args: []string{"30"}})
+	for _, name := range []string{"clock", "bar"} {
+		if _, err := h.handleUp(ctx, &rpc.UpRequest{Name: name}); err != nil {
+			t.Fatalf("handleUp(%s) error: %v", name, err)
+		}
+	}
+
+	// clock has been silent in the background for an hour
+	sup.mu.Lock()
+	last := sup.prismList[sup.findPrism("clock")].lastOutput
+	sup.mu.Unlock()
+	last.Store(time.Now().Add(-time.Hour).UnixMilli())
+
+	if _, err := h.handleFg(ctx, &rpc.FgRequest{Name: "clock"}); err != nil {
+		t.Fatalf("handleFg(clock) error: %v", err)
+	}
+	if err := checkOutputWithin(last, 5*time.Second, time.Now()); err != nil {
+		t.Errorf("output-within check right after fg: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+// idle.go implements the empty-foreground state: prism/bg on the foreground
+// prism suspends it without resuming another, and the panel shows an idle
+// screen until prism/fg or prism/up brings a prism forward again.
+
+package main
+
+import (
+	"io"
+	"log"
+	"strings"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+	"golang.org/x/sys/unix"
+)
+
+func (s *supervisor) setIdleSpec(spec *rpc.IdleSpec) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idleSpec = spec
+}
+
+// hasForeground reports whether a prism currently owns the panel.
+// Assumes caller holds s.mu lock
+func (s *supervisor) hasForeground() bool {
+	return len(s.prismList) > 0 && s.prismList[0].state == prismForeground
+}
+
+// suspendForeground sends the foreground prism to the background and shows
+// the idle screen. It stays at the head of the MRU list.
+// Assumes caller holds s.mu lock
+func (s *supervisor) suspendForeground() {
+	s.backgroundForeground()
+	s.showIdle()
+}
+
+// backgroundForeground suspends the foreground prism without resuming
+// another, leaving the panel to the caller. Returns the prism's name.
+// Assumes caller holds s.mu lock
+func (s *supervisor) backgroundForeground() string {
+	fg := s.prismList[0]
+	log.Printf("Suspending foreground %s (PID %d) without replacement", fg.name, fg.pid)
+
+	s.suspend(fg)
+	s.prismList[0].state = prismBackground
+
+	if s.stateManager != nil {
+		s.stateManager.OnForegroundChanged("")
+	}
+
+	if s.notifyMgr != nil {
+		s.notifyMgr.OnForegroundChanged(fg.name, "")
+	}
+
+	return fg.name
+}
+
+// showNotice backgrounds the foreground prism, if any, and draws text in its
+// place. Returns the name of the prism it backgrounded.
+// Assumes caller holds s.mu lock
+func (s *supervisor) showNotice(text string) string {
+	var background string
+	if s.hasForeground() {
+		background = s.backgroundForeground()
+	}
+
+	if s.mirror != nil {
+		deactivateMirror(s.mirror)
+		s.mirror = nil
+	}
+	s.hideIdle()
+	s.sinks.setForeground("", nil)
+
+	if err := s.termState.resetTerminalState(); err != nil {
+		log.Printf("Warning: failed to reset terminal state: %v", err)
+	}
+	io.WriteString(s.sinks, "\x1b[2J\x1b[H\x1b[0m"+strings.ReplaceAll(text, "\n", "\r\n"))
+
+	return background
+}
+
+// showIdle detaches the mirror and draws the idle screen, running the idle
+// app if one is configured and falling back to the idle text.
+// Assumes caller holds s.mu lock
+func (s *supervisor) showIdle() {
+	if s.mirror != nil {
+		deactivateMirror(s.mirror)
+		s.mirror = nil
+	}
+	s.sinks.setForeground("", nil)
+
+	if err := s.termState.resetTerminalState(); err != nil {
+		log.Printf("Warning: failed to reset terminal state: %v", err)
+	}
+	io.WriteString(s.sinks, "\x1b[2J\x1b[H\x1b[0m")
+
+	if s.idleSpec == nil {
+		return
+	}
+
+	if s.idleSpec.Path != "" {
+		err := s.resumeIdleApp()
+		if err == nil {
+			return
+		}
+		log.Printf("Warning: failed to run idle app %s: %v", s.idleSpec.Path, err)
+	}
+
+	io.WriteString(s.sinks, s.idleSpec.Text)
+}
+
+// resumeIdleApp launches the idle app, or resumes it if it was suspended the
+// last time a prism came forward, and mirrors it to the panel.
+// Assumes caller holds s.mu lock
+func (s *supervisor) resumeIdleApp() error {
+	if s.idleApp != nil && s.idleApp.name != s.idleSpec.Path {
+		s.stopIdleApp()
+	}
+
+	if s.idleApp == nil {
+		instance, err := s.spawnPrism(s.idleSpec.Path)
+		if err != nil {
+			return err
+		}
+		s.idleApp = &instance
+		log.Printf("Idle app started: %s (PID %d)", instance.name, instance.pid)
+	} else {
+		if err := syncTerminalSize(int(s.termState.input().Fd()), int(s.idleApp.ptyMaster.Fd())); err != nil {
+			log.Printf("Warning: failed to sync terminal size: %v", err)
+		}
+		if err := unix.Kill(s.idleApp.pid, unix.SIGCONT); err != nil {
+			log.Printf("Warning: failed to SIGCONT idle app: %v", err)
+		}
+		unix.Kill(s.idleApp.pid, unix.SIGWINCH)
+	}
+
+	mirror, err := activateMirror(s.mirrorCtx, s.termState.input(), s.idleApp.ptyMaster, withSinks(s.sinks), withPrismOutput(s.idleApp.output))
+	if err != nil {
+		return err
+	}
+	s.mirror = mirror
+
+	return nil
+}
+
+// hideIdle suspends the idle app before a prism takes the foreground. The
+// caller replaces the mirror.
+// Assumes caller holds s.mu lock
+func (s *supervisor) hideIdle() {
+	if s.idleApp == nil {
+		return
+	}
+	if err := unix.Kill(s.idleApp.pid, unix.SIGSTOP); err != nil {
+		log.Printf("Warning: failed to SIGSTOP idle app: %v", err)
+	}
+}
+
+// stopIdleApp terminates an idle app that is no longer configured. Its PID
+// is remembered until the reaper collects it.
+// Assumes caller holds s.mu lock
+func (s *supervisor) stopIdleApp() {
+	if s.idleApp == nil {
+		return
+	}
+	s.stoppedIdle[s.idleApp.pid] = true
+	unix.Kill(s.idleApp.pid, unix.SIGCONT)
+	unix.Kill(s.idleApp.pid, unix.SIGTERM)
+	closePTY(s.idleApp.ptyMaster)
+	s.idleApp.sandbox.release()
+	s.idleApp = nil
+}
+
+// idleAppExited cleans up after the idle app exits. If the idle screen is
+// showing it falls back to the idle text. Returns false if pid is not the
+// idle app.
+// Assumes caller holds s.mu lock
+func (s *supervisor) idleAppExited(pid int) bool {
+	if s.idleApp == nil || s.idleApp.pid != pid {
+		return false
+	}
+
+	log.Printf("Idle app exited: %s (PID %d)", s.idleApp.name, pid)
+
+	showing := !s.hasForeground()
+	if showing && s.mirror != nil {
+		deactivateMirror(s.mirror)
+		s.mirror = nil
+	}
+
+	if err := closePTY(s.idleApp.ptyMaster); err != nil {
+		log.Printf("Warning: failed to close idle app PTY: %v", err)
+	}
+	s.idleApp.sandbox.release()
+	s.idleApp = nil
+
+	if showing && !s.shuttingDown && s.idleSpec != nil {
+		io.WriteString(s.sinks, "\x1b[2J\x1b[H\x1b[0m")
+		io.WriteString(s.sinks, s.idleSpec.Text)
+	}
+
+	return true
+}
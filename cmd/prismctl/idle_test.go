@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/state"
+)
+
+func TestSupervisor_SuspendForeground(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "test.state")
+	stateMgr, err := newStateManager(statePath, "test")
+	if err != nil {
+		t.Fatalf("newStateManager() error: %v", err)
+	}
+	defer stateMgr.Close()
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start sleep: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	sup := newSupervisor(&terminalState{}, stateMgr, nil)
+	sup.prismList = []prismInstance{{name: "clock", pid: cmd.Process.Pid, state: prismForeground}}
+	stateMgr.OnPrismStarted("clock", cmd.Process.Pid, true)
+
+	sup.mu.Lock()
+	if !sup.hasForeground() {
+		t.Fatal("hasForeground() = false before suspend")
+	}
+	sup.suspendForeground()
+	hasFg := sup.hasForeground()
+	sup.mu.Unlock()
+
+	if hasFg {
+		t.Error("hasForeground() = true after suspend, want idle")
+	}
+	if sup.prismList[0].name != "clock" || sup.prismList[0].state != prismBackground {
+		t.Errorf("prismList[0] = %s/%v, want clock in background at head of MRU", sup.prismList[0].name, sup.prismList[0].state)
+	}
+	if sup.isForegroundPID(cmd.Process.Pid) {
+		t.Error("isForegroundPID() = true for suspended prism")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		stopped, err := processStopped(cmd.Process.Pid)
+		if err == nil && stopped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("prism was not stopped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	reader, err := state.OpenPrismStateReader(statePath)
+	if err != nil {
+		t.Fatalf("OpenPrismStateReader() error: %v", err)
+	}
+	defer reader.Close()
+
+	s, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if fg := s.GetFgPrism(); fg != "" {
+		t.Errorf("FgPrism = %q, want empty while idle", fg)
+	}
+}
+
+func TestSupervisor_StopIdleAppReaped(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start sleep: %v", err)
+	}
+	pid := cmd.Process.Pid
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	sup := newSupervisor(&terminalState{}, nil, nil)
+	sup.idleApp = &prismInstance{name: "idle", pid: pid}
+
+	sup.mu.Lock()
+	sup.stopIdleApp()
+	sup.mu.Unlock()
+
+	if sup.idleApp != nil {
+		t.Error("idleApp still set after stopIdleApp()")
+	}
+	if !sup.stoppedIdle[pid] {
+		t.Fatal("stopped idle app is not tracked until reaped")
+	}
+
+	sup.handleChildExit(childExit{pid: pid, signal: 15})
+	if sup.stoppedIdle[pid] {
+		t.Error("stopped idle app still tracked after its exit was handled")
+	}
+}
@@ -58,3 +58,48 @@ func stopRPCServer(server *rpc.Server) {
 
 	log.Printf("RPC server stopped")
 }
+
+// registerWithShined completes shined's spawn handshake by reporting this
+// prismctl's PID, kitty window and socket. Retries briefly in case shined's
+// socket is not accepting yet; a prismctl started by hand without shined
+// simply logs and carries on.
+func registerWithShined(instance, socketPath string) {
+	req := &rpc.PanelRegisterRequest{
+		Instance: instance,
+		PID:      os.Getpid(),
+		WindowID: os.Getenv("KITTY_WINDOW_ID"),
+		Socket:   socketPath,
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < 5; attempt++ {
+		if attempt > 0 {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		client, err := rpc.NewShinedClient(paths.ShinedSocket(), rpc.WithTimeout(time.Second))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		result, err := client.RegisterPanel(ctx, req)
+		cancel()
+		client.Close()
+
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if result.Registered {
+			log.Printf("Registered with shined (PID %d, window %q)", req.PID, req.WindowID)
+		} else {
+			log.Printf("shined was not expecting instance %s; running unmanaged", instance)
+		}
+		return
+	}
+
+	log.Printf("Could not register with shined: %v", lastErr)
+}
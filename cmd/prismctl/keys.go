@@ -0,0 +1,213 @@
+// keys.go encodes named keys for prism/send into the byte sequences a
+// terminal sends for them (xterm conventions, which kitty also emits).
+//
+// Key names follow tmux send-keys: "Enter", "Esc", "Up", "F5", "C-c",
+// "M-x", "C-Up". Modifiers may also be spelled "ctrl-", "alt-" and "shift-"
+// (with "-" or "+"). Anything that is not a key name is sent as literal text.
+
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	pasteStart = "\x1b[200~"
+	pasteEnd   = "\x1b[201~"
+)
+
+var simpleKeys = map[string]string{
+	"enter":     "\r",
+	"return":    "\r",
+	"tab":       "\t",
+	"btab":      "\x1b[Z",
+	"esc":       "\x1b",
+	"escape":    "\x1b",
+	"space":     " ",
+	"bspace":    "\x7f",
+	"backspace": "\x7f",
+}
+
+// cursorKeys are CSI sequences with a final byte: ESC [ <final>, or
+// ESC [ 1 ; <mod> <final> when modified
+var cursorKeys = map[string]byte{
+	"up":    'A',
+	"down":  'B',
+	"right": 'C',
+	"left":  'D',
+	"home":  'H',
+	"end":   'F',
+}
+
+// tildeKeys are CSI sequences of the form ESC [ <code> ~, or
+// ESC [ <code> ; <mod> ~ when modified
+var tildeKeys = map[string]int{
+	"insert":   2,
+	"ic":       2,
+	"delete":   3,
+	"del":      3,
+	"dc":       3,
+	"pageup":   5,
+	"pgup":     5,
+	"ppage":    5,
+	"pagedown": 6,
+	"pgdn":     6,
+	"npage":    6,
+	"f5":       15,
+	"f6":       17,
+	"f7":       18,
+	"f8":       19,
+	"f9":       20,
+	"f10":      21,
+	"f11":      23,
+	"f12":      24,
+}
+
+// ss3Keys are ESC O <final> when unmodified
+var ss3Keys = map[string]byte{
+	"f1": 'P',
+	"f2": 'Q',
+	"f3": 'R',
+	"f4": 'S',
+}
+
+type keyMods struct {
+	ctrl, alt, shift bool
+}
+
+// param is the xterm modifier parameter: 1 + shift + 2*alt + 4*ctrl
+func (m keyMods) param() int {
+	p := 1
+	if m.shift {
+		p++
+	}
+	if m.alt {
+		p += 2
+	}
+	if m.ctrl {
+		p += 4
+	}
+	return p
+}
+
+// encodeKeys concatenates the encoding of each key
+func encodeKeys(keys []string) []byte {
+	var out []byte
+	for _, key := range keys {
+		out = append(out, encodeKey(key)...)
+	}
+	return out
+}
+
+// encodeKey returns the bytes for a key name, or the key itself as literal
+// text if it is not a recognised name
+func encodeKey(key string) []byte {
+	if seq, ok := namedKey(key); ok {
+		return []byte(seq)
+	}
+	return []byte(key)
+}
+
+func namedKey(key string) (string, bool) {
+	mods, base := splitMods(key)
+	name := strings.ToLower(base)
+
+	if seq, ok := simpleKeys[name]; ok {
+		switch {
+		case mods == keyMods{}:
+			return seq, true
+		case mods == keyMods{shift: true} && name == "tab":
+			return simpleKeys["btab"], true
+		case mods == keyMods{alt: true}:
+			return "\x1b" + seq, true
+		}
+		return "", false
+	}
+
+	if final, ok := cursorKeys[name]; ok {
+		if mods == (keyMods{}) {
+			return "\x1b[" + string(final), true
+		}
+		return "\x1b[1;" + strconv.Itoa(mods.param()) + string(final), true
+	}
+
+	if code, ok := tildeKeys[name]; ok {
+		if mods == (keyMods{}) {
+			return "\x1b[" + strconv.Itoa(code) + "~", true
+		}
+		return "\x1b[" + strconv.Itoa(code) + ";" + strconv.Itoa(mods.param()) + "~", true
+	}
+
+	if final, ok := ss3Keys[name]; ok {
+		if mods == (keyMods{}) {
+			return "\x1bO" + string(final), true
+		}
+		return "\x1b[1;" + strconv.Itoa(mods.param()) + string(final), true
+	}
+
+	// Modified single character: C-c, M-x, C-M-a
+	if len(base) == 1 && (mods.ctrl || mods.alt) && !mods.shift {
+		ch := base[0]
+		if mods.ctrl {
+			c, ok := ctrlChar(ch)
+			if !ok {
+				return "", false
+			}
+			ch = c
+		}
+		if mods.alt {
+			return "\x1b" + string(ch), true
+		}
+		return string(ch), true
+	}
+
+	return "", false
+}
+
+// splitMods strips modifier prefixes (C-, M-, S-, ctrl-, alt-, shift-, with
+// "-" or "+") from a key name
+func splitMods(key string) (keyMods, string) {
+	var mods keyMods
+	for {
+		lower := strings.ToLower(key)
+		matched := false
+		for _, m := range []struct {
+			prefix string
+			set    *bool
+		}{
+			{"ctrl", &mods.ctrl}, {"alt", &mods.alt}, {"meta", &mods.alt}, {"shift", &mods.shift},
+			{"c", &mods.ctrl}, {"m", &mods.alt}, {"s", &mods.shift},
+		} {
+			if len(lower) > len(m.prefix)+1 && strings.HasPrefix(lower, m.prefix) {
+				sep := lower[len(m.prefix)]
+				if sep == '-' || sep == '+' {
+					*m.set = true
+					key = key[len(m.prefix)+1:]
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			return mods, key
+		}
+	}
+}
+
+// ctrlChar maps a character to its control code (C-a = 0x01, C-@ = 0x00)
+func ctrlChar(ch byte) (byte, bool) {
+	switch {
+	case ch >= 'a' && ch <= 'z':
+		return ch - 'a' + 1, true
+	case ch >= 'A' && ch <= 'Z':
+		return ch - 'A' + 1, true
+	case ch >= '@' && ch <= '_':
+		return ch - '@', true
+	case ch == ' ':
+		return 0, true
+	case ch == '?':
+		return 0x7f, true
+	}
+	return 0, false
+}
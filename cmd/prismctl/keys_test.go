@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEncodeKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"Enter", "\r"},
+		{"esc", "\x1b"},
+		{"Tab", "\t"},
+		{"S-Tab", "\x1b[Z"},
+		{"BSpace", "\x7f"},
+		{"Up", "\x1b[A"},
+		{"C-Up", "\x1b[1;5A"},
+		{"shift+left", "\x1b[1;2D"},
+		{"PageDown", "\x1b[6~"},
+		{"C-Delete", "\x1b[3;5~"},
+		{"F1", "\x1bOP"},
+		{"F12", "\x1b[24~"},
+		{"C-c", "\x03"},
+		{"ctrl-a", "\x01"},
+		{"C-[", "\x1b"},
+		{"M-x", "\x1bx"},
+		{"C-M-a", "\x1b\x01"},
+		{"M-Enter", "\x1b\r"},
+		{"hello", "hello"},
+		{"c-3po", "c-3po"},
+		{"C-", "C-"},
+	}
+
+	for _, tt := range tests {
+		if got := string(encodeKey(tt.key)); got != tt.want {
+			t.Errorf("encodeKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeKeys(t *testing.T) {
+	got := string(encodeKeys([]string{"ls", "Space", "-la", "Enter"}))
+	if want := "ls -la\r"; got != want {
+		t.Errorf("encodeKeys() = %q, want %q", got, want)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/starbased-co/shine/pkg/paths"
 )
@@ -31,8 +33,76 @@ func setupLogging() error {
 	return nil
 }
 
+// options are prismctl's command-line flags
+type options struct {
+	instance string
+	headless bool
+	cols     int
+	rows     int
+}
+
+// Virtual terminal size when --headless is given without --size
+const defaultHeadlessCols, defaultHeadlessRows = 80, 24
+
+// parseArgs parses `prismctl [--headless [--size COLSxROWS]] <instance>`
+func parseArgs(args []string) (*options, error) {
+	opts := &options{cols: defaultHeadlessCols, rows: defaultHeadlessRows}
+	var sized bool
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--headless":
+			opts.headless = true
+		case arg == "--size" || strings.HasPrefix(arg, "--size="):
+			value, found := strings.CutPrefix(arg, "--size=")
+			if !found {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("--size requires a value like 80x24")
+				}
+				i++
+				value = args[i]
+			}
+			cols, rows, err := parseSize(value)
+			if err != nil {
+				return nil, err
+			}
+			opts.cols, opts.rows, sized = cols, rows, true
+		case strings.HasPrefix(arg, "-"):
+			return nil, fmt.Errorf("unknown flag: %s", arg)
+		case opts.instance == "":
+			opts.instance = arg
+		default:
+			return nil, fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+
+	if opts.instance == "" {
+		return nil, fmt.Errorf("missing instance name")
+	}
+	if sized && !opts.headless {
+		return nil, fmt.Errorf("--size requires --headless; kitty sets the size of a panel")
+	}
+
+	return opts, nil
+}
+
+// parseSize parses COLSxROWS, e.g. 80x24
+func parseSize(s string) (int, int, error) {
+	c, r, ok := strings.Cut(strings.ToLower(s), "x")
+	cols, errC := strconv.Atoi(c)
+	rows, errR := strconv.Atoi(r)
+	if !ok || errC != nil || errR != nil || cols <= 0 || rows <= 0 || cols > 0xffff || rows > 0xffff {
+		return 0, 0, fmt.Errorf("invalid size %q, want COLSxROWS like 80x24", s)
+	}
+	return cols, rows, nil
+}
 
 func main() {
+	if len(os.Args) >= 2 && os.Args[1] == sandboxExecArg {
+		runSandboxExec(os.Args[2:])
+	}
+
 	if len(os.Args) >= 2 {
 		arg := os.Args[1]
 		if arg == "-h" || arg == "--help" {
@@ -59,14 +129,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	instanceName := os.Args[1]
+	opts, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "prismctl: %v\n", err)
+		os.Exit(1)
+	}
+
+	instanceName := opts.instance
 	log.Printf("prismctl starting (instance: %s)", instanceName)
 
-	termState, err := newTerminalState()
-	if err != nil {
-		log.Fatalf("Failed to initialize terminal state: %v", err)
+	var termState *terminalState
+	if opts.headless {
+		termState, err = newHeadlessTerminalState(opts.cols, opts.rows)
+		if err != nil {
+			log.Fatalf("Failed to create virtual terminal: %v", err)
+		}
+		defer termState.close()
+		log.Printf("Running headless on a %dx%d virtual terminal", opts.cols, opts.rows)
+	} else {
+		termState, err = newTerminalState()
+		if err != nil {
+			log.Fatalf("Failed to initialize terminal state: %v", err)
+		}
+		log.Printf("Terminal state saved")
 	}
-	log.Printf("Terminal state saved")
 
 	statePath := paths.PrismState(instanceName)
 	stateMgr, err := newStateManager(statePath, instanceName)
@@ -81,6 +167,8 @@ func main() {
 	log.Printf("Notification manager started")
 
 	sup := newSupervisor(termState, stateMgr, notifyMgr)
+	metricsRegistry.OnGather(sup.sampleUsage)
+	go sup.runUsageSampler(usageInterval)
 
 	sigHandler := newSignalHandler(sup)
 	defer sigHandler.stop()
@@ -91,6 +179,14 @@ func main() {
 	}
 	defer stopRPCServer(rpcServer)
 
+	attachServer, err := startAttachServer(instanceName, sup)
+	if err != nil {
+		log.Printf("Warning: failed to start attach server: %v", err)
+	}
+	defer attachServer.stop()
+
+	go registerWithShined(instanceName, rpcServer.SocketPath())
+
 	log.Printf("prismctl running (PID %d), awaiting configuration via RPC", os.Getpid())
 	sigHandler.run()
 
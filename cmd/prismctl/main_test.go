@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		want     options
+		wantFail bool
+	}{
+		{args: []string{"bar"}, want: options{instance: "bar", cols: 80, rows: 24}},
+		{args: []string{"--headless", "bar"}, want: options{instance: "bar", headless: true, cols: 80, rows: 24}},
+		{args: []string{"--headless", "--size", "120x40", "bar"}, want: options{instance: "bar", headless: true, cols: 120, rows: 40}},
+		{args: []string{"bar", "--headless", "--size=100X30"}, want: options{instance: "bar", headless: true, cols: 100, rows: 30}},
+		{args: []string{}, wantFail: true},
+		{args: []string{"--size", "80x24", "bar"}, wantFail: true},
+		{args: []string{"--headless", "--size", "80", "bar"}, wantFail: true},
+		{args: []string{"--headless", "--size", "0x24", "bar"}, wantFail: true},
+		{args: []string{"--headless", "--size"}, wantFail: true},
+		{args: []string{"--verbose", "bar"}, wantFail: true},
+		{args: []string{"bar", "baz"}, wantFail: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseArgs(tt.args)
+		if tt.wantFail {
+			if err == nil {
+				t.Errorf("parseArgs(%q) = %+v, want error", tt.args, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseArgs(%q) error: %v", tt.args, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("parseArgs(%q) = %+v, want %+v", tt.args, *got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// metrics.go defines the metrics prismctl exports over service/metrics
+
+package main
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/metrics"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	metricsRegistry = metrics.NewRegistry()
+
+	swapLatency = metricsRegistry.Histogram("prismctl_swap_latency_seconds",
+		"Time taken to hand the panel to another prism", metrics.LatencyBuckets)
+	prismRestarts = metricsRegistry.Counter("prismctl_prism_restarts_total",
+		"Prisms relaunched in place", "prism")
+	prismCrashes = metricsRegistry.Counter("prismctl_prism_crashes_total",
+		"Prisms that exited with a non-zero code or a signal", "prism", "exit_code", "signal")
+	rpcDuration = metricsRegistry.Histogram("prismctl_rpc_duration_seconds",
+		"Time taken to handle RPC calls", metrics.LatencyBuckets, "method")
+	rpcErrors = metricsRegistry.Counter("prismctl_rpc_errors_total",
+		"RPC calls that returned an error", "method")
+	notificationsDropped = metricsRegistry.Counter("prismctl_notifications_dropped_total",
+		"Notifications to shined that were not delivered", "reason")
+	healthCheckFailures = metricsRegistry.Counter("prismctl_health_check_failures_total",
+		"Failed prism health checks", "prism")
+	prismCPU = metricsRegistry.Gauge("prismctl_prism_cpu_seconds",
+		"CPU time consumed by each running prism since it was launched", "prism")
+	prismRSS = metricsRegistry.Gauge("prismctl_prism_rss_bytes",
+		"Resident memory of each running prism", "prism")
+)
+
+// Reasons a notification is dropped
+const (
+	dropDisconnected = "disconnected"
+	dropSendFailed   = "send-failed"
+)
+
+// observeRPC records an RPC call's latency and outcome
+func observeRPC(method string, elapsed time.Duration, err error) {
+	rpcDuration.Observe(elapsed.Seconds(), method)
+	if err != nil {
+		rpcErrors.Inc(method)
+	}
+}
+
+// recordCrash counts a prism's abnormal exit
+func recordCrash(name string, exit childExit) {
+	signal := ""
+	if exit.signal != 0 {
+		signal = unix.SignalName(unix.Signal(exit.signal))
+	}
+	prismCrashes.Inc(name, strconv.Itoa(exit.exitCode), signal)
+}
+
+// sampleUsage refreshes the per-prism CPU and memory gauges from /proc
+func (s *supervisor) sampleUsage() {
+	s.mu.Lock()
+	prisms := make(map[string]int, len(s.prismList))
+	for _, p := range s.prismList {
+		prisms[p.name] = p.pid
+	}
+	s.mu.Unlock()
+
+	prismCPU.Reset()
+	prismRSS.Reset()
+	for name, pid := range prisms {
+		usage, err := metrics.ReadProcessUsage(pid)
+		if err != nil {
+			log.Printf("Metrics: failed to sample %s (PID %d): %v", name, pid, err)
+			continue
+		}
+		prismCPU.Set(usage.CPUSeconds, name)
+		prismRSS.Set(float64(usage.RSSBytes), name)
+	}
+}
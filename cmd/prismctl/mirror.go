@@ -1,6 +1,6 @@
 // mirror.go implements bidirectional I/O mirroring between the real PTY and
 // child PTYs. The mirror reflects user input to the foreground prism and
-// prism output back to the terminal.
+// prism output back to the terminal and any attached clients.
 
 package main
 
@@ -12,7 +12,11 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+	"github.com/starbased-co/shine/pkg/screen"
 )
 
 type mirrorState struct {
@@ -21,15 +25,255 @@ type mirrorState struct {
 	wg       sync.WaitGroup
 	active   bool
 	childPTY *os.File
+	output   *prismOutput // PTY reader the mirror is attached to, if any
+}
+
+type mirrorOption func(*mirrorOptions)
+
+type mirrorOptions struct {
+	lastOutput *atomic.Int64
+	screen     io.Writer
+	sinks      *sinkSet
+	output     *prismOutput
+}
+
+// withOutputActivity records the unix ms of the last prism output in last
+func withOutputActivity(last *atomic.Int64) mirrorOption {
+	return func(o *mirrorOptions) {
+		o.lastOutput = last
+	}
+}
+
+// withScreen also feeds prism output to a terminal emulator for prism/capture
+func withScreen(screen io.Writer) mirrorOption {
+	return func(o *mirrorOptions) {
+		o.screen = screen
+	}
+}
+
+// withPrismOutput takes output from the prism's PTY reader instead of
+// reading the child PTY, which cannot be handed back once read
+func withPrismOutput(output *prismOutput) mirrorOption {
+	return func(o *mirrorOptions) {
+		o.output = output
+	}
+}
+
+// withSinks sends prism output to every sink in sinks instead of os.Stdout
+func withSinks(sinks *sinkSet) mirrorOption {
+	return func(o *mirrorOptions) {
+		o.sinks = sinks
+	}
+}
+
+// sinkSet fans prism output out to the real terminal and attached clients.
+// A client that fails a write is dropped; the rest keep receiving output.
+// The terminal is never dropped.
+type sinkSet struct {
+	mu       sync.Mutex
+	terminal io.Writer
+	clients  []io.Writer
+
+	// The prism being mirrored, so a new client is painted from the screen
+	// its output will continue
+	prism  string
+	screen *screen.Screen
+}
+
+func newSinkSet(terminal io.Writer) *sinkSet {
+	if terminal == nil {
+		terminal = io.Discard
+	}
+	return &sinkSet{terminal: terminal}
+}
+
+func (ss *sinkSet) Write(p []byte) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.writeLocked(p)
+	return len(p), nil
+}
+
+func (ss *sinkSet) writeLocked(p []byte) {
+	ss.terminal.Write(p)
+	ss.writeClientsLocked(p)
+}
+
+func (ss *sinkSet) writeClientsLocked(p []byte) {
+	kept := ss.clients[:0]
+	for _, w := range ss.clients {
+		if _, err := w.Write(p); err != nil {
+			log.Printf("Mirror: dropping attached client after write error: %v", err)
+			continue
+		}
+		kept = append(kept, w)
+	}
+	ss.clients = kept
+}
+
+// setForeground records the prism now being mirrored, or "" and nil while
+// the idle screen or a notice shows
+func (ss *sinkSet) setForeground(prism string, sc *screen.Screen) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.prism, ss.screen = prism, sc
+}
+
+// add registers a client. paint, if set, runs first under the sink lock with
+// the prism being mirrored, so the client starts from a complete picture
+// without missing or repeating output.
+func (ss *sinkSet) add(w io.Writer, paint func(w io.Writer, prism string, sc *screen.Screen) error) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if paint != nil {
+		if err := paint(w, ss.prism, ss.screen); err != nil {
+			return err
+		}
+	}
+	ss.clients = append(ss.clients, w)
+	return nil
+}
+
+func (ss *sinkSet) remove(w io.Writer) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for i, sink := range ss.clients {
+		if sink == w {
+			ss.clients = append(ss.clients[:i], ss.clients[i+1:]...)
+			return
+		}
+	}
+}
+
+// resize tells attached clients the panel is now cols x rows and repaints
+// them from the prism's resized screen
+func (ss *sinkSet) resize(cols, rows int) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if len(ss.clients) == 0 {
+		return
+	}
+	notice := []byte(rpc.AttachResizeNotice(cols, rows))
+	if ss.screen != nil {
+		notice = append(notice, paintScreen(ss.screen)...)
+	}
+	ss.writeClientsLocked(notice)
+}
+
+// count returns the number of attached clients
+func (ss *sinkSet) count() int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return len(ss.clients)
+}
+
+// screenTee feeds the screen and the sinks under the sink lock, keeping the
+// screen consistent with what sinks have seen when a paint runs
+type screenTee struct {
+	sinks  *sinkSet
+	screen io.Writer
+}
+
+func (t *screenTee) Write(p []byte) (int, error) {
+	t.sinks.mu.Lock()
+	defer t.sinks.mu.Unlock()
+	t.screen.Write(p)
+	t.sinks.writeLocked(p)
+	return len(p), nil
+}
+
+// activityWriter stamps the time of every write before passing it through
+type activityWriter struct {
+	w    io.Writer
+	last *atomic.Int64
+}
+
+func (a *activityWriter) Write(p []byte) (int, error) {
+	a.last.Store(time.Now().UnixMilli())
+	return a.w.Write(p)
+}
+
+// prismOutput reads a prism's PTY for the prism's whole life, so that only
+// one reader ever consumes its output. While a mirror is attached output
+// goes to the mirror; otherwise it only updates the prism's screen. A
+// starting prism is watched for the ready OSC sequence either way, so it can
+// signal readiness from the background.
+type prismOutput struct {
+	mu         sync.Mutex
+	mirror     io.Writer // attached mirror's output, nil while not mirrored
+	background io.Writer
+}
+
+func startPrismOutput(childPTY *os.File, background io.Writer, ready *readiness) *prismOutput {
+	po := &prismOutput{background: background}
+
+	var output io.Writer = po
+	if ready.watchesOutput() {
+		output = &readyScanner{w: po, ready: ready}
+	}
+
+	go func() {
+		if _, err := io.Copy(output, childPTY); err != nil {
+			if err != io.EOF && err != io.ErrClosedPipe && !isExpectedPTYError(err) {
+				log.Printf("Prism output error: %v", err)
+			}
+		}
+	}()
+	return po
+}
+
+// Write never fails, so that a failing destination does not stop the reader
+func (po *prismOutput) Write(p []byte) (int, error) {
+	po.mu.Lock()
+	defer po.mu.Unlock()
+	if po.mirror != nil {
+		po.mirror.Write(p)
+	} else {
+		po.background.Write(p)
+	}
+	return len(p), nil
+}
+
+// attach sends output to w until detach
+func (po *prismOutput) attach(w io.Writer) {
+	po.mu.Lock()
+	defer po.mu.Unlock()
+	po.mirror = w
+}
+
+// detach returns output to the background writer. Once it returns, nothing
+// more is written to the mirror.
+func (po *prismOutput) detach() {
+	po.mu.Lock()
+	defer po.mu.Unlock()
+	po.mirror = nil
 }
 
 // activateMirror launches bidirectional copy between Real PTY and child PTY
 // Real PTY (stdin/stdout) ↔ child PTY master (foreground prism)
-func activateMirror(ctx context.Context, realPTY *os.File, childPTY *os.File) (*mirrorState, error) {
+func activateMirror(ctx context.Context, realPTY *os.File, childPTY *os.File, opts ...mirrorOption) (*mirrorState, error) {
 	if realPTY == nil || childPTY == nil {
 		return nil, fmt.Errorf("cannot activate mirror with nil PTY")
 	}
 
+	var options mirrorOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var output io.Writer = os.Stdout
+	switch {
+	case options.sinks != nil && options.screen != nil:
+		output = &screenTee{sinks: options.sinks, screen: options.screen}
+	case options.sinks != nil:
+		output = options.sinks
+	case options.screen != nil:
+		output = io.MultiWriter(output, options.screen)
+	}
+	if options.lastOutput != nil {
+		output = &activityWriter{w: output, last: options.lastOutput}
+	}
+
 	// Clear any previous read deadline (from deactivateMirror)
 	if err := childPTY.SetReadDeadline(time.Time{}); err != nil {
 		log.Printf("Warning: failed to clear read deadline: %v", err)
@@ -42,9 +286,10 @@ func activateMirror(ctx context.Context, realPTY *os.File, childPTY *os.File) (*
 		cancel:   cancel,
 		active:   true,
 		childPTY: childPTY,
+		output:   options.output,
 	}
 
-	state.wg.Add(2)
+	state.wg.Add(1)
 
 	// Real PTY → child PTY (user input to prism)
 	go func() {
@@ -61,14 +306,19 @@ func activateMirror(ctx context.Context, realPTY *os.File, childPTY *os.File) (*
 	}()
 
 	// child PTY → Real PTY (prism output to terminal)
-	go func() {
-		defer state.wg.Done()
-		if _, err := io.Copy(os.Stdout, childPTY); err != nil {
-			if err != io.EOF && err != io.ErrClosedPipe && !isExpectedPTYError(err) {
-				log.Printf("Mirror (child→real) error: %v", err)
+	if options.output != nil {
+		options.output.attach(output)
+	} else {
+		state.wg.Add(1)
+		go func() {
+			defer state.wg.Done()
+			if _, err := io.Copy(output, childPTY); err != nil {
+				if err != io.EOF && err != io.ErrClosedPipe && !isExpectedPTYError(err) {
+					log.Printf("Mirror (child→real) error: %v", err)
+				}
 			}
-		}
-	}()
+		}()
+	}
 
 	log.Printf("Mirror activated: Real PTY ↔ child PTY (fd %d)", childPTY.Fd())
 
@@ -82,8 +332,11 @@ func deactivateMirror(state *mirrorState) {
 
 	state.cancel()
 
-	// Force child PTY io.Copy to return by setting deadline
-	if state.childPTY != nil {
+	// Force child PTY io.Copy to return by setting deadline, unless the
+	// prism's own reader feeds the mirror and must keep reading
+	if state.output != nil {
+		state.output.detach()
+	} else if state.childPTY != nil {
 		state.childPTY.SetReadDeadline(time.Unix(0, 0))
 	}
 
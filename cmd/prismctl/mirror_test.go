@@ -6,6 +6,8 @@ import (
 	"os"
 	"testing"
 	"time"
+
+	"github.com/starbased-co/shine/pkg/screen"
 )
 
 func TestActivateMirror_NilRealPTY(t *testing.T) {
@@ -188,6 +190,41 @@ func TestActivateMirror_DataFlow(t *testing.T) {
 	deactivateMirror(state)
 }
 
+func TestActivateMirror_FeedsScreen(t *testing.T) {
+	realR, realW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create real pipe: %v", err)
+	}
+	defer realW.Close()
+
+	childR, childW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create child pipe: %v", err)
+	}
+	defer childW.Close()
+
+	sc := screen.New(20, 2, 0)
+	state, err := activateMirror(context.Background(), realR, childR, withScreen(sc))
+	if err != nil {
+		t.Fatalf("activateMirror() unexpected error: %v", err)
+	}
+	defer deactivateMirror(state)
+
+	childW.Write([]byte("\x1b[1mclock\x1b[0m 12:00"))
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		text, _ := sc.Render(screen.FormatText, false)
+		if text == "clock 12:00\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("screen = %q, want %q", text, "clock 12:00\n")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestDeactivateMirror_NilState(t *testing.T) {
 	deactivateMirror(nil)
 }
@@ -98,6 +98,7 @@ func (nm *NotificationManager) sendNotification(fn func(context.Context, *rpc.Sh
 
 	if !connected || client == nil {
 		// Not connected - silently skip
+		notificationsDropped.Inc(dropDisconnected)
 		return
 	}
 
@@ -107,6 +108,7 @@ func (nm *NotificationManager) sendNotification(fn func(context.Context, *rpc.Sh
 
 	if err := fn(ctx, client); err != nil {
 		log.Printf("Notification: failed to send: %v", err)
+		notificationsDropped.Inc(dropSendFailed)
 
 		// Mark as disconnected and trigger reconnect
 		nm.mu.Lock()
@@ -135,10 +137,17 @@ func (nm *NotificationManager) OnPrismStopped(name string, exitCode int) {
 	})
 }
 
-func (nm *NotificationManager) OnPrismCrashed(name string, exitCode, signal int) {
-	log.Printf("Notification: prism crashed %s (exit=%d, signal=%d)", name, exitCode, signal)
+func (nm *NotificationManager) OnPrismCrashed(name string, exitCode, signal int, reason string) {
+	log.Printf("Notification: prism crashed %s (exit=%d, signal=%d, reason=%q)", name, exitCode, signal, reason)
 	nm.sendNotification(func(ctx context.Context, c *rpc.ShinedClient) error {
-		return c.NotifyPrismCrashed(ctx, nm.instance, name, exitCode, signal)
+		return c.NotifyPrismCrashed(ctx, nm.instance, name, exitCode, signal, reason)
+	})
+}
+
+func (nm *NotificationManager) OnPrismHealthChanged(name, status, message string) {
+	log.Printf("Notification: prism health %s → %s (%s)", name, status, message)
+	nm.sendNotification(func(ctx context.Context, c *rpc.ShinedClient) error {
+		return c.NotifyPrismHealth(ctx, nm.instance, name, status, message)
 	})
 }
 
@@ -149,6 +158,13 @@ func (nm *NotificationManager) OnForegroundChanged(from, to string) {
 	})
 }
 
+func (nm *NotificationManager) OnPanelClosing(reason string) {
+	log.Printf("Notification: panel closing (%s)", reason)
+	nm.sendNotification(func(ctx context.Context, c *rpc.ShinedClient) error {
+		return c.NotifyPanelClosing(ctx, nm.instance, reason)
+	})
+}
+
 func (nm *NotificationManager) Close() {
 	close(nm.stopC)
 
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+// notifySocketEnv names the sd_notify socket in a prism's environment
+const notifySocketEnv = "NOTIFY_SOCKET"
+
+// notifySocketSeq keeps notify socket paths unique across relaunches
+var notifySocketSeq atomic.Int64
+
+// readiness tracks whether a freshly launched prism has signalled that it
+// finished starting. Prisms without a ready spec are ready at launch and
+// carry a nil readiness; all methods are safe to call on nil.
+type readiness struct {
+	spec *rpc.ReadySpec
+	done chan struct{}
+	once sync.Once
+	err  error
+
+	notify *net.UnixConn // sd_notify socket, nil unless spec.Type is notify
+	path   string        // notify socket path
+}
+
+// newReadiness prepares to receive a prism's ready signal. For notify
+// readiness it listens on a fresh datagram socket in dir.
+func newReadiness(spec *rpc.ReadySpec, dir, prismName string) (*readiness, error) {
+	r := &readiness{spec: spec, done: make(chan struct{})}
+
+	if spec.Type == rpc.ReadyNotify {
+		r.path = filepath.Join(dir, fmt.Sprintf("prism-%d-%s-%d.notify", os.Getpid(), prismName, notifySocketSeq.Add(1)))
+		os.Remove(r.path)
+
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: r.path, Net: "unixgram"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on notify socket: %w", err)
+		}
+		r.notify = conn
+		go r.readNotify()
+	}
+
+	return r, nil
+}
+
+// env returns the variables that tell the prism how to signal readiness
+func (r *readiness) env() []string {
+	if r == nil || r.notify == nil {
+		return nil
+	}
+	return []string{notifySocketEnv + "=" + r.path}
+}
+
+// arm starts the start timeout once the prism is running. onTimeout runs
+// if the prism has not become ready by then.
+func (r *readiness) arm(onTimeout func(timeout time.Duration)) {
+	if r == nil {
+		return
+	}
+	timeout := time.Duration(r.spec.TimeoutMs) * time.Millisecond
+	time.AfterFunc(timeout, func() {
+		if r.finish(fmt.Errorf("not ready within %v", timeout)) {
+			onTimeout(timeout)
+		}
+	})
+}
+
+// readNotify reads sd_notify datagrams until READY=1 arrives or the socket
+// is closed
+func (r *readiness) readNotify() {
+	buf := make([]byte, 4096)
+	for {
+		n, _, err := r.notify.ReadFromUnix(buf)
+		if err != nil {
+			return
+		}
+		for _, line := range bytes.Split(buf[:n], []byte("\n")) {
+			if string(line) == "READY=1" {
+				r.finish(nil)
+				return
+			}
+		}
+	}
+}
+
+// markReady records that the prism signalled readiness
+func (r *readiness) markReady() {
+	if r == nil {
+		return
+	}
+	r.finish(nil)
+}
+
+// fail records that the prism will never become ready, e.g. because it exited
+func (r *readiness) fail(err error) {
+	if r == nil {
+		return
+	}
+	r.finish(err)
+}
+
+// finish settles readiness once and releases the notify socket. Reports
+// whether this call was the one that settled it.
+func (r *readiness) finish(err error) bool {
+	settled := false
+	r.once.Do(func() {
+		settled = true
+		r.err = err
+		if r.notify != nil {
+			r.notify.Close()
+			os.Remove(r.path)
+		}
+		close(r.done)
+	})
+	return settled
+}
+
+// pending reports whether the prism is still starting
+func (r *readiness) pending() bool {
+	if r == nil {
+		return false
+	}
+	select {
+	case <-r.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// wait blocks until the prism is ready or has failed to start
+func (r *readiness) wait() error {
+	if r == nil {
+		return nil
+	}
+	<-r.done
+	return r.err
+}
+
+// watchesOutput reports whether readiness is signalled in PTY output
+func (r *readiness) watchesOutput() bool {
+	return r != nil && r.spec.Type == rpc.ReadyOSC
+}
+
+var (
+	readyOSCBEL = []byte(rpc.ReadyOSCSequence + "\x07")
+	readyOSCST  = []byte(rpc.ReadyOSCSequence + "\x1b\\")
+)
+
+// readyScanner passes prism output through, watching for the OSC sequence
+// a prism writes to signal readiness. The sequence may span writes.
+type readyScanner struct {
+	w     io.Writer
+	ready *readiness
+	tail  []byte
+}
+
+func (rs *readyScanner) Write(p []byte) (int, error) {
+	if rs.ready.pending() {
+		buf := append(rs.tail, p...)
+		if bytes.Contains(buf, readyOSCBEL) || bytes.Contains(buf, readyOSCST) {
+			log.Printf("Prism signalled readiness via OSC")
+			rs.ready.markReady()
+			rs.tail = nil
+		} else {
+			keep := min(len(buf), len(readyOSCST)-1)
+			rs.tail = append([]byte(nil), buf[len(buf)-keep:]...)
+		}
+	}
+	return rs.w.Write(p)
+}
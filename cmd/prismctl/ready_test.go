@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+func TestReadiness_Notify(t *testing.T) {
+	ready, err := newReadiness(&rpc.ReadySpec{Type: rpc.ReadyNotify, TimeoutMs: 5000}, t.TempDir(), "clock")
+	if err != nil {
+		t.Fatalf("newReadiness() error: %v", err)
+	}
+
+	env := ready.env()
+	if len(env) != 1 || !strings.HasPrefix(env[0], notifySocketEnv+"=") {
+		t.Fatalf("env() = %v, want %s", env, notifySocketEnv)
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: ready.path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("DialUnix() error: %v", err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("STATUS=loading"))
+	time.Sleep(20 * time.Millisecond)
+	if !ready.pending() {
+		t.Fatal("ready after a datagram without READY=1")
+	}
+
+	conn.Write([]byte("STATUS=done\nREADY=1"))
+	if err := ready.wait(); err != nil {
+		t.Errorf("wait() error: %v", err)
+	}
+}
+
+func TestReadiness_Timeout(t *testing.T) {
+	ready, err := newReadiness(&rpc.ReadySpec{Type: rpc.ReadyOSC, TimeoutMs: 20}, t.TempDir(), "clock")
+	if err != nil {
+		t.Fatalf("newReadiness() error: %v", err)
+	}
+
+	timedOut := make(chan time.Duration, 1)
+	ready.arm(func(timeout time.Duration) { timedOut <- timeout })
+
+	if err := ready.wait(); err == nil {
+		t.Fatal("wait() should fail after the start timeout")
+	}
+	select {
+	case <-timedOut:
+	case <-time.After(time.Second):
+		t.Fatal("timeout callback not called")
+	}
+
+	// Signalling after the timeout changes nothing
+	ready.markReady()
+	if err := ready.wait(); err == nil {
+		t.Error("markReady() after the timeout overrode the failure")
+	}
+}
+
+func TestReadyScanner_SplitSequence(t *testing.T) {
+	ready, _ := newReadiness(&rpc.ReadySpec{Type: rpc.ReadyOSC, TimeoutMs: 5000}, t.TempDir(), "clock")
+
+	var out bytes.Buffer
+	rs := &readyScanner{w: &out, ready: ready}
+
+	seq := rpc.ReadyOSCSequence + "\x1b\\"
+	rs.Write([]byte("loading..." + seq[:6]))
+	if !ready.pending() {
+		t.Fatal("ready after half the sequence")
+	}
+	rs.Write([]byte(seq[6:] + "done"))
+	if ready.pending() {
+		t.Error("not ready after the whole sequence")
+	}
+
+	if got := out.String(); got != "loading..."+seq+"done" {
+		t.Errorf("output = %q, want everything passed through", got)
+	}
+}
+
+func TestSupervisor_StartWaitsForReady(t *testing.T) {
+	shPath, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	ts, err := newHeadlessTerminalState(80, 24)
+	if err != nil {
+		t.Fatalf("newHeadlessTerminalState() error: %v", err)
+	}
+	defer ts.close()
+
+	sup := newSupervisor(ts, nil, nil)
+	defer sup.shutdown(rpc.PanelExitRequested)
+
+	script := `sleep 0.2; printf '\033]777;shine;ready\007'; exec sleep 30`
+	sup.registerApp("slow", &appSpec{path: shPath, args: []string{"-c", script}, ready: &rpc.ReadySpec{Type: rpc.ReadyOSC, TimeoutMs: 5000}})
+	sup.registerApp("stuck", &appSpec{path: shPath, args: []string{"-c", "exec sleep 30"}, ready: &rpc.ReadySpec{Type: rpc.ReadyOSC, TimeoutMs: 100}})
+
+	begin := time.Now()
+	if err := sup.start("slow"); err != nil {
+		t.Fatalf("start(slow) error: %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed < 200*time.Millisecond {
+		t.Errorf("start(slow) returned after %v, before the app was ready", elapsed)
+	}
+
+	if err := sup.start("stuck"); err == nil || !strings.Contains(err.Error(), "not ready within") {
+		t.Fatalf("start(stuck) error = %v, want a start timeout", err)
+	}
+
+	// The stuck app is killed and removed; slow comes back to the foreground
+	reaper := &signalHandler{supervisor: sup}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		reaper.handleSIGCHLD()
+
+		sup.mu.Lock()
+		stuck := sup.findPrism("stuck")
+		fg := ""
+		if sup.hasForeground() {
+			fg = sup.prismList[0].name
+		}
+		sup.mu.Unlock()
+
+		if stuck == -1 && fg == "slow" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("after start timeout: stuck index %d, foreground %q", stuck, fg)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sup.mu.Lock()
+	defer sup.mu.Unlock()
+	if len(sup.readyTimeout) != 0 {
+		t.Errorf("readyTimeout = %v, want the killed PID cleared once reaped", sup.readyTimeout)
+	}
+}
+
+func TestSupervisor_BackgroundPrismSignalsReady(t *testing.T) {
+	shPath, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+
+	ts, err := newHeadlessTerminalState(80, 24)
+	if err != nil {
+		t.Fatalf("newHeadlessTerminalState() error: %v", err)
+	}
+	defer ts.close()
+
+	sup := newSupervisor(ts, nil, nil)
+	defer sup.shutdown(rpc.PanelExitRequested)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		reaper := &signalHandler{supervisor: sup}
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				reaper.handleSIGCHLD()
+			}
+		}
+	}()
+
+	script := `sleep 0.2; printf '\033]777;shine;ready\007'; exec sleep 30`
+	sup.registerApp("slow", &appSpec{path: shPath, args: []string{"-c", script}, ready: &rpc.ReadySpec{Type: rpc.ReadyOSC, TimeoutMs: 1000}})
+	sup.registerApp("clock", &appSpec{path: sleepPath, args: []string{"30"}})
+
+	// readyOf returns slow's current PID and readiness
+	readyOf := func() (int, *readiness) {
+		sup.mu.Lock()
+		defer sup.mu.Unlock()
+		idx := sup.findPrism("slow")
+		if idx == -1 {
+			t.Fatal("slow is no longer running")
+		}
+		return sup.prismList[idx].pid, sup.prismList[idx].ready
+	}
+
+	// Sent to the background while still starting
+	sup.mu.Lock()
+	_, err = sup.startLocked("slow")
+	sup.mu.Unlock()
+	if err != nil {
+		t.Fatalf("startLocked(slow) error: %v", err)
+	}
+	if err := sup.start("clock"); err != nil {
+		t.Fatalf("start(clock) error: %v", err)
+	}
+	pid, ready := readyOf()
+	if err := ready.wait(); err != nil {
+		t.Fatalf("slow started in the background: %v", err)
+	}
+
+	// Relaunched in the background
+	if err := sup.restartPrism("slow", restartKillTimeout); err != nil {
+		t.Fatalf("restartPrism(slow) error: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		newPID, newReady := readyOf()
+		if newPID != pid {
+			ready = newReady
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("slow was not relaunched")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := ready.wait(); err != nil {
+		t.Fatalf("slow relaunched in the background: %v", err)
+	}
+
+	sup.mu.Lock()
+	defer sup.mu.Unlock()
+	if sup.prismList[0].name != "clock" {
+		t.Errorf("foreground = %q, want clock to stay in front", sup.prismList[0].name)
+	}
+	if len(sup.readyTimeout) != 0 {
+		t.Errorf("readyTimeout = %v, want no start timeouts", sup.readyTimeout)
+	}
+}
@@ -0,0 +1,185 @@
+// reconcile.go implements prism/configure as a declarative reconcile: the
+// request lists the apps a panel should run, and the supervisor starts,
+// restarts and stops prisms until the running set matches it.
+
+package main
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+// newAppSpec builds the launch configuration for an app from its RPC form
+func newAppSpec(app rpc.AppInfo) *appSpec {
+	return &appSpec{
+		path:    app.Path,
+		args:    app.Args,
+		env:     app.Env,
+		sandbox: app.Sandbox,
+		health:  app.Health,
+		ready:   app.Ready,
+		lazy:    app.Lazy,
+	}
+}
+
+// launchChanges lists the settings that differ between two launch
+// configurations and need a relaunch to take effect. Health, readiness and
+// laziness are picked up on the next launch without forcing one.
+func (a *appSpec) launchChanges(b *appSpec) []string {
+	var changes []string
+	if a.path != b.path {
+		changes = append(changes, "path")
+	}
+	if !slices.Equal(a.args, b.args) {
+		changes = append(changes, "args")
+	}
+	if !slices.Equal(a.env, b.env) {
+		changes = append(changes, "env")
+	}
+	if !reflect.DeepEqual(a.sandbox, b.sandbox) {
+		changes = append(changes, "sandbox")
+	}
+	return changes
+}
+
+// reconcile brings the running prisms in line with apps. Listed apps are
+// started or, if their launch configuration changed, restarted; registered
+// apps missing from the list are stopped. The foreground prism keeps the
+// panel if it survives, otherwise defaultApp takes it.
+func (s *supervisor) reconcile(ctx context.Context, apps []rpc.AppInfo, defaultApp string) []rpc.AppResult {
+	s.mu.Lock()
+	var foreground string
+	if s.hasForeground() {
+		foreground = s.prismList[0].name
+	}
+	s.mu.Unlock()
+
+	results := make([]rpc.AppResult, 0, len(apps))
+	listed := make(map[string]bool)
+	failed := make(map[string]bool)
+	for _, app := range apps {
+		if !app.Enabled {
+			continue
+		}
+		listed[app.Name] = true
+
+		result := s.reconcileApp(ctx, app, app.Name == defaultApp)
+		if result.Action == rpc.AppFailed {
+			log.Printf("Failed to reconcile app %s: %s", app.Name, result.Reason)
+			failed[app.Name] = true
+		}
+		results = append(results, result)
+	}
+
+	// Registered apps no longer listed; prisms launched ad hoc with
+	// prism/up were never registered and are left alone
+	s.mu.Lock()
+	var removed []string
+	for name := range s.apps {
+		if !listed[name] {
+			removed = append(removed, name)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(removed)
+
+	// Settle the foreground before stopping anything, so a removed prism is
+	// never the one left holding the panel
+	target := defaultApp
+	if foreground != "" && !slices.Contains(removed, foreground) && !failed[foreground] {
+		target = foreground
+	}
+	if target != "" && !failed[target] {
+		s.mu.Lock()
+		available := s.findPrism(target) != -1 || s.apps[target] != nil
+		s.mu.Unlock()
+		if available {
+			if err := s.start(target); err != nil {
+				log.Printf("Failed to foreground %s: %v", target, err)
+			}
+		}
+	}
+
+	for _, name := range removed {
+		results = append(results, s.removeApp(name))
+	}
+
+	return results
+}
+
+// reconcileApp registers one listed app and starts or restarts it as needed
+func (s *supervisor) reconcileApp(ctx context.Context, app rpc.AppInfo, isDefault bool) rpc.AppResult {
+	spec := newAppSpec(app)
+	prev := s.registerApp(app.Name, spec)
+
+	s.mu.Lock()
+	running := s.findPrism(app.Name) != -1
+	s.mu.Unlock()
+
+	result := rpc.AppResult{Name: app.Name}
+	switch {
+	case running && prev != nil:
+		changes := prev.launchChanges(spec)
+		if len(changes) == 0 {
+			result.Action = rpc.AppUnchanged
+			return result
+		}
+		result.Reason = strings.Join(changes, ", ") + " changed"
+		if _, err := s.restartAndWait(ctx, app.Name); err != nil {
+			result.Action = rpc.AppFailed
+			result.Reason = err.Error()
+			return result
+		}
+		result.Action = rpc.AppRestarted
+
+	case running:
+		// Launched with prism/up before it was configured
+		result.Action = rpc.AppUnchanged
+		result.Reason = "already running"
+
+	case app.Lazy && !isDefault:
+		result.Action = rpc.AppRegistered
+		result.Reason = "lazy"
+
+	default:
+		// Each start takes the foreground and waits for the app to be
+		// ready, so apps come up one at a time in the order given
+		if err := s.start(app.Name); err != nil {
+			result.Action = rpc.AppFailed
+			result.Reason = err.Error()
+			return result
+		}
+		result.Action = rpc.AppStarted
+		result.Reason = "new"
+		if prev != nil {
+			result.Reason = "not running"
+		}
+	}
+
+	return result
+}
+
+// removeApp unregisters an app and stops its prism if it is running
+func (s *supervisor) removeApp(name string) rpc.AppResult {
+	s.unregisterApp(name)
+
+	s.mu.Lock()
+	running := s.findPrism(name) != -1
+	s.mu.Unlock()
+
+	result := rpc.AppResult{Name: name, Action: rpc.AppStopped, Reason: "removed"}
+	if !running {
+		return result
+	}
+	if err := s.killPrism(name); err != nil {
+		result.Action = rpc.AppFailed
+		result.Reason = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,433 @@
+// sandbox.go confines prisms according to the [sandbox] section of their
+// prism config.
+//
+// Limits that must be applied from inside the child (rlimits, no-new-privs,
+// mounts) are handled by re-executing prismctl as a small helper which sets
+// them up and then execs the prism binary:
+//
+//   prismctl __sandbox-exec -- /path/to/prism [args...]
+//
+// Namespaces are created by the kernel at clone time (CLONE_NEWUSER plus
+// CLONE_NEWNET and/or CLONE_NEWNS), and the child is placed directly into a
+// private cgroup v2 scope via CLONE_INTO_CGROUP so memory.max/cpu.max apply
+// from the first instruction.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	sandboxExecArg = "__sandbox-exec"
+	sandboxSpecEnv = "SHINE_SANDBOX_SPEC"
+
+	cgroupRoot = "/sys/fs/cgroup"
+)
+
+// Linux capability numbers used for the helper's ambient set
+const (
+	capNetAdmin = 12
+	capSysAdmin = 21
+)
+
+type sandbox struct {
+	spec   *rpc.SandboxSpec
+	cgroup *cgroupScope
+}
+
+// needsHelper reports whether limits must be applied from inside the child
+func needsHelper(spec *rpc.SandboxSpec) bool {
+	return spec.MemoryBytes > 0 || spec.CPUSeconds > 0 || spec.OpenFiles > 0 ||
+		spec.NoNewPrivs || spec.NoNetwork || spec.ReadOnlyHome
+}
+
+func needsCgroup(spec *rpc.SandboxSpec) bool {
+	return spec.MemoryMaxBytes > 0 || spec.CPUMaxPercent > 0
+}
+
+// prepareSandbox rewrites cmd so it runs under spec. scopeName identifies the
+// cgroup scope created for the child. Returns nil if spec is nil.
+func prepareSandbox(cmd *exec.Cmd, scopeName string, spec *rpc.SandboxSpec) (*sandbox, error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	sb := &sandbox{spec: spec}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	if needsHelper(spec) {
+		self, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate prismctl executable: %w", err)
+		}
+
+		encoded, err := json.Marshal(spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode sandbox spec: %w", err)
+		}
+
+		args := append([]string{cmd.Path}, cmd.Args[1:]...)
+		cmd.Path = self
+		cmd.Args = append([]string{"prismctl", sandboxExecArg, "--"}, args...)
+
+		env := cmd.Env
+		if env == nil {
+			env = os.Environ()
+		}
+		cmd.Env = append(env, sandboxSpecEnv+"="+string(encoded))
+	}
+
+	if spec.NoNetwork || spec.ReadOnlyHome {
+		attr := cmd.SysProcAttr
+		attr.Cloneflags |= unix.CLONE_NEWUSER
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+		attr.GidMappingsEnableSetgroups = false
+
+		// The helper needs these inside the new user namespace; it clears the
+		// ambient set again before exec'ing the prism.
+		if spec.NoNetwork {
+			attr.Cloneflags |= unix.CLONE_NEWNET
+			attr.AmbientCaps = append(attr.AmbientCaps, capNetAdmin)
+		}
+		if spec.ReadOnlyHome {
+			attr.Cloneflags |= unix.CLONE_NEWNS
+			attr.AmbientCaps = append(attr.AmbientCaps, capSysAdmin)
+		}
+	}
+
+	if needsCgroup(spec) {
+		scope, err := createCgroupScope(scopeName, spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cgroup scope: %w", err)
+		}
+		sb.cgroup = scope
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = int(scope.dir.Fd())
+	}
+
+	return sb, nil
+}
+
+// started releases resources only needed while the child is being created
+func (sb *sandbox) started() {
+	if sb == nil || sb.cgroup == nil {
+		return
+	}
+	sb.cgroup.closeDir()
+}
+
+// crashReason classifies an exit caused by one of the sandbox limits.
+// Returns an empty string for ordinary crashes.
+func (sb *sandbox) crashReason(signal int, cpuTime time.Duration) string {
+	if sb == nil {
+		return ""
+	}
+
+	if sb.cgroup != nil && sb.cgroup.oomKills() > 0 {
+		return rpc.CrashReasonMemoryLimit
+	}
+
+	if sb.spec.CPUSeconds > 0 {
+		limit := time.Duration(sb.spec.CPUSeconds) * time.Second
+		if signal == int(unix.SIGXCPU) || (signal == int(unix.SIGKILL) && cpuTime >= limit) {
+			return rpc.CrashReasonCPULimit
+		}
+	}
+
+	return ""
+}
+
+// release tears down the cgroup scope once the child has exited
+func (sb *sandbox) release() {
+	if sb == nil || sb.cgroup == nil {
+		return
+	}
+	sb.cgroup.remove()
+}
+
+type cgroupScope struct {
+	path string
+	dir  *os.File
+}
+
+// createCgroupScope creates a cgroup next to prismctl's own. prismctl's
+// cgroup holds processes, so by the no-internal-processes rule it cannot
+// hand controllers down to children of its own.
+func createCgroupScope(name string, spec *rpc.SandboxSpec) (*cgroupScope, error) {
+	own, err := ownCgroup()
+	if err != nil {
+		return nil, err
+	}
+
+	parent := filepath.Join(cgroupRoot, filepath.Dir(own))
+
+	// May fail if already enabled or not delegated; writing the limits below
+	// reports the real problem.
+	_ = os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+memory +cpu"), 0644)
+
+	path := filepath.Join(parent, sanitizeScopeName(name)+".scope")
+	if err := os.Mkdir(path, 0755); err != nil {
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		log.Printf("Warning: reusing stale cgroup %s", path)
+	}
+
+	scope := &cgroupScope{path: path}
+
+	if spec.MemoryMaxBytes > 0 {
+		if err := scope.write("memory.max", strconv.FormatInt(spec.MemoryMaxBytes, 10)); err != nil {
+			scope.remove()
+			return nil, err
+		}
+	}
+
+	if spec.CPUMaxPercent > 0 {
+		const period = 100000
+		quota := spec.CPUMaxPercent * period / 100
+		if err := scope.write("cpu.max", fmt.Sprintf("%d %d", quota, period)); err != nil {
+			scope.remove()
+			return nil, err
+		}
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		scope.remove()
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	scope.dir = dir
+
+	log.Printf("Created cgroup scope %s", path)
+	return scope, nil
+}
+
+func (c *cgroupScope) write(file, value string) error {
+	if err := os.WriteFile(filepath.Join(c.path, file), []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to set %s: %w", file, err)
+	}
+	return nil
+}
+
+// oomKills returns the number of processes killed for exceeding memory.max
+func (c *cgroupScope) oomKills() int {
+	data, err := os.ReadFile(filepath.Join(c.path, "memory.events"))
+	if err != nil {
+		return 0
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			n, _ := strconv.Atoi(fields[1])
+			return n
+		}
+	}
+	return 0
+}
+
+func (c *cgroupScope) closeDir() {
+	if c.dir != nil {
+		c.dir.Close()
+		c.dir = nil
+	}
+}
+
+func (c *cgroupScope) remove() {
+	c.closeDir()
+
+	if err := os.Remove(c.path); err == nil || os.IsNotExist(err) {
+		return
+	}
+
+	// Descendants of the prism may still be alive
+	_ = c.write("cgroup.kill", "1")
+	time.Sleep(10 * time.Millisecond)
+
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: failed to remove cgroup %s: %v", c.path, err)
+	}
+}
+
+// ownCgroup returns prismctl's cgroup v2 path relative to the hierarchy root
+func ownCgroup() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", fmt.Errorf("failed to read /proc/self/cgroup: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("cgroup v2 hierarchy not available")
+}
+
+func sanitizeScopeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// runSandboxExec is the helper entrypoint. It runs inside the child, applies
+// the sandbox spec from the environment and execs the prism. Never returns.
+func runSandboxExec(args []string) {
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "prismctl sandbox: no command given")
+		os.Exit(127)
+	}
+
+	var spec rpc.SandboxSpec
+	if err := json.Unmarshal([]byte(os.Getenv(sandboxSpecEnv)), &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "prismctl sandbox: invalid spec: %v\n", err)
+		os.Exit(126)
+	}
+	os.Unsetenv(sandboxSpecEnv)
+
+	// no_new_privs is a per-thread attribute; it must be set on the thread
+	// that calls execve
+	runtime.LockOSThread()
+
+	if err := applySandbox(&spec); err != nil {
+		fmt.Fprintf(os.Stderr, "prismctl sandbox: %v\n", err)
+		os.Exit(126)
+	}
+
+	err := unix.Exec(args[0], args, os.Environ())
+	fmt.Fprintf(os.Stderr, "prismctl sandbox: exec %s: %v\n", args[0], err)
+	os.Exit(127)
+}
+
+func applySandbox(spec *rpc.SandboxSpec) error {
+	if spec.NoNetwork {
+		if err := bringUpLoopback(); err != nil {
+			return fmt.Errorf("failed to bring up loopback: %w", err)
+		}
+	}
+
+	if spec.ReadOnlyHome {
+		if err := mountReadOnlyHome(spec.WritablePaths); err != nil {
+			return err
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to clear ambient capabilities: %w", err)
+	}
+
+	if spec.MemoryBytes > 0 {
+		limit := uint64(spec.MemoryBytes)
+		if err := unix.Setrlimit(unix.RLIMIT_AS, &unix.Rlimit{Cur: limit, Max: limit}); err != nil {
+			return fmt.Errorf("failed to set RLIMIT_AS: %w", err)
+		}
+	}
+
+	if spec.CPUSeconds > 0 {
+		// SIGXCPU at the soft limit, SIGKILL one second later
+		if err := unix.Setrlimit(unix.RLIMIT_CPU, &unix.Rlimit{Cur: spec.CPUSeconds, Max: spec.CPUSeconds + 1}); err != nil {
+			return fmt.Errorf("failed to set RLIMIT_CPU: %w", err)
+		}
+	}
+
+	if spec.OpenFiles > 0 {
+		if err := unix.Setrlimit(unix.RLIMIT_NOFILE, &unix.Rlimit{Cur: spec.OpenFiles, Max: spec.OpenFiles}); err != nil {
+			return fmt.Errorf("failed to set RLIMIT_NOFILE: %w", err)
+		}
+	}
+
+	if spec.NoNewPrivs {
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return fmt.Errorf("failed to set no_new_privs: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// mountReadOnlyHome remounts $HOME read-only inside the child's private mount
+// namespace, keeping each of writable bind-mounted read-write on top.
+func mountReadOnlyHome(writable []string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	// Keep our mounts from propagating back to the parent namespace
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+
+	if err := unix.Mount(home, home, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("failed to bind %s: %w", home, err)
+	}
+
+	for _, path := range writable {
+		if _, err := os.Stat(path); err != nil {
+			log.Printf("Warning: skipping writable path %s: %v", path, err)
+			continue
+		}
+		if err := unix.Mount(path, path, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind writable path %s: %w", path, err)
+		}
+	}
+
+	// Locked flags inherited from the parent namespace must be preserved on
+	// remount, otherwise the kernel refuses with EPERM
+	var st unix.Statfs_t
+	if err := unix.Statfs(home, &st); err != nil {
+		return fmt.Errorf("failed to stat %s: %w", home, err)
+	}
+	locked := uintptr(st.Flags) & (unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC |
+		unix.MS_NOATIME | unix.MS_NODIRATIME | unix.MS_RELATIME)
+
+	if err := unix.Mount("", home, "", unix.MS_BIND|unix.MS_REMOUNT|unix.MS_RDONLY|locked, ""); err != nil {
+		return fmt.Errorf("failed to remount %s read-only: %w", home, err)
+	}
+
+	return nil
+}
+
+func bringUpLoopback() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
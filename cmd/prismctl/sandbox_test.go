@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+	"golang.org/x/sys/unix"
+)
+
+func TestPrepareSandbox_NilSpec(t *testing.T) {
+	cmd := exec.Command("/bin/true")
+
+	sb, err := prepareSandbox(cmd, "test", nil)
+	if err != nil {
+		t.Fatalf("prepareSandbox() error: %v", err)
+	}
+	if sb != nil {
+		t.Error("prepareSandbox() with nil spec should return nil sandbox")
+	}
+	if cmd.Path != "/bin/true" {
+		t.Errorf("cmd.Path = %q, want unchanged /bin/true", cmd.Path)
+	}
+}
+
+func TestPrepareSandbox_WrapsWithHelper(t *testing.T) {
+	cmd := exec.Command("/bin/echo", "hello")
+	spec := &rpc.SandboxSpec{OpenFiles: 64, NoNewPrivs: true}
+
+	sb, err := prepareSandbox(cmd, "test", spec)
+	if err != nil {
+		t.Fatalf("prepareSandbox() error: %v", err)
+	}
+	if sb == nil {
+		t.Fatal("prepareSandbox() returned nil sandbox")
+	}
+
+	self, _ := os.Executable()
+	if cmd.Path != self {
+		t.Errorf("cmd.Path = %q, want %q", cmd.Path, self)
+	}
+
+	want := []string{"prismctl", sandboxExecArg, "--", "/bin/echo", "hello"}
+	if strings.Join(cmd.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("cmd.Args = %v, want %v", cmd.Args, want)
+	}
+
+	var encoded string
+	for _, kv := range cmd.Env {
+		if v, ok := strings.CutPrefix(kv, sandboxSpecEnv+"="); ok {
+			encoded = v
+		}
+	}
+	if encoded == "" {
+		t.Fatalf("%s not set in child environment", sandboxSpecEnv)
+	}
+
+	var decoded rpc.SandboxSpec
+	if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+		t.Fatalf("failed to decode spec: %v", err)
+	}
+	if decoded.OpenFiles != 64 || !decoded.NoNewPrivs {
+		t.Errorf("decoded spec = %+v, want open_files=64 no_new_privs=true", decoded)
+	}
+
+	if cmd.SysProcAttr.Cloneflags != 0 {
+		t.Errorf("Cloneflags = %#x, want 0 without namespaces", cmd.SysProcAttr.Cloneflags)
+	}
+}
+
+func TestPrepareSandbox_Namespaces(t *testing.T) {
+	cmd := exec.Command("/bin/true")
+	spec := &rpc.SandboxSpec{NoNetwork: true, ReadOnlyHome: true}
+
+	if _, err := prepareSandbox(cmd, "test", spec); err != nil {
+		t.Fatalf("prepareSandbox() error: %v", err)
+	}
+
+	flags := cmd.SysProcAttr.Cloneflags
+	for _, want := range []uintptr{unix.CLONE_NEWUSER, unix.CLONE_NEWNET, unix.CLONE_NEWNS} {
+		if flags&want == 0 {
+			t.Errorf("Cloneflags = %#x, missing %#x", flags, want)
+		}
+	}
+
+	if len(cmd.SysProcAttr.UidMappings) != 1 || cmd.SysProcAttr.UidMappings[0].HostID != os.Getuid() {
+		t.Errorf("UidMappings = %+v, want identity mapping for current user", cmd.SysProcAttr.UidMappings)
+	}
+}
+
+func TestSandbox_CrashReason(t *testing.T) {
+	sb := &sandbox{spec: &rpc.SandboxSpec{CPUSeconds: 5}}
+
+	tests := []struct {
+		name    string
+		signal  int
+		cpuTime time.Duration
+		want    string
+	}{
+		{"sigxcpu", int(unix.SIGXCPU), 5 * time.Second, rpc.CrashReasonCPULimit},
+		{"sigkill at hard limit", int(unix.SIGKILL), 6 * time.Second, rpc.CrashReasonCPULimit},
+		{"sigkill below limit", int(unix.SIGKILL), time.Second, ""},
+		{"segfault", int(unix.SIGSEGV), 6 * time.Second, ""},
+		{"clean exit", 0, 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := sb.crashReason(tt.signal, tt.cpuTime); got != tt.want {
+			t.Errorf("%s: crashReason() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	var none *sandbox
+	if got := none.crashReason(int(unix.SIGXCPU), 0); got != "" {
+		t.Errorf("nil sandbox crashReason() = %q, want empty", got)
+	}
+}
+
+func TestCgroupScope_OOMKills(t *testing.T) {
+	dir := t.TempDir()
+	events := "low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\noom_group_kill 0\n"
+	if err := os.WriteFile(dir+"/memory.events", []byte(events), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scope := &cgroupScope{path: dir}
+	if got := scope.oomKills(); got != 1 {
+		t.Errorf("oomKills() = %d, want 1", got)
+	}
+
+	sb := &sandbox{spec: &rpc.SandboxSpec{MemoryMaxBytes: 1 << 20}, cgroup: scope}
+	if got := sb.crashReason(int(unix.SIGKILL), 0); got != rpc.CrashReasonMemoryLimit {
+		t.Errorf("crashReason() = %q, want %q", got, rpc.CrashReasonMemoryLimit)
+	}
+}
+
+func TestSanitizeScopeName(t *testing.T) {
+	if got := sanitizeScopeName("shine-42-my app/v2"); got != "shine-42-my_app_v2" {
+		t.Errorf("sanitizeScopeName() = %q, want %q", got, "shine-42-my_app_v2")
+	}
+}
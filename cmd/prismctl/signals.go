@@ -18,14 +18,20 @@
 //
 // SIGTERM/SIGHUP
 //   - Full graceful shutdown of all prisms
+//   - SIGHUP means kitty closed the window; shined is told so it won't restart
 
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/starbased-co/shine/pkg/rpc"
 	"golang.org/x/sys/unix"
 )
 
@@ -74,23 +80,28 @@ func (sh *signalHandler) handleSIGCHLD() {
 	// Reap all exited children
 	for {
 		var status unix.WaitStatus
-		pid, err := unix.Wait4(-1, &status, unix.WNOHANG, nil)
+		var usage unix.Rusage
+		pid, err := unix.Wait4(-1, &status, unix.WNOHANG, &usage)
 		if err != nil || pid <= 0 {
 			// No more children to reap
 			break
 		}
 
 		// Notify supervisor of child exit
-		exitCode := 0
+		exit := childExit{
+			pid:     pid,
+			cpuTime: time.Duration(usage.Utime.Nano() + usage.Stime.Nano()),
+		}
 		if status.Exited() {
-			exitCode = status.ExitStatus()
-			log.Printf("Child %d exited with code %d", pid, exitCode)
+			exit.exitCode = status.ExitStatus()
+			log.Printf("Child %d exited with code %d", pid, exit.exitCode)
 		} else if status.Signaled() {
-			exitCode = 128 + int(status.Signal())
+			exit.signal = int(status.Signal())
+			exit.exitCode = 128 + exit.signal
 			log.Printf("Child %d terminated by signal %s", pid, status.Signal())
 		}
 
-		sh.supervisor.handleChildExit(pid, exitCode)
+		sh.supervisor.handleChildExit(exit)
 	}
 }
 
@@ -98,7 +109,8 @@ func (sh *signalHandler) handleSIGCHLD() {
 // Returns true if prismctl should shutdown (exit signal loop)
 func (sh *signalHandler) handleSIGINT() bool {
 	sh.supervisor.mu.Lock()
-	hasForeground := len(sh.supervisor.prismList) > 0
+	hasForeground := sh.supervisor.hasForeground()
+	hasPrisms := len(sh.supervisor.prismList) > 0
 	var foregroundName string
 	if hasForeground {
 		foregroundName = sh.supervisor.prismList[0].name
@@ -115,6 +127,10 @@ func (sh *signalHandler) handleSIGINT() bool {
 		// Note: killPrism is async - handleChildExit will clean up
 		// User can press Ctrl+C again to exit if no more prisms
 		return false // Keep running, let signal loop process SIGCHLD
+	} else if hasPrisms {
+		// The idle screen is showing; background prisms are left alone
+		log.Printf("Ctrl+C: no foreground prism, ignoring")
+		return false
 	} else {
 		// No prisms running, shutdown prismctl
 		log.Printf("Ctrl+C: no prisms running, shutting down")
@@ -125,7 +141,14 @@ func (sh *signalHandler) handleSIGINT() bool {
 
 func (sh *signalHandler) handleShutdown(sig os.Signal) {
 	log.Printf("Received %s, shutting down gracefully", sig)
-	sh.supervisor.shutdown()
+
+	// Kitty sends SIGHUP when the user closes the panel window
+	reason := rpc.PanelExitTerminated
+	if sig == unix.SIGHUP {
+		reason = rpc.PanelExitWindowClosed
+	}
+
+	sh.supervisor.shutdown(reason)
 }
 
 func (sh *signalHandler) handleSIGWINCH() {
@@ -136,3 +159,25 @@ func (sh *signalHandler) stop() {
 	signal.Stop(sh.sigCh)
 	close(sh.sigCh)
 }
+
+// parseSignal accepts a signal name with or without the SIG prefix, in any
+// case, or a signal number
+func parseSignal(s string) (unix.Signal, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		if n <= 0 || unix.SignalName(unix.Signal(n)) == "" {
+			return 0, fmt.Errorf("unknown signal: %s", s)
+		}
+		return unix.Signal(n), nil
+	}
+
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig := unix.SignalNum(name)
+	if sig == 0 {
+		return 0, fmt.Errorf("unknown signal: %s", s)
+	}
+	return sig, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    unix.Signal
+		wantErr bool
+	}{
+		{"SIGUSR1", unix.SIGUSR1, false},
+		{"usr1", unix.SIGUSR1, false},
+		{" HUP ", unix.SIGHUP, false},
+		{"15", unix.SIGTERM, false},
+		{"SIGBOGUS", 0, true},
+		{"0", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSignal(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSignal(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSignal(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSupervisor_SignalPrism(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start sleep: %v", err)
+	}
+
+	sup := newSupervisor(&terminalState{}, nil, nil)
+	sup.prismList = []prismInstance{{name: "clock", pid: cmd.Process.Pid, state: prismForeground}}
+
+	if _, err := sup.signalPrism("missing", unix.SIGUSR1); err == nil {
+		t.Error("signalPrism() on unknown prism should fail")
+	}
+
+	pid, err := sup.signalPrism("clock", unix.SIGUSR1)
+	if err != nil {
+		t.Fatalf("signalPrism() error: %v", err)
+	}
+	if pid != cmd.Process.Pid {
+		t.Errorf("signalPrism() pid = %d, want %d", pid, cmd.Process.Pid)
+	}
+
+	// sleep does not handle SIGUSR1, so it terminates
+	err = cmd.Wait()
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if err == nil || !ok || status.Signal() != unix.SIGUSR1 {
+		t.Errorf("sleep exited with %v, want termination by SIGUSR1", err)
+	}
+}
+
+func TestSignalHandler_SIGINTIdle(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start sleep: %v", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		<-exited
+	})
+
+	// A backgrounded prism heads the MRU list while the idle screen shows
+	sup := newSupervisor(&terminalState{}, nil, nil)
+	sup.prismList = []prismInstance{{name: "clock", pid: cmd.Process.Pid, state: prismBackground}}
+	sh := &signalHandler{supervisor: sup}
+
+	if sh.handleSIGINT() {
+		t.Error("handleSIGINT() = true, want prismctl to keep running")
+	}
+	select {
+	case <-exited:
+		t.Error("background prism was killed")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if len(sup.prismList) != 1 {
+		t.Errorf("prismList has %d prisms, want 1", len(sup.prismList))
+	}
+}
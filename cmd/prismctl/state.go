@@ -41,6 +41,14 @@ func (s *StateManager) OnPrismStopped(name string) {
 	s.writer.RemovePrism(name)
 }
 
+func (s *StateManager) OnPrismRestarted(name string, pid, restarts int) {
+	log.Printf("State: prism restarted %s (PID %d, restarts=%d)", name, pid, restarts)
+	if restarts > 255 {
+		restarts = 255
+	}
+	s.writer.RestartPrism(name, int32(pid), uint8(restarts))
+}
+
 func (s *StateManager) OnForegroundChanged(name string) {
 	log.Printf("State: foreground changed to %s", name)
 	s.writer.SetForeground(name)
@@ -51,6 +59,17 @@ func (s *StateManager) OnPrismResumed(name string) {
 	s.OnForegroundChanged(name)
 }
 
+// OnUsageSampled records a prism's latest resource usage
+func (s *StateManager) OnUsageSampled(name string, usage prismUsage) {
+	s.writer.SetUsage(name, state.PrismUsage{
+		CPUPercent: usage.cpuPercent,
+		RSSBytes:   usage.rssBytes,
+		Threads:    usage.threads,
+		Children:   usage.children,
+		ProcState:  usage.state,
+	})
+}
+
 func (s *StateManager) UpdatePrism(index int, name string, pid int, fg bool, restarts uint8) {
 	stateVal := state.PrismStateBg
 	if fg {
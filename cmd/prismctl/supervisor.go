@@ -3,13 +3,18 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/starbased-co/shine/pkg/paths"
+	"github.com/starbased-co/shine/pkg/rpc"
+	"github.com/starbased-co/shine/pkg/screen"
 	"golang.org/x/sys/unix"
 )
 
@@ -21,10 +26,18 @@ const (
 )
 
 type prismInstance struct {
-	name      string
-	pid       int
-	state     prismState
-	ptyMaster *os.File
+	name       string
+	pid        int
+	state      prismState
+	ptyMaster  *os.File
+	sandbox    *sandbox
+	startTime  time.Time
+	restarts   int
+	lastOutput *atomic.Int64 // unix ms of last PTY output, shared across copies
+	health     *healthMonitor
+	screen     *screen.Screen // rendered PTY output, for prism/capture
+	ready      *readiness     // ready signal, nil if the app has no ready spec
+	output     *prismOutput   // sole reader of ptyMaster, for the prism's life
 }
 
 type supervisor struct {
@@ -39,12 +52,34 @@ type supervisor struct {
 	shuttingDown bool
 	stateManager *StateManager
 	notifyMgr    *NotificationManager
-	appPaths     map[string]string // App name → resolved binary path
+	apps         map[string]*appSpec // App name → launch configuration
+	restarting   map[int]bool        // PIDs killed to be relaunched in place
+	readyTimeout map[int]bool        // PIDs killed for not becoming ready in time
+	idleSpec     *rpc.IdleSpec       // Idle screen shown while no prism is foreground
+	idleApp      *prismInstance      // Running idle app, suspended while a prism is foreground
+	stoppedIdle  map[int]bool        // PIDs of idle apps stopped but not yet reaped
+	sinks        *sinkSet            // Where mirrored output goes: the panel plus attached clients
+	usage        map[int]prismUsage  // PID → last resource usage sample
+}
+
+// appSpec is the launch configuration registered for an app via
+// prism/configure. New per-app settings go here as fields rather than as
+// registerApp parameters, so callers and tests name only what they set.
+type appSpec struct {
+	path    string   // resolved binary path
+	args    []string // command-line arguments
+	env     []string // extra KEY=VALUE environment entries
+	sandbox *rpc.SandboxSpec
+	health  *rpc.HealthCheckSpec
+	ready   *rpc.ReadySpec
+	lazy    bool // launched on first prism/fg rather than at configure
 }
 
 type childExit struct {
 	pid      int
 	exitCode int
+	signal   int           // terminating signal, 0 for a normal exit
+	cpuTime  time.Duration // user + system CPU time consumed
 }
 
 func newSupervisor(termState *terminalState, stateMgr *StateManager, notifyMgr *NotificationManager) *supervisor {
@@ -59,7 +94,11 @@ func newSupervisor(termState *terminalState, stateMgr *StateManager, notifyMgr *
 		mirrorCancel: cancel,
 		stateManager:  stateMgr,
 		notifyMgr:     notifyMgr,
-		appPaths:      make(map[string]string),
+		apps:          make(map[string]*appSpec),
+		restarting:    make(map[int]bool),
+		readyTimeout:  make(map[int]bool),
+		stoppedIdle:   make(map[int]bool),
+		sinks:         newSinkSet(termState.output()),
 	}
 }
 
@@ -72,83 +111,169 @@ func (s *supervisor) findPrism(name string) int {
 	return -1
 }
 
-func (s *supervisor) registerApp(name, path string) {
+// registerApp records the launch configuration for an app and returns the
+// one it replaces, if any
+func (s *supervisor) registerApp(name string, app *appSpec) *appSpec {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.apps[name]
+	s.apps[name] = app
+	return prev
+}
+
+// unregisterApp forgets an app's launch configuration
+func (s *supervisor) unregisterApp(name string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.appPaths[name] = path
+	delete(s.apps, name)
+}
+
+// isLazy reports whether name is a registered lazy app.
+// Assumes caller holds s.mu lock
+func (s *supervisor) isLazy(name string) bool {
+	app, ok := s.apps[name]
+	return ok && app.lazy
 }
 
 func (s *supervisor) startPrism(prismName string) error {
 	return s.start(prismName)
 }
 
-// start implements idempotent launch/resume with three cases
+// start implements idempotent launch/resume with three cases. It returns
+// once the prism is ready, which for an app with a ready spec is when it
+// signals readiness rather than when it is launched.
 func (s *supervisor) start(prismName string) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	ready, err := s.startLocked(prismName)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
 
+	if err := ready.wait(); err != nil {
+		return fmt.Errorf("prism %s failed to start: %w", prismName, err)
+	}
+	return nil
+}
+
+// startLocked launches or resumes a prism, returning its readiness.
+// Assumes caller holds s.mu lock
+func (s *supervisor) startLocked(prismName string) (*readiness, error) {
 	targetIdx := s.findPrism(prismName)
 	if targetIdx == -1 {
-		return s.launchAndForeground(prismName)
+		if err := s.launchAndForeground(prismName); err != nil {
+			return nil, err
+		}
+		return s.prismList[0].ready, nil
 	}
 
-	if targetIdx == 0 {
+	ready := s.prismList[targetIdx].ready
+	if targetIdx == 0 && s.hasForeground() {
 		log.Printf("Prism %s already in foreground", prismName)
-		return nil
+		return ready, nil
 	}
 
-	return s.resumeToForeground(targetIdx)
+	return ready, s.resumeToForeground(targetIdx)
 }
 
 // launchAndForeground launches a new prism and brings it to foreground
 // Assumes caller holds s.mu lock
 func (s *supervisor) launchAndForeground(prismName string) error {
+	log.Printf("Launching new prism: %s", prismName)
+
+	if s.hasForeground() {
+		old := s.prismList[0]
+		log.Printf("Suspending current foreground %s (PID %d)", old.name, old.pid)
+		s.suspend(old)
+		s.prismList[0].state = prismBackground
+	} else {
+		s.hideIdle()
+	}
+
+	// CRITICAL: Reset terminal state
+	log.Printf("Resetting terminal state")
+	if err := s.termState.resetTerminalState(); err != nil {
+		log.Printf("Warning: failed to reset terminal state: %v", err)
+	}
+
+	// Stabilization delay
+	time.Sleep(10 * time.Millisecond)
+
+	newInstance, err := s.spawnPrism(prismName)
+	if err != nil {
+		return err
+	}
+	newInstance.state = prismForeground
+	s.prismList = append([]prismInstance{newInstance}, s.prismList...)
+
+	if err := s.activateMirrorToForeground(); err != nil {
+		log.Printf("Warning: failed to start mirror: %v", err)
+	}
+
+	if s.stateManager != nil {
+		s.stateManager.OnPrismStarted(prismName, newInstance.pid, true)
+	}
+
+	if s.notifyMgr != nil {
+		s.notifyMgr.OnPrismStarted(prismName, newInstance.pid)
+	}
+
+	return nil
+}
+
+// spawnPrism starts the prism's process on a fresh PTY sized like the real
+// terminal. The caller decides where the instance goes in the MRU list.
+// Assumes caller holds s.mu lock
+func (s *supervisor) spawnPrism(prismName string) (prismInstance, error) {
 	var binaryPath string
+	var app *appSpec
 	var err error
 
-	if path, ok := s.appPaths[prismName]; ok && path != "" {
-		binaryPath = path
+	if registered, ok := s.apps[prismName]; ok {
+		app = registered
+		binaryPath = app.path
 	}
 
 	if binaryPath == "" {
 		binaryPath, err = exec.LookPath(prismName)
 		if err != nil {
-			return fmt.Errorf("prism not found in PATH: %s (%w)", prismName, err)
+			return prismInstance{}, fmt.Errorf("prism not found in PATH: %s (%w)", prismName, err)
 		}
 	}
 
-	log.Printf("Launching new prism: %s (resolved to %s)", prismName, binaryPath)
-
-	if len(s.prismList) > 0 {
-		old := s.prismList[0]
-		log.Printf("Suspending current foreground %s (PID %d)", old.name, old.pid)
-		if err := unix.Kill(old.pid, unix.SIGSTOP); err != nil {
-			log.Printf("Warning: failed to SIGSTOP %s: %v", old.name, err)
-		}
-		s.prismList[0].state = prismBackground
-	}
+	log.Printf("Spawning prism: %s (resolved to %s)", prismName, binaryPath)
 
 	ptyMaster, ptySlave, err := allocatePTY()
 	if err != nil {
-		return fmt.Errorf("failed to allocate PTY: %w", err)
+		return prismInstance{}, fmt.Errorf("failed to allocate PTY: %w", err)
 	}
 
-	if err := syncTerminalSize(int(os.Stdin.Fd()), int(ptyMaster.Fd())); err != nil {
+	if err := syncTerminalSize(int(s.termState.input().Fd()), int(ptyMaster.Fd())); err != nil {
 		closePTY(ptyMaster)
 		ptySlave.Close()
-		return fmt.Errorf("failed to sync terminal size: %w", err)
+		return prismInstance{}, fmt.Errorf("failed to sync terminal size: %w", err)
 	}
 
-	// CRITICAL: Reset terminal state
-	log.Printf("Resetting terminal state")
-	if err := s.termState.resetTerminalState(); err != nil {
-		log.Printf("Warning: failed to reset terminal state: %v", err)
+	var args, env []string
+	if app != nil {
+		args = app.args
+		env = app.env
 	}
 
-	// Stabilization delay
-	time.Sleep(10 * time.Millisecond)
+	var ready *readiness
+	if app != nil && app.ready != nil {
+		ready, err = newReadiness(app.ready, paths.RuntimeDir(), prismName)
+		if err != nil {
+			closePTY(ptyMaster)
+			ptySlave.Close()
+			return prismInstance{}, err
+		}
+	}
 
-	cmd := exec.Command(binaryPath)
+	cmd := exec.Command(binaryPath, args...)
+	if env = append(env, ready.env()...); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	cmd.Stdin = ptySlave
 	cmd.Stdout = ptySlave
 	cmd.Stderr = ptySlave
@@ -158,51 +283,122 @@ func (s *supervisor) launchAndForeground(prismName string) error {
 		Ctty:    0,
 	}
 
+	var sandboxSpec *rpc.SandboxSpec
+	if app != nil {
+		sandboxSpec = app.sandbox
+	}
+
+	sb, err := prepareSandbox(cmd, fmt.Sprintf("shine-%d-%s", os.Getpid(), prismName), sandboxSpec)
+	if err != nil {
+		ready.fail(err)
+		closePTY(ptyMaster)
+		ptySlave.Close()
+		return prismInstance{}, fmt.Errorf("failed to prepare sandbox: %w", err)
+	}
+
 	if err := cmd.Start(); err != nil {
+		ready.fail(err)
+		sb.release()
 		closePTY(ptyMaster)
 		ptySlave.Close()
-		return fmt.Errorf("failed to start prism: %w", err)
+		return prismInstance{}, fmt.Errorf("failed to start prism: %w", err)
 	}
 
 	ptySlave.Close()
+	sb.started()
 
 	pid := cmd.Process.Pid
 	log.Printf("Prism started: %s (PID %d) with PTY", prismName, pid)
 
-	newInstance := prismInstance{
-		name:      prismName,
-		pid:       pid,
-		state:     prismForeground,
-		ptyMaster: ptyMaster,
+	instance := prismInstance{
+		name:       prismName,
+		pid:        pid,
+		ptyMaster:  ptyMaster,
+		sandbox:    sb,
+		startTime:  time.Now(),
+		lastOutput: new(atomic.Int64),
+		ready:      ready,
 	}
-	s.prismList = append([]prismInstance{newInstance}, s.prismList...)
+	instance.lastOutput.Store(instance.startTime.UnixMilli())
 
-	if err := s.activateMirrorToForeground(); err != nil {
-		log.Printf("Warning: failed to start mirror: %v", err)
+	if ws, err := unix.IoctlGetWinsize(int(ptyMaster.Fd()), unix.TIOCGWINSZ); err == nil {
+		instance.screen = screen.New(int(ws.Col), int(ws.Row), captureScrollback)
+	} else {
+		instance.screen = screen.New(80, 24, captureScrollback)
 	}
+	instance.output = startPrismOutput(ptyMaster, &activityWriter{w: instance.screen, last: instance.lastOutput}, ready)
 
-	if s.stateManager != nil {
-		s.stateManager.OnPrismStarted(prismName, pid, true)
+	if app != nil && app.health != nil {
+		instance.health = startHealthMonitor(s, prismName, pid, app.health, instance.lastOutput)
 	}
 
-	if s.notifyMgr != nil {
-		s.notifyMgr.OnPrismStarted(prismName, pid)
+	ready.arm(func(timeout time.Duration) {
+		s.readyTimedOut(prismName, pid, timeout)
+	})
+
+	return instance, nil
+}
+
+// readyTimedOut kills a prism that did not signal readiness within its
+// start timeout. The exit is reported as a crash.
+func (s *supervisor) readyTimedOut(prismName string, pid int, timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.findPrism(prismName)
+	if idx == -1 || s.prismList[idx].pid != pid {
+		return
 	}
 
-	return nil
+	log.Printf("Prism %s (PID %d) not ready within %v, killing", prismName, pid, timeout)
+	s.readyTimeout[pid] = true
+	unix.Kill(pid, unix.SIGCONT)
+	unix.Kill(pid, unix.SIGKILL)
+}
+
+// suspend stops a prism sent to the background. A prism that is still
+// starting keeps running until it is ready, so that it can signal readiness.
+// Assumes caller holds s.mu lock
+func (s *supervisor) suspend(p prismInstance) {
+	if !p.ready.pending() {
+		if err := unix.Kill(p.pid, unix.SIGSTOP); err != nil {
+			log.Printf("Warning: failed to SIGSTOP %s: %v", p.name, err)
+		}
+		return
+	}
+
+	log.Printf("Prism %s is still starting, suspending once ready", p.name)
+	go func() {
+		if p.ready.wait() != nil {
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		idx := s.findPrism(p.name)
+		if idx == -1 || s.prismList[idx].pid != p.pid || s.prismList[idx].state != prismBackground {
+			return
+		}
+		if err := unix.Kill(p.pid, unix.SIGSTOP); err != nil {
+			log.Printf("Warning: failed to SIGSTOP %s: %v", p.name, err)
+		}
+	}()
 }
 
 func (s *supervisor) resumeToForeground(targetIdx int) error {
 	target := s.prismList[targetIdx]
 	log.Printf("Resuming prism %s (PID %d) to foreground", target.name, target.pid)
 
-	if len(s.prismList) > 0 && targetIdx != 0 {
+	previousFg := ""
+	if s.hasForeground() {
 		old := s.prismList[0]
+		previousFg = old.name
 		log.Printf("Suspending current foreground %s (PID %d)", old.name, old.pid)
-		if err := unix.Kill(old.pid, unix.SIGSTOP); err != nil {
-			log.Printf("Warning: failed to SIGSTOP %s: %v", old.name, err)
-		}
+		s.suspend(old)
 		s.prismList[0].state = prismBackground
+	} else {
+		s.hideIdle()
 	}
 
 	// Resume the target prism
@@ -217,7 +413,7 @@ func (s *supervisor) resumeToForeground(targetIdx int) error {
 
 	time.Sleep(10 * time.Millisecond)
 
-	if err := syncTerminalSize(int(os.Stdin.Fd()), int(target.ptyMaster.Fd())); err != nil {
+	if err := syncTerminalSize(int(s.termState.input().Fd()), int(target.ptyMaster.Fd())); err != nil {
 		log.Printf("Warning: failed to sync terminal size: %v", err)
 	}
 
@@ -240,8 +436,7 @@ func (s *supervisor) resumeToForeground(targetIdx int) error {
 		s.stateManager.OnForegroundChanged(target.name)
 	}
 
-	if s.notifyMgr != nil && len(s.prismList) > 1 {
-		previousFg := s.prismList[1].name
+	if s.notifyMgr != nil {
 		s.notifyMgr.OnForegroundChanged(previousFg, target.name)
 	}
 
@@ -273,10 +468,255 @@ func (s *supervisor) killPrism(prismName string) error {
 	return nil
 }
 
-func (s *supervisor) handleChildExit(pid, exitCode int) {
+// signalPrism delivers sig to a prism and returns its PID. A suspended
+// background prism handles the signal when it is next resumed.
+func (s *supervisor) signalPrism(prismName string, sig unix.Signal) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	targetIdx := s.findPrism(prismName)
+	if targetIdx == -1 {
+		return 0, fmt.Errorf("prism not found: %s", prismName)
+	}
+
+	pid := s.prismList[targetIdx].pid
+	log.Printf("Sending %s to prism %s (PID %d)", unix.SignalName(sig), prismName, pid)
+
+	if err := unix.Kill(pid, sig); err != nil {
+		return 0, fmt.Errorf("failed to send %s: %w", unix.SignalName(sig), err)
+	}
+
+	return pid, nil
+}
+
+// captureScrollback is how many lines scrolled off a prism's screen are kept
+// for prism/capture
+const captureScrollback = 1000
+
+// sendInputTimeout bounds a write to a prism's PTY. A suspended prism does
+// not drain its input, so a large write can fill the PTY buffer.
+const sendInputTimeout = 2 * time.Second
+
+// sendInput writes data to a prism's PTY master as if typed into its panel.
+// Works for background prisms too; they read the input when resumed.
+func (s *supervisor) sendInput(prismName string, data []byte) (int, error) {
+	s.mu.Lock()
+	targetIdx := s.findPrism(prismName)
+	if targetIdx == -1 {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("prism not found: %s", prismName)
+	}
+	ptyMaster := s.prismList[targetIdx].ptyMaster
+	s.mu.Unlock()
+
+	// Write outside the lock so a full PTY buffer cannot stall the supervisor
+	if err := ptyMaster.SetWriteDeadline(time.Now().Add(sendInputTimeout)); err != nil {
+		log.Printf("Warning: failed to set write deadline: %v", err)
+	}
+	defer ptyMaster.SetWriteDeadline(time.Time{})
+
+	n, err := ptyMaster.Write(data)
+	if err != nil {
+		return n, fmt.Errorf("failed to write to PTY: %w", err)
+	}
+
+	return n, nil
+}
+
+// sendForeground writes input to the foreground prism, as typed into an
+// attached terminal. Input is dropped while the idle screen shows.
+func (s *supervisor) sendForeground(data []byte) error {
+	s.mu.Lock()
+	if !s.hasForeground() {
+		s.mu.Unlock()
+		return nil
+	}
+	name := s.prismList[0].name
+	s.mu.Unlock()
+
+	_, err := s.sendInput(name, data)
+	return err
+}
+
+// restartKillTimeout is how long a restarting prism gets to exit after SIGTERM
+const restartKillTimeout = 2 * time.Second
+
+// restartPrism terminates a prism so that handleChildExit relaunches it in
+// the same MRU position instead of removing it. Escalates to SIGKILL if the
+// prism has not exited after killTimeout.
+func (s *supervisor) restartPrism(prismName string, killTimeout time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	targetIdx := s.findPrism(prismName)
+	if targetIdx == -1 {
+		return fmt.Errorf("prism not found: %s", prismName)
+	}
+
+	pid := s.prismList[targetIdx].pid
+	log.Printf("Restarting prism %s (PID %d)", prismName, pid)
+
+	s.restarting[pid] = true
+
+	// Resume first - suspended processes ignore SIGTERM
+	unix.Kill(pid, unix.SIGCONT)
+
+	if err := unix.Kill(pid, unix.SIGTERM); err != nil {
+		delete(s.restarting, pid)
+		return fmt.Errorf("failed to send SIGTERM: %w", err)
+	}
+
+	go func() {
+		time.Sleep(killTimeout)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.restarting[pid] {
+			log.Printf("Prism %s (PID %d) did not exit after %v, sending SIGKILL", prismName, pid, killTimeout)
+			unix.Kill(pid, unix.SIGKILL)
+		}
+	}()
+
+	return nil
+}
+
+// restartAndWait restarts a prism and blocks until its replacement is
+// running and ready
+func (s *supervisor) restartAndWait(ctx context.Context, prismName string) (prismInstance, error) {
+	s.mu.Lock()
+	idx := s.findPrism(prismName)
+	if idx == -1 {
+		s.mu.Unlock()
+		return prismInstance{}, fmt.Errorf("prism not found: %s", prismName)
+	}
+	oldPID := s.prismList[idx].pid
+	s.mu.Unlock()
+
+	if err := s.restartPrism(prismName, restartKillTimeout); err != nil {
+		return prismInstance{}, err
+	}
+
+	// Relaunch happens when the old process is reaped; wait for the new PID
+	deadline := time.Now().Add(restartKillTimeout + time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		idx := s.findPrism(prismName)
+		var prism prismInstance
+		if idx != -1 {
+			prism = s.prismList[idx]
+		}
+		s.mu.Unlock()
+
+		if idx == -1 {
+			return prismInstance{}, fmt.Errorf("%s failed to relaunch", prismName)
+		}
+		if prism.pid != oldPID {
+			if err := prism.ready.wait(); err != nil {
+				return prismInstance{}, fmt.Errorf("prism %s failed to start: %w", prismName, err)
+			}
+			return prism, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return prismInstance{}, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	return prismInstance{}, fmt.Errorf("%s did not come back up", prismName)
+}
+
+// relaunchInPlace replaces an exited prism with a fresh process at the same
+// MRU index, keeping its foreground/background status.
+// Assumes caller holds s.mu lock
+func (s *supervisor) relaunchInPlace(idx int, old prismInstance) error {
+	foreground := old.state == prismForeground
+
+	if foreground {
+		if s.mirror != nil {
+			deactivateMirror(s.mirror)
+			s.mirror = nil
+		}
+		if err := s.termState.resetTerminalState(); err != nil {
+			log.Printf("Warning: failed to reset terminal state: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	instance, err := s.spawnPrism(old.name)
+	if err != nil {
+		return err
+	}
+	instance.state = old.state
+	instance.restarts = old.restarts + 1
+	s.prismList[idx] = instance
+
+	if foreground {
+		io.WriteString(s.sinks, "\x1b[2J\x1b[H\x1b[0m")
+		if err := s.activateMirrorToForeground(); err != nil {
+			log.Printf("Warning: failed to start mirror: %v", err)
+		}
+	} else {
+		s.suspend(instance)
+	}
+
+	log.Printf("Relaunched %s (PID %d → %d, restarts=%d)", old.name, old.pid, instance.pid, instance.restarts)
+	prismRestarts.Inc(old.name)
+
+	if s.stateManager != nil {
+		s.stateManager.OnPrismRestarted(instance.name, instance.pid, instance.restarts)
+	}
+
+	if s.notifyMgr != nil {
+		s.notifyMgr.OnPrismStarted(instance.name, instance.pid)
+	}
+
+	return nil
+}
+
+// onHealthChanged is called by a prism's health monitor on status transitions
+func (s *supervisor) onHealthChanged(name string, pid int, status, message string, spec *rpc.HealthCheckSpec) {
+	if s.notifyMgr != nil {
+		s.notifyMgr.OnPrismHealthChanged(name, status, message)
+	}
+
+	if status != rpc.HealthUnhealthy || !spec.Restart {
+		return
+	}
+
+	s.mu.Lock()
+	idx := s.findPrism(name)
+	current := idx != -1 && s.prismList[idx].pid == pid
+	s.mu.Unlock()
+
+	if !current {
+		return
+	}
+
+	log.Printf("Prism %s is unhealthy (%s), restarting", name, message)
+	if err := s.restartPrism(name, restartKillTimeout); err != nil {
+		log.Printf("Failed to restart unhealthy prism %s: %v", name, err)
+	}
+}
+
+// isForegroundPID reports whether pid is the current foreground prism
+func (s *supervisor) isForegroundPID(pid int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hasForeground() && s.prismList[0].pid == pid
+}
+
+func (s *supervisor) handleChildExit(exit childExit) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	pid, exitCode := exit.pid, exit.exitCode
+
+	restart := s.restarting[pid]
+	delete(s.restarting, pid)
+
 	exitedIdx := -1
 	for i, p := range s.prismList {
 		if p.pid == pid {
@@ -286,6 +726,14 @@ func (s *supervisor) handleChildExit(pid, exitCode int) {
 	}
 
 	if exitedIdx == -1 {
+		if s.idleAppExited(pid) {
+			return
+		}
+		if s.stoppedIdle[pid] {
+			delete(s.stoppedIdle, pid)
+			log.Printf("Stopped idle app exited (PID %d)", pid)
+			return
+		}
 		log.Printf("Warning: received exit for unknown PID %d", pid)
 		return
 	}
@@ -297,14 +745,41 @@ func (s *supervisor) handleChildExit(pid, exitCode int) {
 		log.Printf("Warning: failed to close PTY master: %v", err)
 	}
 
+	exited.health.stop()
+	exited.ready.fail(fmt.Errorf("exited with code %d", exitCode))
+
+	crashReason := exited.sandbox.crashReason(exit.signal, exit.cpuTime)
+	exited.sandbox.release()
+	if s.readyTimeout[pid] {
+		delete(s.readyTimeout, pid)
+		crashReason = rpc.CrashReasonStartTimeout
+	}
+	switch crashReason {
+	case "":
+	case rpc.CrashReasonStartTimeout:
+		log.Printf("Prism %s did not become ready in time (%s)", exited.name, crashReason)
+	default:
+		log.Printf("Prism %s exceeded its sandbox limits (%s)", exited.name, crashReason)
+	}
+
 	select {
-	case s.childExitCh <- childExit{pid: pid, exitCode: exitCode}:
+	case s.childExitCh <- exit:
 		log.Printf("Sent exit event to childExitCh for PID %d", pid)
 	default:
 		log.Printf("WARNING: Failed to send exit event - channel full or no listener for PID %d", pid)
 	}
 
-	if exitedIdx == 0 {
+	if restart && !s.shuttingDown {
+		err := s.relaunchInPlace(exitedIdx, exited)
+		if err == nil {
+			return
+		}
+		log.Printf("Failed to relaunch %s, treating as exit: %v", exited.name, err)
+	}
+
+	wasForeground := exited.state == prismForeground
+
+	if wasForeground {
 		if s.mirror != nil {
 			deactivateMirror(s.mirror)
 			s.mirror = nil
@@ -321,22 +796,26 @@ func (s *supervisor) handleChildExit(pid, exitCode int) {
 		s.stateManager.OnPrismStopped(exited.name)
 	}
 
+	if exitCode != 0 {
+		recordCrash(exited.name, exit)
+	}
+
 	if s.notifyMgr != nil {
 		if exitCode == 0 {
 			s.notifyMgr.OnPrismStopped(exited.name, exitCode)
 		} else {
-			s.notifyMgr.OnPrismCrashed(exited.name, exitCode, 0)
+			s.notifyMgr.OnPrismCrashed(exited.name, exitCode, exit.signal, crashReason)
 		}
 	}
 
 	if len(s.prismList) == 0 {
 		log.Printf("Last prism exited, initiating shutdown")
-		go s.shutdown()
+		go s.shutdown(rpc.PanelExitEmpty)
 		return
 	}
 
 	// Auto-bring next to foreground if foreground exited
-	if exitedIdx == 0 && len(s.prismList) > 0 {
+	if wasForeground && len(s.prismList) > 0 {
 		time.Sleep(10 * time.Millisecond)
 
 		next := s.prismList[0]
@@ -346,7 +825,7 @@ func (s *supervisor) handleChildExit(pid, exitCode int) {
 			log.Printf("Warning: failed to SIGCONT %s: %v", next.name, err)
 		}
 
-		if err := syncTerminalSize(int(os.Stdin.Fd()), int(next.ptyMaster.Fd())); err != nil {
+		if err := syncTerminalSize(int(s.termState.input().Fd()), int(next.ptyMaster.Fd())); err != nil {
 			log.Printf("Warning: failed to sync terminal size: %v", err)
 		}
 
@@ -363,32 +842,40 @@ func (s *supervisor) propagateResize() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if len(s.prismList) == 0 {
-		return
+	prisms := s.prismList
+	if s.idleApp != nil {
+		prisms = append(prisms[:len(prisms):len(prisms)], *s.idleApp)
 	}
 
-	realWinsize, err := unix.IoctlGetWinsize(int(os.Stdin.Fd()), unix.TIOCGWINSZ)
+	realWinsize, err := unix.IoctlGetWinsize(int(s.termState.input().Fd()), unix.TIOCGWINSZ)
 	if err != nil {
 		log.Printf("Warning: failed to get Real PTY size: %v", err)
 		return
 	}
 
-	log.Printf("Propagating resize to %d prisms: %dx%d", len(s.prismList), realWinsize.Col, realWinsize.Row)
+	log.Printf("Propagating resize to %d prisms: %dx%d", len(prisms), realWinsize.Col, realWinsize.Row)
 
-	for _, prism := range s.prismList {
+	for _, prism := range prisms {
 		if err := unix.IoctlSetWinsize(int(prism.ptyMaster.Fd()), unix.TIOCSWINSZ, realWinsize); err != nil {
 			log.Printf("Warning: failed to sync size to %s (PID %d): %v", prism.name, prism.pid, err)
 			continue
 		}
 
+		if prism.screen != nil {
+			prism.screen.Resize(int(realWinsize.Col), int(realWinsize.Row))
+		}
+
 		if err := unix.Kill(prism.pid, unix.SIGWINCH); err != nil {
 			log.Printf("Warning: failed to send SIGWINCH to %s (PID %d): %v", prism.name, prism.pid, err)
 		}
 	}
+
+	s.sinks.resize(int(realWinsize.Col), int(realWinsize.Row))
 }
 
-// shutdown performs graceful shutdown
-func (s *supervisor) shutdown() {
+// shutdown terminates all prisms. reason is reported to shined (one of the
+// rpc.PanelExit* constants) so it can tell a deliberate close from a crash.
+func (s *supervisor) shutdown(reason string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -399,7 +886,11 @@ func (s *supervisor) shutdown() {
 	}
 	s.shuttingDown = true
 
-	log.Printf("Supervisor shutdown initiated")
+	log.Printf("Supervisor shutdown initiated (%s)", reason)
+
+	if s.notifyMgr != nil {
+		s.notifyMgr.OnPanelClosing(reason)
+	}
 
 	if s.mirror != nil {
 		deactivateMirror(s.mirror)
@@ -412,8 +903,11 @@ func (s *supervisor) shutdown() {
 
 	close(s.shutdownCh)
 
+	s.stopIdleApp()
+
 	// Resume all suspended prisms first - they ignore SIGTERM while suspended
 	for _, prism := range s.prismList {
+		prism.health.stop()
 		unix.Kill(prism.pid, unix.SIGCONT)
 	}
 
@@ -444,7 +938,7 @@ func (s *supervisor) shutdown() {
 
 	log.Printf("Supervisor shutdown complete")
 
-	fmt.Println("[ ] Exiting... ")
+	fmt.Fprintln(s.termState.output(), "[ ] Exiting... ")
 }
 
 func (s *supervisor) isShuttingDown() bool {
@@ -469,13 +963,28 @@ func (s *supervisor) activateMirrorToForeground() error {
 		log.Printf("Stopped previous mirror before starting new one")
 	}
 
-	// os.Stdin (Real PTY slave) ↔ foreground.ptyMaster
-	mirror, err := activateMirror(s.mirrorCtx, os.Stdin, foreground.ptyMaster)
+	// Real PTY slave ↔ foreground.ptyMaster
+	opts := []mirrorOption{withSinks(s.sinks)}
+	if foreground.lastOutput != nil {
+		// A prism quiet while stopped in the background starts a fresh
+		// output-within window
+		foreground.lastOutput.Store(time.Now().UnixMilli())
+		opts = append(opts, withOutputActivity(foreground.lastOutput))
+	}
+	if foreground.screen != nil {
+		opts = append(opts, withScreen(foreground.screen))
+	}
+	if foreground.output != nil {
+		opts = append(opts, withPrismOutput(foreground.output))
+	}
+
+	mirror, err := activateMirror(s.mirrorCtx, s.termState.input(), foreground.ptyMaster, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to start mirror: %w", err)
 	}
 
 	s.mirror = mirror
+	s.sinks.setForeground(foreground.name, foreground.screen)
 	log.Printf("Mirror started to foreground prism: %s (PID %d)", foreground.name, foreground.pid)
 
 	return nil
@@ -492,17 +1001,18 @@ func (s *supervisor) swapMirror() error {
 	// Clear screen AFTER stopping old mirror but BEFORE starting new one
 	// This ensures no race between clear and buffered output from background prism
 	// CSI 2 J = clear screen, CSI H = cursor home, CSI 0 m = reset all attributes
-	os.Stdout.WriteString("\x1b[2J\x1b[H\x1b[0m")
+	io.WriteString(s.sinks, "\x1b[2J\x1b[H\x1b[0m")
 
 	if err := s.activateMirrorToForeground(); err != nil {
 		return err
 	}
 
-	swapLatency := time.Since(startTime)
-	log.Printf("Mirror swap completed in %v", swapLatency)
+	latency := time.Since(startTime)
+	log.Printf("Mirror swap completed in %v", latency)
+	swapLatency.Observe(latency.Seconds())
 
-	if swapLatency > 50*time.Millisecond {
-		log.Printf("Warning: swap latency exceeded 50ms target: %v", swapLatency)
+	if latency > 50*time.Millisecond {
+		log.Printf("Warning: swap latency exceeded 50ms target: %v", latency)
 	}
 
 	return nil
@@ -375,3 +375,37 @@ func TestSupervisor_MutexProtection(t *testing.T) {
 		t.Errorf("prismList length = %d after concurrent append, want 1", listLen)
 	}
 }
+
+func TestSupervisor_SendInput(t *testing.T) {
+	master, slave, err := allocatePTY()
+	if err != nil {
+		t.Fatalf("allocatePTY() error: %v", err)
+	}
+	defer master.Close()
+	defer slave.Close()
+
+	sup := newSupervisor(&terminalState{}, nil, nil)
+	sup.prismList = []prismInstance{{name: "chat", pid: 1, state: prismBackground, ptyMaster: master}}
+
+	if _, err := sup.sendInput("missing", []byte("x")); err == nil {
+		t.Error("sendInput() on unknown prism should fail")
+	}
+
+	n, err := sup.sendInput("chat", append([]byte("hello"), encodeKey("Enter")...))
+	if err != nil {
+		t.Fatalf("sendInput() error: %v", err)
+	}
+	if n != 6 {
+		t.Errorf("sendInput() = %d bytes, want 6", n)
+	}
+
+	// The slave is in canonical mode, so Enter (CR → NL) completes the line
+	buf := make([]byte, 64)
+	read, err := slave.Read(buf)
+	if err != nil {
+		t.Fatalf("slave Read() error: %v", err)
+	}
+	if got := string(buf[:read]); got != "hello\n" {
+		t.Errorf("slave read %q, want %q", got, "hello\n")
+	}
+}
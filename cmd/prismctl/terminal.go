@@ -2,14 +2,21 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"golang.org/x/sys/unix"
 )
 
+// terminalState is the real terminal prisms are mirrored to: kitty's PTY on
+// stdin/stdout, or a virtual PTY when running headless. The zero value
+// stands for stdin/stdout.
 type terminalState struct {
 	savedTermios *unix.Termios
 	fd           int
+	tty          *os.File // real PTY slave; nil means os.Stdin
+	out          *os.File // where panel output goes; nil means os.Stdout
+	virtual      *os.File // master side of the virtual PTY, nil unless headless
 }
 
 func newTerminalState() (*terminalState, error) {
@@ -26,6 +33,92 @@ func newTerminalState() (*terminalState, error) {
 	}, nil
 }
 
+// newHeadlessTerminalState creates a virtual terminal of the given size to
+// stand in for a kitty window. What prisms draw on it is discarded; watch
+// them with shine attach or prism/capture.
+func newHeadlessTerminalState(cols, rows int) (*terminalState, error) {
+	master, slave, err := allocatePTY()
+	if err != nil {
+		return nil, err
+	}
+
+	fd := int(slave.Fd())
+	ws := &unix.Winsize{Col: uint16(cols), Row: uint16(rows)}
+	if err := unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, ws); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, fmt.Errorf("failed to set virtual terminal size: %w", err)
+	}
+
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		master.Close()
+		slave.Close()
+		return nil, fmt.Errorf("failed to get terminal attributes: %w", err)
+	}
+
+	// Nobody reads the virtual screen; drain it so writes never block
+	go io.Copy(io.Discard, master)
+
+	return &terminalState{
+		savedTermios: termios,
+		fd:           fd,
+		tty:          slave,
+		out:          slave,
+		virtual:      master,
+	}, nil
+}
+
+func (ts *terminalState) headless() bool {
+	return ts.virtual != nil
+}
+
+// input is the real PTY slave: the mirror reads user input from it and
+// prism PTYs copy its size
+func (ts *terminalState) input() *os.File {
+	if ts.tty == nil {
+		return os.Stdin
+	}
+	return ts.tty
+}
+
+func (ts *terminalState) output() *os.File {
+	if ts.out == nil {
+		return os.Stdout
+	}
+	return ts.out
+}
+
+// setSize resizes the virtual terminal. Only a headless terminal can be
+// resized from prismctl; kitty owns the size of a panel.
+func (ts *terminalState) setSize(cols, rows int) error {
+	if !ts.headless() {
+		return fmt.Errorf("terminal size is set by kitty unless running headless")
+	}
+	ws := &unix.Winsize{Col: uint16(cols), Row: uint16(rows)}
+	if err := unix.IoctlSetWinsize(ts.fd, unix.TIOCSWINSZ, ws); err != nil {
+		return fmt.Errorf("failed to set terminal size: %w", err)
+	}
+	return nil
+}
+
+// size returns the real terminal's columns and rows
+func (ts *terminalState) size() (int, int, error) {
+	ws, err := unix.IoctlGetWinsize(int(ts.input().Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+// close releases a virtual terminal
+func (ts *terminalState) close() {
+	if ts.virtual != nil {
+		ts.virtual.Close()
+		ts.tty.Close()
+	}
+}
+
 // resetTerminalState resets the terminal to canonical mode and clears visual state
 // This MUST be called after EVERY child exit (clean or crash) to prevent terminal corruption
 // - What does canonical mean? https://www.gnu.org/software/libc/manual/html_node/Canonical-or-Not.html
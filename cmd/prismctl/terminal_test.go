@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+	"golang.org/x/sys/unix"
+)
+
+func TestHeadlessTerminal_Size(t *testing.T) {
+	ts, err := newHeadlessTerminalState(100, 30)
+	if err != nil {
+		t.Fatalf("newHeadlessTerminalState() error: %v", err)
+	}
+	defer ts.close()
+
+	if !ts.headless() {
+		t.Error("headless() = false for a virtual terminal")
+	}
+	if cols, rows, err := ts.size(); err != nil || cols != 100 || rows != 30 {
+		t.Errorf("size() = %d,%d,%v, want 100,30", cols, rows, err)
+	}
+
+	if err := ts.setSize(120, 40); err != nil {
+		t.Fatalf("setSize() error: %v", err)
+	}
+	if cols, rows, _ := ts.size(); cols != 120 || rows != 40 {
+		t.Errorf("size() after setSize = %d,%d, want 120,40", cols, rows)
+	}
+
+	if err := (&terminalState{}).setSize(80, 24); err == nil {
+		t.Error("setSize() on the kitty terminal should fail")
+	}
+}
+
+func TestHeadless_RunAndResize(t *testing.T) {
+	catPath, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat not available")
+	}
+
+	ts, err := newHeadlessTerminalState(80, 24)
+	if err != nil {
+		t.Fatalf("newHeadlessTerminalState() error: %v", err)
+	}
+	defer ts.close()
+
+	sup := newSupervisor(ts, nil, nil)
+	sup.registerApp("echo", &appSpec{path: catPath})
+	defer sup.shutdown(rpc.PanelExitRequested)
+
+	if err := sup.start("echo"); err != nil {
+		t.Fatalf("start() error: %v", err)
+	}
+
+	// Output reaches the prism's screen with no kitty window attached
+	if _, err := sup.sendInput("echo", []byte("hello\r")); err != nil {
+		t.Fatalf("sendInput() error: %v", err)
+	}
+	handlers := &rpcHandlers{supervisor: sup}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		result, err := handlers.handleCapture(context.Background(), &rpc.CaptureRequest{})
+		if err != nil {
+			t.Fatalf("handleCapture() error: %v", err)
+		}
+		if result.Content == "hello\nhello\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("captured %q, want the echoed and catted line", result.Content)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := handlers.handleResize(context.Background(), &rpc.ResizeRequest{Cols: 0, Rows: 24}); err == nil {
+		t.Error("handleResize() with zero columns should fail")
+	}
+	if _, err := handlers.handleResize(context.Background(), &rpc.ResizeRequest{Cols: 132, Rows: 43}); err != nil {
+		t.Fatalf("handleResize() error: %v", err)
+	}
+
+	sup.mu.Lock()
+	prism := sup.prismList[0]
+	sup.mu.Unlock()
+
+	ws, err := unix.IoctlGetWinsize(int(prism.ptyMaster.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		t.Fatalf("IoctlGetWinsize() error: %v", err)
+	}
+	if ws.Col != 132 || ws.Row != 43 {
+		t.Errorf("prism PTY size = %dx%d, want 132x43", ws.Col, ws.Row)
+	}
+	if cols, rows := prism.screen.Size(); cols != 132 || rows != 43 {
+		t.Errorf("prism screen size = %dx%d, want 132x43", cols, rows)
+	}
+}
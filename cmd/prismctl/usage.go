@@ -0,0 +1,146 @@
+// usage.go samples each prism's process tree from /proc — CPU, memory,
+// threads, children and process state — for prism/list and the state file.
+
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/metrics"
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+// usageInterval is how often prism usage is sampled; CPU% is averaged over it
+const usageInterval = 2 * time.Second
+
+// prismUsage is the resource usage of a prism's process tree
+type prismUsage struct {
+	cpuPercent float64 // over the last sample interval
+	rssBytes   int64
+	threads    int
+	children   int  // descendant processes
+	state      byte // stat(5) state of the prism process itself
+}
+
+func (u prismUsage) info() *rpc.PrismUsage {
+	return &rpc.PrismUsage{
+		CPUPercent: u.cpuPercent,
+		RSSBytes:   u.rssBytes,
+		Threads:    u.threads,
+		Children:   u.children,
+		ProcState:  metrics.ProcessStateName(u.state),
+	}
+}
+
+// cpuSample is a prism tree's cumulative CPU time at a point in time
+type cpuSample struct {
+	seconds float64
+	at      time.Time
+}
+
+// runUsageSampler samples prism usage every interval until shutdown
+func (s *supervisor) runUsageSampler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	prev := make(map[int]cpuSample)
+	for {
+		select {
+		case <-s.shutdownCh:
+			return
+		case <-ticker.C:
+			prev = s.sampleTrees(prev)
+		}
+	}
+}
+
+// sampleTrees records the usage of every running prism's process tree. CPU%
+// is measured against prev, the samples returned by the previous call; a
+// prism sampled for the first time reports its average since launch.
+func (s *supervisor) sampleTrees(prev map[int]cpuSample) map[int]cpuSample {
+	type target struct {
+		name    string
+		pid     int
+		started time.Time
+	}
+
+	s.mu.Lock()
+	targets := make([]target, 0, len(s.prismList))
+	for _, p := range s.prismList {
+		targets = append(targets, target{p.name, p.pid, p.startTime})
+	}
+	s.mu.Unlock()
+
+	next := make(map[int]cpuSample, len(targets))
+	usage := make(map[int]prismUsage, len(targets))
+
+	var parents map[int]int
+	if len(targets) > 0 {
+		var err error
+		if parents, err = metrics.ReadParents(); err != nil {
+			log.Printf("Usage: failed to read process table: %v", err)
+			return prev
+		}
+	}
+
+	now := time.Now()
+	for _, t := range targets {
+		root, err := metrics.ReadProcessUsage(t.pid)
+		if err != nil {
+			// Exited since the list was copied; the reaper will catch up
+			continue
+		}
+
+		u := prismUsage{
+			rssBytes: root.RSSBytes,
+			threads:  root.Threads,
+			state:    root.State,
+		}
+		cpu := root.CPUSeconds
+
+		descendants := metrics.Descendants(parents, t.pid)
+		u.children = len(descendants)
+		for _, pid := range descendants {
+			child, err := metrics.ReadProcessUsage(pid)
+			if err != nil {
+				continue
+			}
+			cpu += child.CPUSeconds
+			u.rssBytes += child.RSSBytes
+			u.threads += child.Threads
+		}
+
+		last, ok := prev[t.pid]
+		if !ok {
+			last = cpuSample{at: t.started}
+		}
+		if elapsed := now.Sub(last.at).Seconds(); elapsed > 0 {
+			// Children that exit take their CPU time with them
+			u.cpuPercent = max(cpu-last.seconds, 0) / elapsed * 100
+		}
+
+		next[t.pid] = cpuSample{seconds: cpu, at: now}
+		usage[t.pid] = u
+
+		if s.stateManager != nil {
+			s.stateManager.OnUsageSampled(t.name, u)
+		}
+	}
+
+	s.mu.Lock()
+	s.usage = usage
+	s.mu.Unlock()
+
+	return next
+}
+
+// usageInfo returns the last usage sample for a prism process, or nil.
+// Assumes caller holds s.mu lock
+func (s *supervisor) usageInfo(pid int) *rpc.PrismUsage {
+	u, ok := s.usage[pid]
+	if !ok {
+		return nil
+	}
+	return u.info()
+}
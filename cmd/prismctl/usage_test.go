@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/metrics"
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+func TestSampleTrees(t *testing.T) {
+	shPath, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+
+	ts, err := newHeadlessTerminalState(80, 24)
+	if err != nil {
+		t.Fatalf("newHeadlessTerminalState() error: %v", err)
+	}
+	defer ts.close()
+
+	sup := newSupervisor(ts, nil, nil)
+	defer sup.shutdown(rpc.PanelExitRequested)
+	h := &rpcHandlers{supervisor: sup}
+	ctx := context.Background()
+
+	// clock runs a child of its own; bar then takes the foreground, leaving
+	// clock stopped in the background
+	sup.registerApp("clock", &appSpec{path: shPath, args: []string{"-c", sleepPath + " 30 & wait"}})
+	sup.registerApp("bar", &appSpec{path: sleepPath, args: []string{"30"}})
+	up := func(name string) int {
+		t.Helper()
+		result, err := h.handleUp(ctx, &rpc.UpRequest{Name: name})
+		if err != nil {
+			t.Fatalf("handleUp(%s) error: %v", name, err)
+		}
+		return result.PID
+	}
+
+	// Let clock fork its child before bar stops it
+	clockPID := up("clock")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		parents, err := metrics.ReadParents()
+		if err != nil {
+			t.Fatalf("ReadParents() error: %v", err)
+		}
+		if len(metrics.Descendants(parents, clockPID)) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("clock did not start its child")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	up("bar")
+
+	usage := func() map[string]*rpc.PrismUsage {
+		sup.sampleTrees(nil)
+		list, _ := h.handleList(ctx)
+		byName := make(map[string]*rpc.PrismUsage)
+		for _, p := range list.Prisms {
+			byName[p.Name] = p.Usage
+		}
+		return byName
+	}
+
+	var got map[string]*rpc.PrismUsage
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		got = usage()
+		if got["clock"] != nil && got["clock"].ProcState == "stopped" && got["clock"].Children == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("clock usage = %+v, want stopped with 1 child", got["clock"])
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	clock := got["clock"]
+	if clock.RSSBytes <= 0 || clock.Threads < 2 {
+		t.Errorf("clock usage = %+v, want RSS and threads covering both processes", clock)
+	}
+
+	bar := got["bar"]
+	if bar == nil {
+		t.Fatal("no usage for bar")
+	}
+	if bar.ProcState != "sleeping" || bar.Children != 0 {
+		t.Errorf("bar usage = %+v, want sleeping with no children", bar)
+	}
+}
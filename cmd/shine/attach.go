@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/paths"
+	"github.com/starbased-co/shine/pkg/rpc"
+	"golang.org/x/term"
+)
+
+const attachUsage = "usage: shine attach [--read-only] <panel>"
+
+// detachKey is Ctrl-], as in telnet
+const detachKey = 0x1d
+
+// cmdAttach mirrors a panel's foreground prism into this terminal:
+//
+//	shine attach bar               # watch and type into the panel
+//	shine attach --read-only bar   # watch only
+//
+// The stream goes straight to the panel's prismctl; shined is not involved.
+func cmdAttach(args []string) error {
+	var readOnly bool
+	var positional []string
+
+	for _, arg := range args {
+		switch arg {
+		case "-r", "--read-only":
+			readOnly = true
+		default:
+			if len(arg) > 1 && arg[0] == '-' {
+				return fmt.Errorf("unknown flag: %s\n%s", arg, attachUsage)
+			}
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) != 1 {
+		return fmt.Errorf(attachUsage)
+	}
+	panel := positional[0]
+
+	conn, resp, err := rpc.DialAttach(paths.PrismAttachSocket(panel), &rpc.AttachRequest{ReadOnly: readOnly}, prismTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to attach to %s (is the panel running?): %w", panel, err)
+	}
+	defer conn.Close()
+
+	stdin := int(os.Stdin.Fd())
+	if cols, rows, err := term.GetSize(stdin); err == nil && (cols < resp.Cols || rows < resp.Rows) {
+		Warning(fmt.Sprintf("Panel %s is %dx%d, larger than this terminal (%dx%d)", panel, resp.Cols, resp.Rows, cols, rows))
+		time.Sleep(time.Second)
+	}
+
+	if streamAttach(conn, readOnly) {
+		Warning(fmt.Sprintf("Panel %s closed the connection", panel))
+	} else {
+		Info(fmt.Sprintf("Detached from %s", panel))
+	}
+	return nil
+}
+
+// streamAttach runs the attached session with the terminal in raw mode.
+// Returns true if prismctl ended it rather than the user detaching.
+func streamAttach(conn io.ReadWriter, readOnly bool) bool {
+	stdin := int(os.Stdin.Fd())
+	if term.IsTerminal(stdin) {
+		if saved, err := term.MakeRaw(stdin); err == nil {
+			defer term.Restore(stdin, saved)
+		}
+		// Alternate screen keeps the panel's picture out of the scrollback
+		os.Stdout.WriteString("\x1b[?1049h")
+		defer os.Stdout.WriteString("\x1b[0m\x1b[?1049l")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(&attachOutput{w: os.Stdout}, conn)
+		close(closed)
+	}()
+
+	// A read-only attach with redirected stdin runs until the panel closes
+	detached := make(chan struct{})
+	if !readOnly || term.IsTerminal(stdin) {
+		go func() {
+			forwardAttachInput(conn, readOnly)
+			close(detached)
+		}()
+	}
+
+	select {
+	case <-closed:
+		return true
+	case <-detached:
+		return false
+	}
+}
+
+// forwardAttachInput copies stdin to the attached prism until the detach key
+// or the end of input. Read-only attaches only watch for the detach key.
+func forwardAttachInput(conn io.Writer, readOnly bool) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := os.Stdin.Read(buf)
+		data := buf[:n]
+
+		i := bytes.IndexByte(data, detachKey)
+		if i >= 0 {
+			data = data[:i]
+		}
+		if len(data) > 0 && !readOnly {
+			if _, werr := conn.Write(data); werr != nil {
+				return
+			}
+		}
+		if i >= 0 || err != nil {
+			return
+		}
+	}
+}
+
+// attachOutput writes the attached stream to the terminal, turning prismctl's
+// resize notices into window resize requests. Terminals that allow it follow
+// the panel's size; others ignore the request.
+type attachOutput struct {
+	w    io.Writer
+	tail []byte // incomplete notice held until the next write
+}
+
+// maxResizeNotice bounds how long a held notice may grow before it is
+// passed through as ordinary output
+var maxResizeNotice = len(rpc.AttachResizeNotice(0xffff, 0xffff))
+
+func (o *attachOutput) Write(p []byte) (int, error) {
+	buf := append(o.tail, p...)
+	o.tail = nil
+
+	var out []byte
+	for len(buf) > 0 {
+		i := bytes.Index(buf, []byte(rpc.AttachResizeSequence))
+		if i < 0 {
+			// Hold back a trailing prefix of the sequence
+			keep := 0
+			for k := min(len(buf), len(rpc.AttachResizeSequence)-1); k > 0; k-- {
+				if bytes.HasSuffix(buf, []byte(rpc.AttachResizeSequence[:k])) {
+					keep = k
+					break
+				}
+			}
+			out = append(out, buf[:len(buf)-keep]...)
+			o.tail = append([]byte(nil), buf[len(buf)-keep:]...)
+			break
+		}
+		out = append(out, buf[:i]...)
+		buf = buf[i:]
+
+		end := bytes.IndexByte(buf, '\a')
+		if end < 0 && len(buf) < maxResizeNotice {
+			o.tail = append([]byte(nil), buf...)
+			break
+		}
+		if end < 0 || end >= maxResizeNotice {
+			// Not a notice after all
+			out = append(out, buf[:len(rpc.AttachResizeSequence)]...)
+			buf = buf[len(rpc.AttachResizeSequence):]
+			continue
+		}
+
+		var cols, rows int
+		if _, err := fmt.Sscanf(string(buf[len(rpc.AttachResizeSequence):end]), ";%d;%d", &cols, &rows); err == nil {
+			// XTWINOPS: resize the text area to rows x cols
+			out = fmt.Appendf(out, "\x1b[8;%d;%dt", rows, cols)
+		}
+		buf = buf[end+1:]
+	}
+
+	if _, err := o.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
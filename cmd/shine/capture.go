@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+const captureUsage = "usage: shine capture [--format text|ansi|html|svg] [--scrollback] [-o file] <panel> [prism]"
+
+// cmdCapture prints a prism's current screen, the foreground prism by default:
+//
+//	shine capture bar                          # foreground prism as text
+//	shine capture --format ansi bar clock      # with colors
+//	shine capture --format svg -o bar.svg bar  # screenshot without a compositor
+func cmdCapture(args []string) error {
+	var format, output string
+	var scrollback bool
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-f" || arg == "--format" || arg == "-o" || arg == "--output":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value\n%s", arg, captureUsage)
+			}
+			i++
+			if arg == "-f" || arg == "--format" {
+				format = args[i]
+			} else {
+				output = args[i]
+			}
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		case strings.HasPrefix(arg, "--output="):
+			output = strings.TrimPrefix(arg, "--output=")
+		case arg == "-s" || arg == "--scrollback":
+			scrollback = true
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag: %s\n%s", arg, captureUsage)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) < 1 || len(positional) > 2 {
+		return fmt.Errorf(captureUsage)
+	}
+
+	req := &rpc.PanelCaptureRequest{Panel: positional[0], Format: format, Scrollback: scrollback}
+	if len(positional) == 2 {
+		req.Name = positional[1]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), prismTimeout)
+	defer cancel()
+
+	var result *rpc.CaptureResult
+	err := callPrism(ctx, req.Panel,
+		func(c *rpc.ShinedClient) (err error) { result, err = c.PrismCapture(ctx, req); return },
+		func(c *rpc.PrismClient) (err error) {
+			result, err = c.Capture(ctx, &rpc.CaptureRequest{
+				Name:       req.Name,
+				Format:     req.Format,
+				Scrollback: req.Scrollback,
+			})
+			return
+		})
+	if err != nil {
+		return fmt.Errorf("failed to capture %s: %w", req.Panel, err)
+	}
+
+	if output == "" || output == "-" {
+		_, err = os.Stdout.WriteString(result.Content)
+		return err
+	}
+
+	if err := os.WriteFile(output, []byte(result.Content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	Success(fmt.Sprintf("Captured %s (%dx%d) to %s", result.Name, result.Cols, result.Rows, output))
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/starbased-co/shine/pkg/metrics"
 	"github.com/starbased-co/shine/pkg/paths"
 	"github.com/starbased-co/shine/pkg/rpc"
 	"github.com/starbased-co/shine/pkg/state"
@@ -37,16 +38,24 @@ func discoverPrismInstances() ([]string, error) {
 
 	instances := make([]string, 0, len(matches))
 	for _, socket := range matches {
+		// The pattern also matches each panel's attach socket
+		if strings.HasSuffix(socket, ".attach.sock") {
+			continue
+		}
 		instances = append(instances, extractInstanceName(socket))
 	}
 
 	return instances, nil
 }
 
+// startupTimeout bounds how long shine start waits for shined to spawn its
+// panels and for their apps to become ready
+const startupTimeout = 2 * time.Minute
+
 func cmdStart() error {
 	if isShinedRunning() {
 		Success("shined is already running")
-		return nil
+		return waitForStartup()
 	}
 
 	Info("Starting shined service...")
@@ -70,7 +79,7 @@ func cmdStart() error {
 	for i := 0; i < 50; i++ {
 		if isShinedRunning() {
 			Success(fmt.Sprintf("shined started (PID: %d)", cmd.Process.Pid))
-			return nil
+			return waitForStartup()
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
@@ -78,6 +87,52 @@ func cmdStart() error {
 	return fmt.Errorf("shined started but socket not created within timeout")
 }
 
+// waitForStartup waits until shined has spawned its configured panels and
+// their apps are ready, then reports any that failed
+func waitForStartup() error {
+	client, err := connectShined()
+	if err != nil {
+		return fmt.Errorf("failed to connect to shined: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+	defer cancel()
+
+	waiting := false
+	for {
+		result, err := client.Status(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("panels not ready within %v", startupTimeout)
+			}
+			return fmt.Errorf("failed to query shined status: %w", err)
+		}
+
+		if !result.Starting {
+			for _, msg := range result.StartupErrors {
+				Error(msg)
+			}
+			if n := len(result.StartupErrors); n > 0 {
+				return fmt.Errorf("%d panel(s) failed to start", n)
+			}
+			Success(fmt.Sprintf("%d panel(s) ready", len(result.Panels)))
+			return nil
+		}
+
+		if !waiting {
+			Info("Waiting for panels to become ready...")
+			waiting = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("panels not ready within %v", startupTimeout)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
 func cmdStop() error {
 	Info("Stopping shine service...")
 
@@ -146,7 +201,7 @@ func cmdReload() error {
 	Info("Reloading configuration...")
 
 	if !isShinedRunning() {
-		return fmt.Errorf("shined is not running")
+		return withExitCode(exitNotRunning, fmt.Errorf("shined is not running"))
 	}
 
 	ctx := context.Background()
@@ -176,43 +231,81 @@ func cmdReload() error {
 	return nil
 }
 
-func displayStateFromMmap(instance string, s *state.PrismRuntimeState) {
-	fmt.Println()
-	fmt.Printf("%s %s\n", styleBold.Render("Panel:"), instance)
-	fmt.Printf("%s %s\n", styleMuted.Render("Source:"), "mmap")
+// statusColumns heads the prism table of shine status
+var statusColumns = []string{"Prism", "PID", "State", "Uptime", "CPU", "Memory", "Threads", "Children", "Process"}
+
+// highCPUPercent is the CPU% above which status highlights a prism
+const highCPUPercent = 50
 
-	fgName := s.GetFgPrism()
-	activePrisms := s.ActivePrisms()
-	bgCount := len(activePrisms) - 1
-	if fgName == "" {
-		bgCount = len(activePrisms)
+// mmapUsage reads the resource usage recorded in a state file entry
+func mmapUsage(e *state.PrismEntry) *rpc.PrismUsage {
+	if !e.Sampled() {
+		return nil
+	}
+	return &rpc.PrismUsage{
+		CPUPercent: e.CPUPercent(),
+		RSSBytes:   e.RSSBytes(),
+		Threads:    int(e.Threads),
+		Children:   int(e.Children),
+		ProcState:  metrics.ProcessStateName(e.ProcState),
 	}
+}
 
-	fmt.Println(StatusBox(fgName, bgCount, len(activePrisms)))
+// usageCells renders a prism's resource usage for the status table. Usage is
+// sampled every few seconds, so a just-launched prism shows dashes.
+func usageCells(u *rpc.PrismUsage) []string {
+	if u == nil {
+		return []string{"-", "-", "-", "-", "-"}
+	}
 
-	if len(activePrisms) > 0 {
-		table := NewTable("Prism", "PID", "State", "Uptime")
-		for _, prism := range activePrisms {
-			name := prism.GetName()
-			stateStr := "background"
-			if name == fgName {
-				stateStr = styleSuccess.Render("foreground")
-			} else {
-				stateStr = styleMuted.Render("background")
-			}
-			uptime := prism.Uptime()
-			uptimeStr := fmt.Sprintf("%v", uptime.Truncate(time.Second))
-			table.AddRow(name, fmt.Sprintf("%d", prism.PID), stateStr, uptimeStr)
-		}
-		fmt.Println()
-		table.Print()
+	cpu := fmt.Sprintf("%.1f%%", u.CPUPercent)
+	if u.CPUPercent >= highCPUPercent {
+		cpu = styleWarning.Render(cpu)
+	}
+	proc := u.ProcState
+	if proc == "stopped" {
+		proc = styleMuted.Render(proc)
+	}
+
+	return []string{cpu, formatBytes(u.RSSBytes), fmt.Sprintf("%d", u.Threads), fmt.Sprintf("%d", u.Children), proc}
+}
+
+// formatBytes renders a size with a binary unit, e.g. "12.5 MiB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
 	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
 }
 
-func displayStateFromRPC(instance string, prisms []rpc.PrismInfo) {
+// mmapPrisms converts a panel's state file to the prism list prism/list
+// returns. The state file carries no health or readiness.
+func mmapPrisms(s *state.PrismRuntimeState) []rpc.PrismInfo {
+	active := s.ActivePrisms()
+	prisms := make([]rpc.PrismInfo, 0, len(active))
+	for _, entry := range active {
+		prisms = append(prisms, rpc.PrismInfo{
+			Name:     entry.GetName(),
+			PID:      int(entry.PID),
+			State:    entry.GetState().String(),
+			UptimeMs: entry.Uptime().Milliseconds(),
+			Restarts: int(entry.Restarts),
+			Usage:    mmapUsage(&entry),
+		})
+	}
+	return prisms
+}
+
+func displayPrisms(instance, source string, prisms []rpc.PrismInfo) {
 	fmt.Println()
 	fmt.Printf("%s %s\n", styleBold.Render("Panel:"), instance)
-	fmt.Printf("%s %s\n", styleMuted.Render("Source:"), "rpc")
+	fmt.Printf("%s %s\n", styleMuted.Render("Source:"), source)
 
 	fgName := ""
 	bgCount := 0
@@ -227,7 +320,7 @@ func displayStateFromRPC(instance string, prisms []rpc.PrismInfo) {
 	fmt.Println(StatusBox(fgName, bgCount, len(prisms)))
 
 	if len(prisms) > 0 {
-		table := NewTable("Prism", "PID", "State", "Uptime")
+		table := NewTable(statusColumns...)
 		for _, prism := range prisms {
 			stateStr := prism.State
 			if prism.State == "fg" {
@@ -235,88 +328,123 @@ func displayStateFromRPC(instance string, prisms []rpc.PrismInfo) {
 			} else {
 				stateStr = styleMuted.Render("background")
 			}
+			if prism.Starting {
+				stateStr += styleMuted.Render(" (starting)")
+			}
 			uptime := time.Duration(prism.UptimeMs) * time.Millisecond
 			uptimeStr := fmt.Sprintf("%v", uptime.Truncate(time.Second))
-			table.AddRow(prism.Name, fmt.Sprintf("%d", prism.PID), stateStr, uptimeStr)
+			row := []string{prism.Name, fmt.Sprintf("%d", prism.PID), stateStr, uptimeStr}
+			table.AddRow(append(row, usageCells(prism.Usage)...)...)
 		}
 		fmt.Println()
 		table.Print()
 	}
 }
 
-func cmdStatus() error {
-	ctx := context.Background()
+// statusOutput is shine status in machine-readable form
+type statusOutput struct {
+	Shined *shinedStatus `json:"shined"` // null when shined is not running
+	Panels []panelStatus `json:"panels"`
+}
 
-	// Try shined first for aggregated status
-	if isShinedRunning() {
-		client, err := connectShined()
-		if err == nil {
-			defer client.Close()
+// shinedStatus is the service-level part of service/status
+type shinedStatus struct {
+	Version       string   `json:"version"`
+	UptimeMs      int64    `json:"uptime_ms"`
+	Backend       string   `json:"backend,omitempty"`
+	Starting      bool     `json:"starting,omitempty"`
+	StartupErrors []string `json:"startup_errors,omitempty"`
+}
 
-			result, err := client.Status(ctx)
-			if err == nil {
-				// Display shined-level status
-				uptime := time.Duration(result.Uptime) * time.Millisecond
-				uptimeStr := uptime.Truncate(time.Second).String()
+// panelStatus is a panel, with the fields of rpc.PanelInfo, and its prisms
+type panelStatus struct {
+	rpc.PanelInfo
+	Prisms []rpc.PrismInfo `json:"prisms"`
+	Error  string          `json:"error,omitempty"` // why the prisms could not be read
 
-				Header(fmt.Sprintf("Shine Status (v%s, uptime: %s)", result.Version, uptimeStr))
+	source string // where the prisms were read from: "mmap" or "rpc"
+}
 
-				if len(result.Panels) == 0 {
-					Warning("No panels running")
-					Info("Start panels with: shine start")
-					return nil
-				}
+// unhealthy reports whether a panel failed its last health check or could
+// not be queried. Panels shined has not checked yet count as healthy.
+func (p *panelStatus) unhealthy() bool {
+	return p.Error != "" || (p.LastCheckMs != 0 && !p.Healthy)
+}
 
-				// Query each panel for detailed status
-				for _, panel := range result.Panels {
-					displayPanelStatus(ctx, panel.Instance)
+// collectStatus gathers the status of shined and every panel. shinedErr is
+// set when shined is running but could not be queried; panels are then
+// discovered from their sockets.
+func collectStatus(ctx context.Context) (status *statusOutput, shinedErr error, err error) {
+	status = &statusOutput{Panels: []panelStatus{}}
+
+	var panels []rpc.PanelInfo
+	if isShinedRunning() {
+		var client *rpc.ShinedClient
+		client, shinedErr = connectShined()
+		if shinedErr == nil {
+			var result *rpc.ServiceStatusResult
+			result, shinedErr = client.Status(ctx)
+			client.Close()
+			if shinedErr == nil {
+				status.Shined = &shinedStatus{
+					Version:       result.Version,
+					UptimeMs:      result.Uptime,
+					Backend:       result.Backend,
+					Starting:      result.Starting,
+					StartupErrors: result.StartupErrors,
 				}
-				return nil
+				panels = result.Panels
 			}
-			// If shined query fails, fall through to discovery
-			Warning(fmt.Sprintf("Failed to query shined: %v, falling back to discovery", err))
 		}
 	}
 
-	// Fallback: discover all running prism instances directly
-	instances, err := discoverPrismInstances()
-	if err != nil {
-		return err
-	}
-
-	if len(instances) == 0 {
-		Warning("No panels running")
-		Info("Start panels with: shine start")
-		return nil
+	if status.Shined == nil {
+		// Fallback: discover all running prism instances directly
+		instances, err := discoverPrismInstances()
+		if err != nil {
+			return nil, shinedErr, err
+		}
+		for _, instance := range instances {
+			panels = append(panels, rpc.PanelInfo{
+				Instance: instance,
+				Name:     instance,
+				Socket:   paths.PrismSocket(instance),
+			})
+		}
 	}
 
-	Header(fmt.Sprintf("Shine Status (%d panel(s))", len(instances)))
-
-	for _, instance := range instances {
-		displayPanelStatus(ctx, instance)
+	for _, info := range panels {
+		panel := panelStatus{PanelInfo: info}
+		readPanelPrisms(ctx, &panel)
+		if status.Shined == nil {
+			// Without shined, a panel is healthy if it answers
+			panel.Healthy = panel.Error == ""
+		}
+		status.Panels = append(status.Panels, panel)
 	}
 
-	return nil
+	return status, shinedErr, nil
 }
 
-func displayPanelStatus(ctx context.Context, instance string) {
+// readPanelPrisms fills in a panel's prisms
+func readPanelPrisms(ctx context.Context, panel *panelStatus) {
 	// Try mmap first (instant, no connection needed)
-	reader, err := state.OpenPrismStateReader(paths.PrismState(instance))
+	reader, err := state.OpenPrismStateReader(paths.PrismState(panel.Instance))
 	if err == nil {
 		s, readErr := reader.Read()
 		reader.Close()
 		if readErr == nil {
-			displayStateFromMmap(instance, s)
+			panel.Prisms = mmapPrisms(s)
+			panel.source = "mmap"
 			return
 		}
 	}
 
 	// Fallback to RPC
-	client, err := rpc.NewPrismClient(paths.PrismSocket(instance))
+	panel.Prisms = []rpc.PrismInfo{}
+	client, err := rpc.NewPrismClient(paths.PrismSocket(panel.Instance))
 	if err != nil {
-		fmt.Println()
-		fmt.Printf("%s %s\n", styleBold.Render("Panel:"), instance)
-		Error(fmt.Sprintf("Failed to connect: %v", err))
+		panel.Error = fmt.Sprintf("Failed to connect: %v", err)
 		return
 	}
 
@@ -324,47 +452,147 @@ func displayPanelStatus(ctx context.Context, instance string) {
 	client.Close()
 
 	if err != nil {
-		fmt.Println()
-		fmt.Printf("%s %s\n", styleBold.Render("Panel:"), instance)
-		Error(fmt.Sprintf("Failed to query: %v", err))
+		panel.Error = fmt.Sprintf("Failed to query: %v", err)
 		return
 	}
 
-	displayStateFromRPC(instance, result.Prisms)
+	panel.Prisms = result.Prisms
+	panel.source = "rpc"
 }
 
-func cmdLogs(panelID string) error {
+// statusExit picks the exit code for shine status: not running when there
+// are no panels, degraded when some are unhealthy and failed when all are
+func statusExit(status *statusOutput) error {
+	if len(status.Panels) == 0 {
+		return withExitCode(exitNotRunning, nil)
+	}
+
+	var unhealthy int
+	for i := range status.Panels {
+		if status.Panels[i].unhealthy() {
+			unhealthy++
+		}
+	}
+	switch {
+	case unhealthy == len(status.Panels):
+		return withExitCode(exitFailed, fmt.Errorf("no panel is healthy"))
+	case unhealthy > 0:
+		return withExitCode(exitDegraded, fmt.Errorf("%d of %d panel(s) unhealthy", unhealthy, len(status.Panels)))
+	}
+	return nil
+}
+
+func cmdStatus() error {
+	ctx := context.Background()
+
+	status, shinedErr, err := collectStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if output.structured() {
+		if err := output.render(status); err != nil {
+			return err
+		}
+		return statusExit(status)
+	}
+
+	if shinedErr != nil {
+		Warning(fmt.Sprintf("Failed to query shined: %v, falling back to discovery", shinedErr))
+	}
+
+	if status.Shined != nil {
+		uptime := time.Duration(status.Shined.UptimeMs) * time.Millisecond
+		uptimeStr := uptime.Truncate(time.Second).String()
+		Header(fmt.Sprintf("Shine Status (v%s, uptime: %s)", status.Shined.Version, uptimeStr))
+	} else if len(status.Panels) > 0 {
+		Header(fmt.Sprintf("Shine Status (%d panel(s))", len(status.Panels)))
+	}
+
+	if len(status.Panels) == 0 {
+		Warning("No panels running")
+		Info("Start panels with: shine start")
+		return statusExit(status)
+	}
+
+	for _, panel := range status.Panels {
+		if panel.Error != "" {
+			fmt.Println()
+			fmt.Printf("%s %s\n", styleBold.Render("Panel:"), panel.Instance)
+			Error(panel.Error)
+			continue
+		}
+		displayPrisms(panel.Instance, panel.source, panel.Prisms)
+	}
+
+	return statusExit(status)
+}
+
+// logsOutput is the shine logs listing in machine-readable form
+type logsOutput struct {
+	Dir  string    `json:"dir"`
+	Logs []logFile `json:"logs"`
+}
+
+type logFile struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Size       int64  `json:"size"`                  // bytes; -1 if unreadable
+	ModifiedMs int64  `json:"modified_ms,omitempty"` // unix ms of the last write
+}
+
+// logsDir is the directory shine logs reads
+func logsDir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
+	return filepath.Join(home, ".local", "share", "shine", "logs"), nil
+}
 
-	logDir := filepath.Join(home, ".local", "share", "shine", "logs")
+func cmdLogs(panelID string) error {
+	logDir, err := logsDir()
+	if err != nil {
+		return err
+	}
 
 	if panelID == "" {
-		Info(fmt.Sprintf("Log directory: %s", logDir))
-
 		files, err := os.ReadDir(logDir)
 		if err != nil {
 			return fmt.Errorf("failed to read log directory: %w", err)
 		}
 
-		if len(files) == 0 {
+		listing := logsOutput{Dir: logDir, Logs: []logFile{}}
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			entry := logFile{Name: file.Name(), Path: filepath.Join(logDir, file.Name()), Size: -1}
+			if info, err := file.Info(); err == nil {
+				entry.Size = info.Size()
+				entry.ModifiedMs = info.ModTime().UnixMilli()
+			}
+			listing.Logs = append(listing.Logs, entry)
+		}
+
+		if output.structured() {
+			return output.render(listing)
+		}
+
+		Info(fmt.Sprintf("Log directory: %s", logDir))
+
+		if len(listing.Logs) == 0 {
 			Warning("No log files found")
 			return nil
 		}
 
 		table := NewTable("Log File", "Size")
-		for _, file := range files {
-			if file.IsDir() {
-				continue
-			}
-			info, _ := file.Info()
+		for _, entry := range listing.Logs {
 			size := "?"
-			if info != nil {
-				size = fmt.Sprintf("%d bytes", info.Size())
+			if entry.Size >= 0 {
+				size = fmt.Sprintf("%d bytes", entry.Size)
 			}
-			table.AddRow(file.Name(), size)
+			table.AddRow(entry.Name, size)
 		}
 
 		table.Print()
@@ -0,0 +1,503 @@
+// completion.go generates shell completion scripts. The scripts call back
+// into `shine __complete`, which completes commands and flags from the
+// table below, and panel and prism names from the running panels' state
+// files, falling back to the configured prisms.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/paths"
+	"github.com/starbased-co/shine/pkg/state"
+)
+
+const completionUsage = "usage: shine completion <bash|zsh|fish>"
+
+// completeKind is what a positional argument or a flag's value completes to
+type completeKind int
+
+const (
+	completeNone   completeKind = iota // a flag that takes no value
+	completeText                       // free text, nothing to offer
+	completePanel                      // panel instances
+	completePrism                      // prisms in the panel named earlier on the line
+	completeSignal                     // signal names for shine prism signal
+	completeKey                        // key names for shine send
+	completeTopic                      // help topics
+	completeLog                        // log files
+	completeShell                      // shells with a completion script
+	completeCaptureFormat
+	completeOutputMode
+	completeFile
+	completeDir
+)
+
+// completionValues are the fixed candidates of the static kinds
+var completionValues = map[completeKind][]string{
+	completeSignal: {"SIGHUP", "SIGINT", "SIGQUIT", "SIGUSR1", "SIGUSR2", "SIGTERM", "SIGKILL", "SIGWINCH"},
+	completeKey: {
+		"Enter", "Tab", "Esc", "Space", "BSpace", "Up", "Down", "Left", "Right",
+		"Home", "End", "PageUp", "PageDown", "Insert", "Delete",
+		"F1", "F2", "F3", "F4", "F5", "F6", "F7", "F8", "F9", "F10", "F11", "F12",
+	},
+	completeShell:         {"bash", "zsh", "fish"},
+	completeCaptureFormat: {"text", "ansi", "html", "svg"},
+	completeOutputMode:    {outputText, outputJSON, outputYAML},
+}
+
+type completionFlag struct {
+	names []string // e.g. "-o", "--output"
+	desc  string
+	value completeKind
+}
+
+type completionCommand struct {
+	name        string
+	desc        string
+	flags       []completionFlag
+	args        []completeKind // positional arguments, in order
+	variadic    bool           // the last argument repeats
+	subcommands []*completionCommand
+}
+
+// outputFlags are the global output flags, also accepted after the
+// commands with structured output
+var outputFlags = []completionFlag{
+	{[]string{"-o", "--output"}, "Output format", completeOutputMode},
+	{[]string{"--format"}, "Go template for the output", completeText},
+}
+
+// prismCommand is a shine prism action taking a panel and a prism
+func prismCommand(name, desc string) *completionCommand {
+	return &completionCommand{name: name, desc: desc, args: []completeKind{completePanel, completePrism}}
+}
+
+// completionTree describes every shine command, flag and argument
+var completionTree = &completionCommand{
+	name: "shine",
+	flags: append([]completionFlag{
+		{[]string{"-h", "--help"}, "Show help", completeNone},
+		{[]string{"-v", "--version"}, "Show version", completeNone},
+	}, outputFlags...),
+	subcommands: []*completionCommand{
+		{name: "start", desc: "Start the shine service"},
+		{name: "stop", desc: "Stop all panels"},
+		{name: "reload", desc: "Reload configuration"},
+		{name: "status", desc: "Show panel status", flags: outputFlags},
+		{name: "top", desc: "Live dashboard of panels and prisms"},
+		{
+			name:  "prism",
+			desc:  "Control prisms in a panel",
+			flags: outputFlags,
+			subcommands: []*completionCommand{
+				prismCommand("up", "Start a prism"),
+				prismCommand("down", "Stop a prism"),
+				prismCommand("fg", "Bring a prism to the foreground"),
+				prismCommand("bg", "Send a prism to the background"),
+				prismCommand("restart", "Relaunch a prism in place"),
+				{name: "signal", desc: "Send a signal to a prism", args: []completeKind{completePanel, completePrism, completeSignal}},
+				{name: "list", desc: "List a panel's prisms", flags: outputFlags, args: []completeKind{completePanel}},
+			},
+		},
+		{
+			name: "send",
+			desc: "Type into a prism",
+			flags: []completionFlag{
+				{[]string{"-l", "--literal"}, "Send the arguments as text", completeNone},
+				{[]string{"-p", "--paste"}, "Send the arguments as a bracketed paste", completeNone},
+				{[]string{"--stdin"}, "Append input read from stdin", completeNone},
+			},
+			args:     []completeKind{completePanel, completePrism, completeKey},
+			variadic: true,
+		},
+		{
+			name: "capture",
+			desc: "Print a prism's current screen",
+			flags: []completionFlag{
+				{[]string{"-f", "--format"}, "Capture format", completeCaptureFormat},
+				{[]string{"-s", "--scrollback"}, "Include scrollback", completeNone},
+				{[]string{"-o", "--output"}, "Write to a file", completeFile},
+			},
+			args: []completeKind{completePanel, completePrism},
+		},
+		{
+			name:  "attach",
+			desc:  "Mirror a panel's foreground prism",
+			flags: []completionFlag{{[]string{"-r", "--read-only"}, "Watch without sending input", completeNone}},
+			args:  []completeKind{completePanel},
+		},
+		{
+			name: "dev",
+			desc: "Run a prism from source",
+			flags: []completionFlag{
+				{[]string{"-b", "--build"}, "Build command", completeText},
+				{[]string{"-p", "--panel"}, "Panel name", completePanel},
+				{[]string{"-k", "--keep"}, "Keep the panel running on exit", completeNone},
+			},
+			args: []completeKind{completeDir},
+		},
+		{name: "logs", desc: "View logs", flags: outputFlags, args: []completeKind{completeLog}},
+		{name: "help", desc: "Show command help", args: []completeKind{completeTopic}},
+		{name: "completion", desc: "Print a shell completion script", args: []completeKind{completeShell}},
+	},
+}
+
+func (c *completionCommand) flag(name string) *completionFlag {
+	for i := range c.flags {
+		if slices.Contains(c.flags[i].names, name) {
+			return &c.flags[i]
+		}
+	}
+	return nil
+}
+
+func (c *completionCommand) subcommand(name string) *completionCommand {
+	for _, sub := range c.subcommands {
+		if sub.name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// candidate is a completion with an optional description
+type candidate struct {
+	value string
+	desc  string
+}
+
+// completeWords completes the last of words, the arguments after "shine" up
+// to and including the word under the cursor. A kind of completeFile or
+// completeDir asks the shell to complete paths instead.
+func completeWords(words []string) ([]candidate, completeKind) {
+	cmd := completionTree
+	var positional []string
+	var pending *completionFlag // flag whose value comes next
+
+	cur := words[len(words)-1]
+	for _, word := range words[:len(words)-1] {
+		switch {
+		case pending != nil:
+			pending = nil
+		case len(word) > 1 && word[0] == '-':
+			if f := cmd.flag(word); f != nil && f.value != completeNone {
+				pending = f
+			}
+		case len(cmd.subcommands) > 0:
+			if cmd = cmd.subcommand(word); cmd == nil {
+				return nil, completeNone
+			}
+		default:
+			positional = append(positional, word)
+		}
+	}
+
+	if pending != nil {
+		return completeValues(pending.value, cmd, positional, cur, "")
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		if name, value, ok := strings.Cut(cur, "="); ok {
+			if f := cmd.flag(name); f != nil && f.value != completeNone {
+				return completeValues(f.value, cmd, positional, value, name+"=")
+			}
+			return nil, completeNone
+		}
+
+		var flags []candidate
+		for _, f := range cmd.flags {
+			for _, name := range f.names {
+				if strings.HasPrefix(name, cur) {
+					flags = append(flags, candidate{name, f.desc})
+				}
+			}
+		}
+		return flags, completeNone
+	}
+
+	if len(cmd.subcommands) > 0 {
+		var subs []candidate
+		for _, sub := range cmd.subcommands {
+			if strings.HasPrefix(sub.name, cur) {
+				subs = append(subs, candidate{sub.name, sub.desc})
+			}
+		}
+		return subs, completeNone
+	}
+
+	i := len(positional)
+	if i >= len(cmd.args) {
+		if !cmd.variadic || len(cmd.args) == 0 {
+			return nil, completeNone
+		}
+		i = len(cmd.args) - 1
+	}
+	return completeValues(cmd.args[i], cmd, positional, cur, "")
+}
+
+// completeValues lists the candidates of a kind that start with cur, each
+// prefixed with prefix
+func completeValues(kind completeKind, cmd *completionCommand, positional []string, cur, prefix string) ([]candidate, completeKind) {
+	var all []candidate
+	switch kind {
+	case completeFile, completeDir:
+		return nil, kind
+	case completePanel:
+		for _, panel := range completionPanels() {
+			all = append(all, candidate{value: panel})
+		}
+	case completePrism:
+		if i := slices.Index(cmd.args, completePanel); i >= 0 && i < len(positional) {
+			all = completionPrisms(positional[i])
+		}
+	case completeTopic:
+		for _, name := range append(registry.Names(), "list", "categories") {
+			if name != "" {
+				all = append(all, candidate{value: name})
+			}
+		}
+	case completeLog:
+		if dir, err := logsDir(); err == nil {
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					all = append(all, candidate{value: strings.TrimSuffix(entry.Name(), ".log")})
+				}
+			}
+		}
+	default:
+		for _, value := range completionValues[kind] {
+			all = append(all, candidate{value: value})
+		}
+	}
+
+	var matches []candidate
+	for _, c := range all {
+		if strings.HasPrefix(c.value, cur) {
+			c.value = prefix + c.value
+			matches = append(matches, c)
+		}
+	}
+	return matches, completeNone
+}
+
+// completionPanels lists running panels from shined's state file and the
+// panel sockets, or the configured panels when none are running
+func completionPanels() []string {
+	var panels []string
+	if reader, err := state.OpenShinedStateReader(paths.ShinedState()); err == nil {
+		s, err := reader.Read()
+		reader.Close()
+		if err == nil {
+			for _, entry := range s.ActivePanels() {
+				panels = append(panels, entry.GetInstance())
+			}
+		}
+	}
+	if instances, err := discoverPrismInstances(); err == nil {
+		panels = append(panels, instances...)
+	}
+
+	if len(panels) == 0 {
+		cfg, err := config.Load(config.DefaultConfigPath())
+		if err != nil {
+			return nil
+		}
+		for _, pc := range cfg.Prisms {
+			instances, err := pc.GetInstances()
+			if err != nil {
+				continue
+			}
+			if len(instances) == 0 {
+				panels = append(panels, pc.Name)
+			}
+			for _, inst := range instances {
+				panels = append(panels, config.InstanceName(pc.Name, inst.Name))
+			}
+		}
+	}
+
+	slices.Sort(panels)
+	return slices.Compact(panels)
+}
+
+// completionPrisms lists the prisms running in a panel from its state file,
+// or the apps its prism configures when the panel is not running
+func completionPrisms(panel string) []candidate {
+	if reader, err := state.OpenPrismStateReader(paths.PrismState(panel)); err == nil {
+		s, err := reader.Read()
+		reader.Close()
+		if err == nil {
+			var prisms []candidate
+			for _, entry := range s.ActivePrisms() {
+				desc := "background"
+				if entry.GetState() == state.PrismStateFg {
+					desc = "foreground"
+				}
+				prisms = append(prisms, candidate{entry.GetName(), desc})
+			}
+			return prisms
+		}
+	}
+
+	cfg, err := config.Load(config.DefaultConfigPath())
+	if err != nil {
+		return nil
+	}
+	name, _, _ := strings.Cut(panel, ":")
+	pc, ok := cfg.Prisms[name]
+	if !ok {
+		return nil
+	}
+	var prisms []candidate
+	for _, app := range pc.AppOrder() {
+		prisms = append(prisms, candidate{app, "configured"})
+	}
+	return prisms
+}
+
+// cmdComplete is the hidden command behind the completion scripts:
+//
+//	shine __complete <shell> [words...]
+//
+// It prints one candidate per line in the shell's format, or ":file" or
+// ":dir" for the shell to complete paths itself.
+func cmdComplete(args []string) error {
+	if len(args) < 1 {
+		return withExitCode(exitUsage, fmt.Errorf("usage: shine __complete <shell> [words...]"))
+	}
+	shell, words := args[0], args[1:]
+	if len(words) == 0 {
+		words = []string{""}
+	}
+
+	candidates, kind := completeWords(words)
+	switch kind {
+	case completeFile:
+		fmt.Println(":file")
+		return nil
+	case completeDir:
+		fmt.Println(":dir")
+		return nil
+	}
+
+	for _, c := range candidates {
+		switch {
+		case shell == "zsh":
+			// _describe splits value and description at the first
+			// unescaped colon
+			value := strings.ReplaceAll(c.value, ":", `\:`)
+			if c.desc != "" {
+				value += ":" + c.desc
+			}
+			fmt.Println(value)
+		case shell == "fish" && c.desc != "":
+			fmt.Printf("%s\t%s\n", c.value, c.desc)
+		default:
+			fmt.Println(c.value)
+		}
+	}
+	return nil
+}
+
+// cmdCompletion prints the completion script for a shell
+func cmdCompletion(args []string) error {
+	if len(args) != 1 {
+		return withExitCode(exitUsage, fmt.Errorf(completionUsage))
+	}
+
+	var script string
+	switch args[0] {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		return withExitCode(exitUsage, fmt.Errorf("unsupported shell: %s\n%s", args[0], completionUsage))
+	}
+	_, err := os.Stdout.WriteString(script)
+	return err
+}
+
+const bashCompletion = `# bash completion for shine
+#
+#   source <(shine completion bash)
+
+_shine() {
+    local line=${COMP_LINE:0:COMP_POINT}
+    local -a words
+    read -ra words <<< "$line"
+    [[ $line == *[[:space:]] ]] && words+=("")
+    local cur=${words[${#words[@]}-1]}
+
+    local IFS=$'\n'
+    local out
+    out=$(shine __complete bash "${words[@]:1}" 2>/dev/null) || return
+
+    case $out in
+    :file) mapfile -t COMPREPLY < <(compgen -f -- "$cur") ;;
+    :dir) mapfile -t COMPREPLY < <(compgen -d -- "$cur") ;;
+    '') COMPREPLY=() ;;
+    *) mapfile -t COMPREPLY <<< "$out" ;;
+    esac
+
+    # bash splits words at colons, as in panel names like clock:left
+    if [[ $cur == *:* && $COMP_WORDBREAKS == *:* ]]; then
+        local prefix=${cur%"${cur##*:}"}
+        COMPREPLY=("${COMPREPLY[@]#"$prefix"}")
+    fi
+}
+
+complete -o filenames -F _shine shine
+`
+
+const zshCompletion = `#compdef shine
+#
+#   source <(shine completion zsh)
+
+_shine() {
+    local -a out
+    out=(${(f)"$(shine __complete zsh "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+
+    case $out[1] in
+    :file) _files ;;
+    :dir) _files -/ ;;
+    *) _describe 'shine' out ;;
+    esac
+}
+
+if [[ $funcstack[1] == _shine ]]; then
+    _shine "$@"
+else
+    compdef _shine shine
+fi
+`
+
+const fishCompletion = `# fish completion for shine
+#
+#   shine completion fish | source
+
+function __shine_complete
+    set -l words (commandline -opc)
+    set -l cur (commandline -ct)
+    set -l out (shine __complete fish $words[2..-1] "$cur" 2>/dev/null)
+
+    switch "$out[1]"
+        case :file
+            __fish_complete_path "$cur"
+        case :dir
+            __fish_complete_directories "$cur"
+        case '*'
+            printf '%s\n' $out
+    end
+end
+
+complete -c shine -f -a '(__shine_complete)'
+`
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCompleteWords(t *testing.T) {
+	// A prism name unlikely to match a running panel, so that its apps
+	// complete from this config
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	configContent := `[prisms.zzdesk]
+order = ["mail", "chat"]
+
+[prisms.zzdesk.apps.mail]
+path = "mail"
+
+[prisms.zzdesk.apps.chat]
+path = "chat"
+
+[prisms.zzdesk.apps.music]
+path = "music"
+`
+	if err := os.MkdirAll(filepath.Join(configHome, "shine"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configHome, "shine", "shine.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		words []string
+		want  []string
+		kind  completeKind
+	}{
+		{"subcommands", []string{"st"}, []string{"start", "stop", "status"}, completeNone},
+		{"nested subcommands", []string{"prism", "r"}, []string{"restart"}, completeNone},
+		{"unknown subcommand", []string{"bogus", ""}, nil, completeNone},
+		{"flags", []string{"capture", "--s"}, []string{"--scrollback"}, completeNone},
+		{"flag value", []string{"-o", "y"}, []string{"yaml"}, completeNone},
+		{"flag=value", []string{"capture", "--format=s"}, []string{"--format=svg"}, completeNone},
+		{"flag=value without a value", []string{"attach", "--read-only="}, nil, completeNone},
+		{"file flag", []string{"capture", "-o", ""}, nil, completeFile},
+		{"prism after panel", []string{"prism", "fg", "zzdesk", ""}, []string{"mail", "chat", "music"}, completeNone},
+		{"prism after panel and flag", []string{"capture", "-f", "ansi", "zzdesk", "m"}, []string{"mail", "music"}, completeNone},
+		{"signal after prism", []string{"prism", "signal", "zzdesk", "mail", "SIGU"}, []string{"SIGUSR1", "SIGUSR2"}, completeNone},
+		{"no argument past the last", []string{"prism", "fg", "zzdesk", "mail", ""}, nil, completeNone},
+		{"send key", []string{"send", "zzdesk", "mail", "Ent"}, []string{"Enter"}, completeNone},
+		{"send keys repeat", []string{"send", "zzdesk", "mail", "Up", "Up", "F1"}, []string{"F1", "F10", "F11", "F12"}, completeNone},
+		{"dev directory", []string{"dev", ""}, nil, completeDir},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, kind := completeWords(tt.words)
+			var got []string
+			for _, c := range candidates {
+				got = append(got, c.value)
+			}
+			if !slices.Equal(got, tt.want) || kind != tt.kind {
+				t.Errorf("completeWords(%q) = %q, %d; want %q, %d", tt.words, got, kind, tt.want, tt.kind)
+			}
+		})
+	}
+
+	// Panels come from the running panels when there are any, so compare
+	// the flag with a panel argument rather than with the config
+	flag, _ := completeWords([]string{"dev", "--panel", ""})
+	arg, _ := completeWords([]string{"attach", ""})
+	if !slices.Equal(flag, arg) {
+		t.Errorf("dev --panel completes %v, want the panels %v", flag, arg)
+	}
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/paths"
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+const devUsage = "usage: shine dev [--build cmd] [--panel name] [--keep] <prism-dir>"
+
+// devPollInterval is how often shine dev looks for changed files. A binary
+// must be unchanged for one interval before it is loaded, so a restart
+// never picks up a half-written file.
+const devPollInterval = 500 * time.Millisecond
+
+// devNoticeLines bounds the build output shown in the panel
+const devNoticeLines = 40
+
+// cmdDev runs a prism from its source directory in a panel and restarts it
+// whenever its binary changes, rebuilding first when a build command is
+// configured:
+//
+//	shine dev ./prisms/clock
+//	shine dev --build "go build -o shine-clock ." ./prisms/clock
+func cmdDev(args []string) error {
+	var build, panel string
+	var keep bool
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-b" || arg == "--build" || arg == "-p" || arg == "--panel":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value\n%s", arg, devUsage)
+			}
+			i++
+			if arg == "-b" || arg == "--build" {
+				build = args[i]
+			} else {
+				panel = args[i]
+			}
+		case strings.HasPrefix(arg, "--build="):
+			build = strings.TrimPrefix(arg, "--build=")
+		case strings.HasPrefix(arg, "--panel="):
+			panel = strings.TrimPrefix(arg, "--panel=")
+		case arg == "-k" || arg == "--keep":
+			keep = true
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag: %s\n%s", arg, devUsage)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) != 1 {
+		return fmt.Errorf(devUsage)
+	}
+
+	dir, err := filepath.Abs(paths.ExpandHome(positional[0]))
+	if err != nil {
+		return err
+	}
+
+	prism, err := config.LoadPrismDir(dir)
+	if err != nil {
+		return err
+	}
+	if err := prism.Config.Validate(); err != nil {
+		return fmt.Errorf("invalid prism.toml: %w", err)
+	}
+
+	d := &devSession{dir: dir, name: prism.Config.Name, build: build}
+	if dev := prism.Config.Dev; dev != nil {
+		if d.build == "" {
+			d.build = dev.Build
+		}
+		d.watch = dev.Watch
+	}
+	d.instance = panel
+	if d.instance == "" {
+		d.instance = config.InstanceName(d.name, "dev")
+	}
+
+	if !isShinedRunning() {
+		return withExitCode(exitNotRunning, fmt.Errorf("shined is not running (start it with: shine start)"))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if d.build != "" {
+		Info(fmt.Sprintf("Building %s...", d.name))
+		if _, err := d.runBuild(ctx); err != nil {
+			return fmt.Errorf("build failed: %w", err)
+		}
+	}
+
+	// Binaries are resolved after the first build, which may create them
+	if prism, err = config.LoadPrismDir(dir); err != nil {
+		return err
+	}
+	d.config = prism.Config
+	if d.apps, err = devApps(prism.Config, dir); err != nil {
+		return err
+	}
+	for i := range d.apps {
+		d.apps[i].loaded = statFile(d.apps[i].path)
+		d.apps[i].seen = d.apps[i].loaded
+	}
+	d.sources = d.sourceStamp()
+
+	spawned, err := d.ensurePanel(ctx)
+	if err != nil {
+		return err
+	}
+	if spawned && !keep {
+		defer d.killPanel()
+	}
+
+	Success(fmt.Sprintf("Running %s in panel %s; watching for changes (Ctrl-C to stop)", d.name, d.instance))
+	d.run(ctx)
+	fmt.Println()
+	return nil
+}
+
+// devSession is one `shine dev` run
+type devSession struct {
+	dir      string
+	name     string
+	build    string   // build command, empty to only watch binaries
+	watch    []string // source globs, empty for all but hidden files
+	instance string   // panel the prism runs in
+	config   *config.PrismConfig
+	apps     []devApp
+
+	sources sourceStamp
+	notice  string // app backgrounded by the build error notice, if shown
+}
+
+// devApp is an app of the prism whose binary lives in its directory
+type devApp struct {
+	name   string
+	path   string
+	loaded fileStamp // binary the running app was started from
+	seen   fileStamp // binary at the last poll
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+type sourceStamp struct {
+	latest time.Time
+	files  int
+}
+
+// devApps lists the enabled apps whose binaries are in dir; those are the
+// ones a rebuild can change
+func devApps(pc *config.PrismConfig, dir string) ([]devApp, error) {
+	var apps []devApp
+	for _, name := range pc.AppOrder() {
+		app := pc.GetApps()[name]
+		if app.Enabled && app.ResolvedPath != "" && filepath.Dir(app.ResolvedPath) == dir {
+			apps = append(apps, devApp{name: name, path: app.ResolvedPath})
+		}
+	}
+	if len(apps) == 0 {
+		return nil, fmt.Errorf("no prism binary found in %s (build it first or set [dev] build in prism.toml)", dir)
+	}
+	return apps, nil
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// sourceStamp summarizes the watched source files, so that editing,
+// adding or removing one changes it
+func (d *devSession) sourceStamp() sourceStamp {
+	var stamp sourceStamp
+	filepath.WalkDir(d.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != d.dir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !d.watches(path) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		stamp.files++
+		if info.ModTime().After(stamp.latest) {
+			stamp.latest = info.ModTime()
+		}
+		return nil
+	})
+	return stamp
+}
+
+// watches reports whether path is a source file to rebuild on. Patterns
+// match the path relative to the prism directory or the file name.
+func (d *devSession) watches(path string) bool {
+	if slices.ContainsFunc(d.apps, func(app devApp) bool { return app.path == path }) {
+		return false
+	}
+	if len(d.watch) == 0 {
+		return true
+	}
+	rel, _ := filepath.Rel(d.dir, path)
+	for _, pattern := range d.watch {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// runBuild runs the build command in the prism directory, echoing its
+// output as it goes and returning it
+func (d *devSession) runBuild(ctx context.Context) ([]byte, error) {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", d.build)
+	cmd.Dir = d.dir
+	cmd.Stdout = io.MultiWriter(&out, os.Stdout)
+	cmd.Stderr = cmd.Stdout
+	err := cmd.Run()
+	return out.Bytes(), err
+}
+
+// run polls for changes until ctx is cancelled
+func (d *devSession) run(ctx context.Context) {
+	ticker := time.NewTicker(devPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if d.build != "" {
+			if stamp := d.sourceStamp(); stamp != d.sources {
+				d.sources = stamp
+				d.rebuild(ctx)
+			}
+		}
+
+		for i := range d.apps {
+			app := &d.apps[i]
+			stamp := statFile(app.path)
+			settled := stamp == app.seen
+			app.seen = stamp
+			if !settled || stamp == app.loaded || stamp.size == 0 {
+				continue
+			}
+
+			app.loaded = stamp
+			if err := d.restart(ctx, app.name); err != nil {
+				Error(fmt.Sprintf("Failed to restart %s: %v", app.name, err))
+			}
+		}
+	}
+}
+
+// rebuild runs the build after a source change. A failed build leaves the
+// running app alone and shows the errors in the panel instead; the next
+// successful build brings the app back.
+func (d *devSession) rebuild(ctx context.Context) {
+	Info("Source changed, rebuilding...")
+
+	out, err := d.runBuild(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	// Files the build wrote are not source changes
+	d.sources = d.sourceStamp()
+
+	if err != nil {
+		Error(fmt.Sprintf("Build failed: %v", err))
+		d.showBuildErrors(ctx, out, err)
+		return
+	}
+
+	Success("Build succeeded")
+	if d.notice != "" {
+		d.withPanel(ctx, func(client *rpc.PrismClient) error {
+			_, err := client.Fg(ctx, d.notice)
+			return err
+		})
+		d.notice = ""
+	}
+}
+
+// showBuildErrors replaces the panel contents with the tail of the build
+// output
+func (d *devSession) showBuildErrors(ctx context.Context, out []byte, buildErr error) {
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if len(lines) > devNoticeLines {
+		lines = lines[len(lines)-devNoticeLines:]
+	}
+	text := fmt.Sprintf("shine dev: build failed (%v)\n\n%s\n", buildErr, strings.Join(lines, "\n"))
+
+	err := d.withPanel(ctx, func(client *rpc.PrismClient) error {
+		result, err := client.Notice(ctx, text)
+		if err == nil && result.Background != "" {
+			d.notice = result.Background
+		}
+		return err
+	})
+	if err != nil {
+		Warning(fmt.Sprintf("Failed to show build errors in %s: %v", d.instance, err))
+	}
+}
+
+// restart relaunches an app in place from its new binary, keeping its
+// panel, MRU position and size. If the panel is gone, e.g. because the last
+// build crashed on startup, it is spawned again.
+func (d *devSession) restart(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, prismTimeout)
+	defer cancel()
+
+	client, err := rpc.NewPrismClient(paths.PrismSocket(d.instance))
+	if err != nil {
+		Info(fmt.Sprintf("Panel %s is gone, spawning it again", d.instance))
+		_, err := d.ensurePanel(ctx)
+		return err
+	}
+	defer client.Close()
+
+	list, err := client.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(list.Prisms, func(p rpc.PrismInfo) bool { return p.Name == name }) {
+		if _, err := client.Up(ctx, name); err != nil {
+			return err
+		}
+		Success(fmt.Sprintf("Started %s", name))
+	} else {
+		result, err := client.Restart(ctx, name)
+		if err != nil {
+			return err
+		}
+		Success(fmt.Sprintf("Restarted %s (PID %d)", name, result.PID))
+	}
+
+	if d.notice == name {
+		d.notice = ""
+		_, err = client.Fg(ctx, name)
+	}
+	return err
+}
+
+// withPanel calls fn with a client for the dev panel's prismctl
+func (d *devSession) withPanel(ctx context.Context, fn func(*rpc.PrismClient) error) error {
+	client, err := rpc.NewPrismClient(paths.PrismSocket(d.instance))
+	if err != nil {
+		return fmt.Errorf("panel %s is not running", d.instance)
+	}
+	defer client.Close()
+	return fn(client)
+}
+
+// ensurePanel reuses the dev panel if it is running and asks shined to
+// spawn it otherwise. Reports whether it was spawned.
+func (d *devSession) ensurePanel(ctx context.Context) (bool, error) {
+	client, err := connectShined()
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to shined: %w", err)
+	}
+	defer client.Close()
+
+	panels, err := client.ListPanels(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list panels: %w", err)
+	}
+	for _, panel := range panels.Panels {
+		if panel.Instance == d.instance {
+			Info(fmt.Sprintf("Reusing panel %s", d.instance))
+			return false, nil
+		}
+	}
+
+	pc := *d.config
+	pc.Enabled = true
+	pc.Instances = nil
+
+	data, err := json.Marshal(&pc)
+	if err != nil {
+		return false, err
+	}
+	var spawnConfig map[string]any
+	if err := json.Unmarshal(data, &spawnConfig); err != nil {
+		return false, err
+	}
+	spawnConfig["instance"] = d.instance
+
+	Info(fmt.Sprintf("Spawning panel %s...", d.instance))
+	if _, err := client.SpawnPanel(ctx, spawnConfig); err != nil {
+		return false, fmt.Errorf("failed to spawn panel %s: %w", d.instance, err)
+	}
+	return true, nil
+}
+
+// killPanel stops the panel shine dev spawned
+func (d *devSession) killPanel() {
+	client, err := connectShined()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	if _, err := client.KillPanel(context.Background(), d.instance); err != nil {
+		Warning(fmt.Sprintf("Failed to stop panel %s: %v", d.instance, err))
+		return
+	}
+	Muted(fmt.Sprintf("Stopped panel %s", d.instance))
+}
@@ -0,0 +1,284 @@
+// format.go renders command results for scripts: JSON, YAML or a Go
+// template, selected by the global --output and --format flags.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Output modes accepted by --output
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// outputOptions are the global output flags
+type outputOptions struct {
+	mode     string             // outputText, outputJSON or outputYAML
+	template *template.Template // from --format; overrides mode
+}
+
+// output holds the flags for this invocation
+var output = outputOptions{mode: outputText}
+
+// structured reports whether results should be rendered for a machine
+// rather than styled for a terminal
+func (o outputOptions) structured() bool {
+	return o.mode != outputText || o.template != nil
+}
+
+// parseOutputFlags removes --output/-o and --format from args. With leading
+// set, it stops at the first argument that is not one of them, i.e. the
+// command.
+func parseOutputFlags(args []string, leading bool) ([]string, error) {
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+
+		switch name {
+		case "-o", "--output", "--format":
+		default:
+			if leading {
+				return append(rest, args[i:]...), nil
+			}
+			rest = append(rest, arg)
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+
+		if name == "--format" {
+			tmpl, err := template.New("format").Funcs(templateFuncs).Parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --format template: %w", err)
+			}
+			output.template = tmpl
+			continue
+		}
+
+		switch value {
+		case outputText, outputJSON, outputYAML:
+			output.mode = value
+		default:
+			return nil, fmt.Errorf("unknown output format %q (want json, yaml or text)", value)
+		}
+	}
+	return rest, nil
+}
+
+// templateFuncs are available to --format templates
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": func(sep string, items []any) string {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, sep)
+	},
+}
+
+// render writes v to stdout in the selected format. Templates see the same
+// fields as the JSON output, under their JSON names.
+func (o outputOptions) render(v any) error {
+	return o.renderTo(os.Stdout, v)
+}
+
+func (o outputOptions) renderTo(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+
+	if o.template != nil {
+		var doc any
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+
+		var b strings.Builder
+		if err := o.template.Execute(&b, doc); err != nil {
+			return fmt.Errorf("failed to execute --format template: %w", err)
+		}
+		out := b.String()
+		if out != "" && !strings.HasSuffix(out, "\n") {
+			out += "\n"
+		}
+		_, err := io.WriteString(w, out)
+		return err
+	}
+
+	switch o.mode {
+	case outputYAML:
+		out, err := jsonToYAML(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	default:
+		var b bytes.Buffer
+		if err := json.Indent(&b, data, "", "  "); err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		b.WriteByte('\n')
+		_, err := w.Write(b.Bytes())
+		return err
+	}
+}
+
+// yamlField is a key of a decoded JSON object; objects are kept as ordered
+// fields so YAML lists keys in the same order as JSON
+type yamlField struct {
+	key   string
+	value any
+}
+
+// jsonToYAML converts a JSON document to block-style YAML
+func jsonToYAML(data []byte) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	doc, err := decodeOrdered(dec)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if scalar, ok := yamlInline(doc); ok {
+		b.WriteString(scalar + "\n")
+	} else {
+		writeYAML(&b, doc, 0)
+	}
+	return b.String(), nil
+}
+
+// decodeOrdered decodes the next JSON value, keeping object key order
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		fields := []yamlField{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, yamlField{key.(string), value})
+		}
+		_, err := dec.Token()
+		return fields, err
+
+	case json.Delim('['):
+		items := []any{}
+		for dec.More() {
+			item, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := dec.Token()
+		return items, err
+	}
+	return tok, nil
+}
+
+// writeYAML writes a non-empty object or array, each line indented by
+// indent spaces
+func writeYAML(b *strings.Builder, v any, indent int) {
+	pad := strings.Repeat(" ", indent)
+
+	switch v := v.(type) {
+	case []yamlField:
+		for _, f := range v {
+			key := yamlString(f.key)
+			if scalar, ok := yamlInline(f.value); ok {
+				fmt.Fprintf(b, "%s%s: %s\n", pad, key, scalar)
+				continue
+			}
+			fmt.Fprintf(b, "%s%s:\n", pad, key)
+			writeYAML(b, f.value, indent+2)
+		}
+
+	case []any:
+		for _, item := range v {
+			if scalar, ok := yamlInline(item); ok {
+				fmt.Fprintf(b, "%s- %s\n", pad, scalar)
+				continue
+			}
+			// Nested blocks start on the dash's line
+			var nested strings.Builder
+			writeYAML(&nested, item, indent+2)
+			b.WriteString(pad + "- " + nested.String()[indent+2:])
+		}
+	}
+}
+
+// yamlInline renders scalars and empty collections, which fit on the line
+// of their key or dash
+func yamlInline(v any) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "null", true
+	case bool:
+		return strconv.FormatBool(v), true
+	case json.Number:
+		return v.String(), true
+	case string:
+		return yamlString(v), true
+	case []yamlField:
+		if len(v) == 0 {
+			return "{}", true
+		}
+	case []any:
+		if len(v) == 0 {
+			return "[]", true
+		}
+	}
+	return "", false
+}
+
+// yamlString quotes s unless YAML would read it back as the same string
+func yamlString(s string) string {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, ":#\n\t\"'\\") ||
+		strings.ContainsAny(s[:1], "-?,[]{}&*!|>%@`") {
+		return strconv.Quote(s)
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+// resetOutput restores the global output flags after a test parses its own
+func resetOutput(t *testing.T) {
+	t.Helper()
+	saved := output
+	output = outputOptions{mode: outputText}
+	t.Cleanup(func() { output = saved })
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"clock", "clock"},
+		{"clock left", "clock left"},
+		{"", `""`},
+		{"yes", `"yes"`},
+		{"No", `"No"`},
+		{"null", `"null"`},
+		{"~", `"~"`},
+		{"1.0", `"1.0"`},
+		{"42", `"42"`},
+		{"1e3", `"1e3"`},
+		{"-x", `"-x"`},
+		{"clock:left", `"clock:left"`},
+		{"a # b", `"a # b"`},
+		{" padded", `" padded"`},
+		{"line\nbreak", `"line\nbreak"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"*alias", `"*alias"`},
+		{"v1.2.3", "v1.2.3"},
+	}
+
+	for _, tt := range tests {
+		if got := yamlString(tt.in); got != tt.want {
+			t.Errorf("yamlString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestJSONToYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"scalar", `"yes"`, "\"yes\"\n"},
+		{"empty object", `{}`, "{}\n"},
+		{
+			"object keeps key order",
+			`{"name":"bar","pid":42,"healthy":true,"error":null,"ratio":0.5}`,
+			"name: bar\npid: 42\nhealthy: true\nerror: null\nratio: 0.5\n",
+		},
+		{
+			"nested arrays of objects",
+			`{"panels":[{"instance":"bar","prisms":[{"name":"clock","pid":1},{"name":"chat","pid":2}]},{"instance":"tray","prisms":[]}]}`,
+			"panels:\n" +
+				"  - instance: bar\n" +
+				"    prisms:\n" +
+				"      - name: clock\n" +
+				"        pid: 1\n" +
+				"      - name: chat\n" +
+				"        pid: 2\n" +
+				"  - instance: tray\n" +
+				"    prisms: []\n",
+		},
+		{
+			"arrays of arrays",
+			`[[1,2],[],{"a":{"b":"1.0"}}]`,
+			"- - 1\n  - 2\n- []\n- a:\n    b: \"1.0\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonToYAML([]byte(tt.json))
+			if err != nil {
+				t.Fatalf("jsonToYAML() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("jsonToYAML() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOutputFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		leading  bool
+		want     []string
+		mode     string
+		template bool
+		wantErr  bool
+	}{
+		{"before the command", []string{"-o", "json", "status"}, true, []string{"status"}, outputJSON, false, false},
+		{"joined value", []string{"--output=yaml", "status"}, true, []string{"status"}, outputYAML, false, false},
+		{"stops at the command", []string{"prism", "list", "-o", "json"}, true, []string{"prism", "list", "-o", "json"}, outputText, false, false},
+		{"after the command", []string{"prism", "list", "bar", "--output", "json"}, false, []string{"prism", "list", "bar"}, outputJSON, false, false},
+		{"template", []string{"status", "--format", "{{.shined}}"}, false, []string{"status"}, outputText, true, false},
+		{"other flags kept", []string{"logs", "-f", "bar"}, false, []string{"logs", "-f", "bar"}, outputText, false, false},
+		{"missing value", []string{"status", "-o"}, false, nil, outputText, false, true},
+		{"unknown mode", []string{"-o", "xml", "status"}, true, nil, outputText, false, true},
+		{"invalid template", []string{"--format", "{{.", "status"}, true, nil, outputText, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetOutput(t)
+
+			got, err := parseOutputFlags(tt.args, tt.leading)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOutputFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseOutputFlags() = %q, want %q", got, tt.want)
+			}
+			if output.mode != tt.mode || (output.template != nil) != tt.template {
+				t.Errorf("output = %q (template %v), want %q (template %v)", output.mode, output.template != nil, tt.mode, tt.template)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	status := &statusOutput{
+		Shined: &shinedStatus{Version: "0.2.0", UptimeMs: 1500, StartupErrors: []string{"bar failed", "tray failed"}},
+		Panels: []panelStatus{{
+			PanelInfo: rpc.PanelInfo{Instance: "bar", Healthy: true},
+			Prisms:    []rpc.PrismInfo{{Name: "clock"}, {Name: "chat"}},
+		}},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"{{.shined.version}}", "0.2.0\n"},
+		{"{{.shined.uptime_ms}}", "1500\n"},
+		{"{{range .panels}}{{.instance}} {{len .prisms}}\n{{end}}", "bar 2\n"},
+		{`{{join "; " .shined.startup_errors}}`, "bar failed; tray failed\n"},
+		{"{{json .shined.version}}", "\"0.2.0\"\n"},
+		{"{{if .missing}}x{{end}}", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			resetOutput(t)
+			if _, err := parseOutputFlags([]string{"--format", tt.format}, false); err != nil {
+				t.Fatalf("parseOutputFlags() error: %v", err)
+			}
+
+			var b strings.Builder
+			if err := output.renderTo(&b, status); err != nil {
+				t.Fatalf("renderTo() error: %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("renderTo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusExit(t *testing.T) {
+	healthy := panelStatus{PanelInfo: rpc.PanelInfo{Instance: "bar", Healthy: true, LastCheckMs: 1}}
+	unchecked := panelStatus{PanelInfo: rpc.PanelInfo{Instance: "dock"}}
+	failing := panelStatus{PanelInfo: rpc.PanelInfo{Instance: "tray", LastCheckMs: 1}}
+	unreadable := panelStatus{PanelInfo: rpc.PanelInfo{Instance: "clock", Healthy: true}, Error: "no state"}
+
+	tests := []struct {
+		name   string
+		panels []panelStatus
+		want   int // 0 for success
+	}{
+		{"no panels", nil, exitNotRunning},
+		{"all healthy", []panelStatus{healthy, unchecked}, 0},
+		{"some unhealthy", []panelStatus{healthy, failing}, exitDegraded},
+		{"unreadable counts as unhealthy", []panelStatus{healthy, unreadable}, exitDegraded},
+		{"none healthy", []panelStatus{failing, unreadable}, exitFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := statusExit(&statusOutput{Panels: tt.panels})
+			code := 0
+			if err != nil {
+				code = exitCode(err)
+			}
+			if code != tt.want {
+				t.Errorf("statusExit() = %v (exit %d), want exit %d", err, code, tt.want)
+			}
+		})
+	}
+}
@@ -1,19 +1,64 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 )
 
 const version = "0.2.0"
 
+// Exit codes, so scripts can tell why a command failed
+const (
+	exitFailed     = 1 // the command failed, or no panel is healthy
+	exitUsage      = 2 // unknown command or invalid flags
+	exitNotRunning = 3 // shined or the named panel is not running
+	exitDegraded   = 4 // running, but some panels are unhealthy
+)
+
+// exitError makes shine exit with a specific code. A nil err exits
+// without a message.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return ""
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error { return e.err }
+
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
 func main() {
-	if len(os.Args) < 2 {
+	args, err := parseOutputFlags(os.Args[1:], true)
+	if err != nil {
+		Error(err.Error())
+		os.Exit(exitUsage)
+	}
+
+	if len(args) < 1 {
 		showHelp("")
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 
-	command := os.Args[1]
+	command := args[0]
+
+	// Commands with structured output also take the flags after the
+	// command name; capture has its own --format and -o
+	switch command {
+	case "status", "logs", "prism":
+		if args, err = parseOutputFlags(args, false); err != nil {
+			Error(err.Error())
+			os.Exit(exitUsage)
+		}
+	}
 
 	switch command {
 	case "-h", "--help":
@@ -24,14 +69,13 @@ func main() {
 		return
 	case "help":
 		topic := ""
-		if len(os.Args) > 2 {
-			topic = os.Args[2]
+		if len(args) > 1 {
+			topic = args[1]
 		}
 		showHelp(topic)
 		return
 	}
 
-	var err error
 	switch command {
 	case "start":
 		err = cmdStart()
@@ -45,10 +89,34 @@ func main() {
 	case "status":
 		err = cmdStatus()
 
+	case "prism":
+		err = cmdPrism(args[1:])
+
+	case "send":
+		err = cmdSend(args[1:])
+
+	case "capture":
+		err = cmdCapture(args[1:])
+
+	case "attach":
+		err = cmdAttach(args[1:])
+
+	case "dev":
+		err = cmdDev(args[1:])
+
+	case "top":
+		err = cmdTop(args[1:])
+
+	case "completion":
+		err = cmdCompletion(args[1:])
+
+	case "__complete":
+		err = cmdComplete(args[1:])
+
 	case "logs":
 		panelID := ""
-		if len(os.Args) > 2 {
-			panelID = os.Args[2]
+		if len(args) > 1 {
+			panelID = args[1]
 		}
 		err = cmdLogs(panelID)
 
@@ -56,11 +124,32 @@ func main() {
 		Error(fmt.Sprintf("Unknown command: %s", command))
 		fmt.Println()
 		showHelp("")
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 
 	if err != nil {
-		Error(err.Error())
-		os.Exit(1)
+		if msg := err.Error(); msg != "" {
+			reportError(msg)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode is the code shine exits with when a command returns err
+func exitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return exitFailed
+}
+
+// reportError prints a command's error, to stderr when stdout carries
+// structured output
+func reportError(msg string) {
+	if output.structured() {
+		fmt.Fprintf(os.Stderr, "shine: %s\n", msg)
+		return
 	}
+	Error(msg)
 }
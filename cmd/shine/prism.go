@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/paths"
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+// prismTimeout bounds a single prism command, long enough for a restart to
+// escalate to SIGKILL
+const prismTimeout = 10 * time.Second
+
+// cmdPrism controls prisms inside a running panel:
+//
+//	shine prism <up|down|fg|bg|restart> <panel> <prism>
+//	shine prism signal <panel> <prism> <signal>
+//	shine prism list <panel>
+func cmdPrism(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: shine prism <up|down|fg|bg|restart|signal|list> <panel> [prism]")
+	}
+
+	action, panel := args[0], args[1]
+	name := ""
+	if len(args) > 2 {
+		name = args[2]
+	}
+
+	switch action {
+	case "up", "down", "fg", "bg", "restart":
+		if name == "" {
+			return fmt.Errorf("usage: shine prism %s <panel> <prism>", action)
+		}
+	case "signal":
+		if name == "" || len(args) < 4 {
+			return fmt.Errorf("usage: shine prism signal <panel> <prism> <signal>")
+		}
+	case "list":
+	default:
+		return fmt.Errorf("unknown prism action: %s", action)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), prismTimeout)
+	defer cancel()
+
+	switch action {
+	case "up":
+		var result *rpc.UpResult
+		err := callPrism(ctx, panel,
+			func(c *rpc.ShinedClient) (err error) { result, err = c.PrismUp(ctx, panel, name); return },
+			func(c *rpc.PrismClient) (err error) { result, err = c.Up(ctx, name); return })
+		if err != nil {
+			return fmt.Errorf("failed to start %s: %w", name, err)
+		}
+		Success(fmt.Sprintf("Started %s in %s (PID %d)", name, panel, result.PID))
+
+	case "down":
+		err := callPrism(ctx, panel,
+			func(c *rpc.ShinedClient) error { _, err := c.PrismDown(ctx, panel, name); return err },
+			func(c *rpc.PrismClient) error { _, err := c.Down(ctx, name); return err })
+		if err != nil {
+			return fmt.Errorf("failed to stop %s: %w", name, err)
+		}
+		Success(fmt.Sprintf("Stopped %s in %s", name, panel))
+
+	case "fg":
+		var result *rpc.FgResult
+		err := callPrism(ctx, panel,
+			func(c *rpc.ShinedClient) (err error) { result, err = c.PrismFg(ctx, panel, name); return },
+			func(c *rpc.PrismClient) (err error) { result, err = c.Fg(ctx, name); return })
+		if err != nil {
+			return fmt.Errorf("failed to foreground %s: %w", name, err)
+		}
+		if result.WasFg {
+			Info(fmt.Sprintf("%s is already in the foreground", name))
+		} else {
+			Success(fmt.Sprintf("Brought %s to the foreground", name))
+		}
+
+	case "bg":
+		var result *rpc.BgResult
+		err := callPrism(ctx, panel,
+			func(c *rpc.ShinedClient) (err error) { result, err = c.PrismBg(ctx, panel, name); return },
+			func(c *rpc.PrismClient) (err error) { result, err = c.Bg(ctx, name); return })
+		if err != nil {
+			return fmt.Errorf("failed to background %s: %w", name, err)
+		}
+		if result.WasBg {
+			Info(fmt.Sprintf("%s is already in the background", name))
+		} else {
+			Success(fmt.Sprintf("Sent %s to the background", name))
+		}
+
+	case "restart":
+		var result *rpc.RestartResult
+		err := callPrism(ctx, panel,
+			func(c *rpc.ShinedClient) (err error) { result, err = c.PrismRestart(ctx, panel, name); return },
+			func(c *rpc.PrismClient) (err error) { result, err = c.Restart(ctx, name); return })
+		if err != nil {
+			return fmt.Errorf("failed to restart %s: %w", name, err)
+		}
+		Success(fmt.Sprintf("Restarted %s in %s (PID %d, restarts: %d)", name, panel, result.PID, result.Restarts))
+
+	case "signal":
+		signal := args[3]
+		var result *rpc.SignalResult
+		err := callPrism(ctx, panel,
+			func(c *rpc.ShinedClient) (err error) { result, err = c.PrismSignal(ctx, panel, name, signal); return },
+			func(c *rpc.PrismClient) (err error) { result, err = c.Signal(ctx, name, signal); return })
+		if err != nil {
+			return fmt.Errorf("failed to signal %s: %w", name, err)
+		}
+		Success(fmt.Sprintf("Sent %s to %s (PID %d)", result.Signal, name, result.PID))
+
+	case "list":
+		var result *rpc.ListResult
+		err := callPrism(ctx, panel,
+			func(c *rpc.ShinedClient) (err error) { result, err = c.PrismList(ctx, panel); return },
+			func(c *rpc.PrismClient) (err error) { result, err = c.List(ctx); return })
+		if err != nil {
+			return fmt.Errorf("failed to list prisms: %w", err)
+		}
+		if output.structured() {
+			return output.render(result)
+		}
+		displayPrisms(panel, "rpc", result.Prisms)
+	}
+
+	return nil
+}
+
+// callPrism routes a prism command through shined, falling back to the
+// panel's prismctl socket when shined is not running or does not know the
+// panel (e.g. a prismctl started by hand)
+func callPrism(ctx context.Context, panel string, viaShined func(*rpc.ShinedClient) error, direct func(*rpc.PrismClient) error) error {
+	if isShinedRunning() {
+		client, err := connectShined()
+		if err == nil {
+			err = viaShined(client)
+			client.Close()
+
+			switch rpc.ErrorCode(err) {
+			case rpc.CodePanelNotFound, rpc.CodeMethodNotFound:
+			default:
+				return err
+			}
+		}
+	}
+
+	client, err := rpc.NewPrismClient(paths.PrismSocket(panel))
+	if err != nil {
+		return withExitCode(exitNotRunning, fmt.Errorf("panel %s is not running", panel))
+	}
+	defer client.Close()
+
+	return direct(client)
+}
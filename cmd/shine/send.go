@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+const sendUsage = "usage: shine send [--literal|--paste] [--stdin] <panel> <prism> [keys...]"
+
+// cmdSend types into a prism without focusing its panel:
+//
+//	shine send bar chat "hello world" Enter   # key names, unknown words as text
+//	shine send --literal bar chat Enter       # the word "Enter", not the key
+//	shine send --paste bar chat "$(cat x)"    # bracketed paste
+//	shine send --stdin bar chat < input.bin   # raw bytes
+func cmdSend(args []string) error {
+	var literal, paste, stdin bool
+	var positional []string
+
+	for i, arg := range args {
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		switch arg {
+		case "-l", "--literal":
+			literal = true
+		case "-p", "--paste":
+			paste = true
+		case "--stdin":
+			stdin = true
+		default:
+			if strings.HasPrefix(arg, "-") && len(positional) < 2 {
+				return fmt.Errorf("unknown flag: %s\n%s", arg, sendUsage)
+			}
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) < 2 {
+		return fmt.Errorf(sendUsage)
+	}
+
+	panel, name, input := positional[0], positional[1], positional[2:]
+
+	req := &rpc.PanelSendRequest{Panel: panel, Name: name, Paste: paste}
+	if literal || paste {
+		req.Text = strings.Join(input, " ")
+	} else {
+		req.Keys = input
+	}
+
+	if stdin {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		req.Data = data
+	}
+
+	if req.Text == "" && len(req.Keys) == 0 && len(req.Data) == 0 {
+		return fmt.Errorf("nothing to send\n%s", sendUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), prismTimeout)
+	defer cancel()
+
+	var result *rpc.SendResult
+	err := callPrism(ctx, panel,
+		func(c *rpc.ShinedClient) (err error) { result, err = c.PrismSend(ctx, req); return },
+		func(c *rpc.PrismClient) (err error) {
+			result, err = c.Send(ctx, &rpc.SendRequest{
+				Name:  req.Name,
+				Text:  req.Text,
+				Keys:  req.Keys,
+				Data:  req.Data,
+				Paste: req.Paste,
+			})
+			return
+		})
+	if err != nil {
+		return fmt.Errorf("failed to send to %s: %w", name, err)
+	}
+
+	Success(fmt.Sprintf("Sent %d bytes to %s in %s", result.Bytes, name, panel))
+	return nil
+}
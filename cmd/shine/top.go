@@ -0,0 +1,607 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/starbased-co/shine/pkg/paths"
+	"github.com/starbased-co/shine/pkg/rpc"
+	"github.com/starbased-co/shine/pkg/state"
+)
+
+const (
+	topRefreshInterval = time.Second
+	maxTopEvents       = 100 // events kept for the log
+	topEventLines      = 8   // events shown below the table
+)
+
+var styleSelected = lipgloss.NewStyle().Reverse(true)
+
+// cmdTop runs a live dashboard of every panel and its prisms:
+//
+//	shine top
+func cmdTop(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: shine top")
+	}
+
+	_, err := tea.NewProgram(newTopModel(), tea.WithAltScreen()).Run()
+	return err
+}
+
+// topPanel is a panel as last read from the state files
+type topPanel struct {
+	instance string
+	pid      int
+	health   string // "healthy", "unhealthy", or "" when shined does not manage the panel
+	fg       string
+	prisms   []topPrism
+	err      error // the panel's state file could not be read
+}
+
+type topPrism struct {
+	name     string
+	pid      int
+	fg       bool
+	uptime   time.Duration
+	restarts int
+	usage    *rpc.PrismUsage
+}
+
+type topEvent struct {
+	at   time.Time
+	text string
+}
+
+// topRow is one selectable table line: a panel, or a prism within it
+type topRow struct {
+	panel *topPanel
+	prism *topPrism // nil for the panel's own row
+}
+
+func (r topRow) key() string {
+	if r.prism == nil {
+		return r.panel.instance
+	}
+	return r.panel.instance + "/" + r.prism.name
+}
+
+// topConfirm is a destructive action waiting for y/n
+type topConfirm struct {
+	prompt string
+	run    tea.Cmd
+}
+
+type topModel struct {
+	panels   []topPanel
+	loaded   bool
+	selected string // key of the selected row, kept across refreshes
+	events   []topEvent
+	status   string
+	confirm  *topConfirm
+	width    int
+	height   int
+}
+
+type topSnapshotMsg struct {
+	at      time.Time
+	panels  []topPanel
+	refresh bool // read after an action, outside the tick loop
+}
+
+type topTickMsg time.Time
+
+// topActionMsg reports the outcome of a key press
+type topActionMsg struct {
+	text string
+	err  error
+}
+
+func newTopModel() topModel {
+	return topModel{width: 80, height: 24}
+}
+
+func (m topModel) Init() tea.Cmd {
+	return readTopSnapshot
+}
+
+func topTick() tea.Cmd {
+	return tea.Tick(topRefreshInterval, func(t time.Time) tea.Msg {
+		return topTickMsg(t)
+	})
+}
+
+func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case topTickMsg:
+		return m, readTopSnapshot
+
+	case topSnapshotMsg:
+		if m.loaded {
+			for _, text := range diffTopSnapshots(m.panels, msg.panels) {
+				m.addEvent(msg.at, text)
+			}
+		} else {
+			m.addEvent(msg.at, fmt.Sprintf("watching %d panel(s)", len(msg.panels)))
+		}
+		m.panels = msg.panels
+		m.loaded = true
+		m.clampSelection()
+		if msg.refresh {
+			// The tick loop is already running
+			return m, nil
+		}
+		return m, topTick()
+
+	case topActionMsg:
+		if msg.err != nil {
+			m.status = styleError.Render(msg.err.Error())
+		} else {
+			m.status = styleSuccess.Render(msg.text)
+		}
+		return m, refreshTopSnapshot
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m topModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.confirm != nil {
+		confirm := m.confirm
+		m.confirm = nil
+		if msg.String() == "y" {
+			m.status = styleMuted.Render("working...")
+			return m, confirm.run
+		}
+		m.status = styleMuted.Render("cancelled")
+		return m, nil
+	}
+
+	rows := m.rows()
+	cursor := m.cursor(rows)
+
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+
+	case "up", "k":
+		if cursor > 0 {
+			m.selected = rows[cursor-1].key()
+		}
+		return m, nil
+
+	case "down", "j":
+		if cursor < len(rows)-1 {
+			m.selected = rows[cursor+1].key()
+		}
+		return m, nil
+	}
+
+	if cursor < 0 {
+		return m, nil
+	}
+	row := rows[cursor]
+	panel := row.panel.instance
+
+	switch msg.String() {
+	case "f":
+		if row.prism == nil {
+			m.status = styleWarning.Render("select a prism to bring to the foreground")
+			return m, nil
+		}
+		return m, topPrismAction(panel, row.prism.name, "fg")
+
+	case "r":
+		if row.prism == nil {
+			m.status = styleWarning.Render("select a prism to restart")
+			return m, nil
+		}
+		return m, topPrismAction(panel, row.prism.name, "restart")
+
+	case "x":
+		if row.prism != nil {
+			m.confirm = &topConfirm{
+				prompt: fmt.Sprintf("Kill %s in %s?", row.prism.name, panel),
+				run:    topPrismAction(panel, row.prism.name, "down"),
+			}
+		} else {
+			m.confirm = &topConfirm{
+				prompt: fmt.Sprintf("Kill panel %s and all its prisms?", panel),
+				run:    topPanelAction(panel, "kill"),
+			}
+		}
+		return m, nil
+
+	case "s":
+		return m, topPanelAction(panel, "show")
+
+	case "h":
+		return m, topPanelAction(panel, "hide")
+	}
+
+	return m, nil
+}
+
+func (m *topModel) addEvent(at time.Time, text string) {
+	m.events = append(m.events, topEvent{at: at, text: text})
+	if len(m.events) > maxTopEvents {
+		m.events = m.events[len(m.events)-maxTopEvents:]
+	}
+}
+
+// rows flattens the panels into table lines
+func (m topModel) rows() []topRow {
+	var rows []topRow
+	for i := range m.panels {
+		panel := &m.panels[i]
+		rows = append(rows, topRow{panel: panel})
+		for j := range panel.prisms {
+			rows = append(rows, topRow{panel: panel, prism: &panel.prisms[j]})
+		}
+	}
+	return rows
+}
+
+// cursor returns the index of the selected row, or -1 if there are none
+func (m topModel) cursor(rows []topRow) int {
+	for i, row := range rows {
+		if row.key() == m.selected {
+			return i
+		}
+	}
+	if len(rows) == 0 {
+		return -1
+	}
+	return 0
+}
+
+// clampSelection moves the selection to the panel's row when its prism
+// goes away, or to the first row when its panel does
+func (m *topModel) clampSelection() {
+	rows := m.rows()
+	if slices.ContainsFunc(rows, func(r topRow) bool { return r.key() == m.selected }) {
+		return
+	}
+	panel, _, _ := strings.Cut(m.selected, "/")
+	if slices.ContainsFunc(rows, func(r topRow) bool { return r.key() == panel }) {
+		m.selected = panel
+		return
+	}
+	if len(rows) > 0 {
+		m.selected = rows[0].key()
+	}
+}
+
+// readTopSnapshot reads every panel from shined's state file and each
+// panel's prisms from its prismctl state file. Panels running without
+// shined are found by their sockets.
+func readTopSnapshot() tea.Msg {
+	var panels []topPanel
+	seen := make(map[string]bool)
+
+	if reader, err := state.OpenShinedStateReader(paths.ShinedState()); err == nil {
+		s, err := reader.Read()
+		reader.Close()
+		if err == nil {
+			for _, entry := range s.ActivePanels() {
+				health := "unhealthy"
+				if entry.IsHealthy() {
+					health = "healthy"
+				}
+				panels = append(panels, topPanel{instance: entry.GetInstance(), pid: int(entry.PID), health: health})
+				seen[entry.GetInstance()] = true
+			}
+		}
+	}
+
+	if instances, err := discoverPrismInstances(); err == nil {
+		for _, instance := range instances {
+			if !seen[instance] {
+				panels = append(panels, topPanel{instance: instance})
+			}
+		}
+	}
+
+	for i := range panels {
+		readTopPrisms(&panels[i])
+	}
+	slices.SortFunc(panels, func(a, b topPanel) int {
+		return strings.Compare(a.instance, b.instance)
+	})
+
+	return topSnapshotMsg{at: time.Now(), panels: panels}
+}
+
+// refreshTopSnapshot reads a snapshot without scheduling another tick
+func refreshTopSnapshot() tea.Msg {
+	msg := readTopSnapshot().(topSnapshotMsg)
+	msg.refresh = true
+	return msg
+}
+
+func readTopPrisms(panel *topPanel) {
+	reader, err := state.OpenPrismStateReader(paths.PrismState(panel.instance))
+	if err != nil {
+		panel.err = err
+		return
+	}
+	s, err := reader.Read()
+	reader.Close()
+	if err != nil {
+		panel.err = err
+		return
+	}
+
+	panel.fg = s.GetFgPrism()
+	for _, entry := range s.ActivePrisms() {
+		panel.prisms = append(panel.prisms, topPrism{
+			name:     entry.GetName(),
+			pid:      int(entry.PID),
+			fg:       entry.GetState() == state.PrismStateFg,
+			uptime:   entry.Uptime(),
+			restarts: int(entry.Restarts),
+			usage:    mmapUsage(&entry),
+		})
+	}
+}
+
+// diffTopSnapshots describes what changed between two snapshots
+func diffTopSnapshots(prev, next []topPanel) []string {
+	var events []string
+
+	before := make(map[string]*topPanel, len(prev))
+	for i := range prev {
+		before[prev[i].instance] = &prev[i]
+	}
+	after := make(map[string]bool, len(next))
+
+	for i := range next {
+		panel := &next[i]
+		after[panel.instance] = true
+
+		old, ok := before[panel.instance]
+		if !ok {
+			events = append(events, fmt.Sprintf("panel %s started", panel.instance))
+			for _, prism := range panel.prisms {
+				events = append(events, fmt.Sprintf("%s/%s started (PID %d)", panel.instance, prism.name, prism.pid))
+			}
+			continue
+		}
+
+		if old.health != panel.health && panel.health != "" {
+			events = append(events, fmt.Sprintf("panel %s is %s", panel.instance, panel.health))
+		}
+
+		oldPrisms := make(map[string]topPrism, len(old.prisms))
+		for _, prism := range old.prisms {
+			oldPrisms[prism.name] = prism
+		}
+		for _, prism := range panel.prisms {
+			was, ok := oldPrisms[prism.name]
+			delete(oldPrisms, prism.name)
+			switch {
+			case !ok:
+				events = append(events, fmt.Sprintf("%s/%s started (PID %d)", panel.instance, prism.name, prism.pid))
+			case was.pid != prism.pid:
+				events = append(events, fmt.Sprintf("%s/%s restarted (PID %d, restarts: %d)", panel.instance, prism.name, prism.pid, prism.restarts))
+			}
+		}
+		for _, prism := range old.prisms {
+			if _, gone := oldPrisms[prism.name]; gone {
+				events = append(events, fmt.Sprintf("%s/%s stopped", panel.instance, prism.name))
+			}
+		}
+
+		if old.fg != panel.fg && panel.fg != "" {
+			events = append(events, fmt.Sprintf("%s/%s in the foreground", panel.instance, panel.fg))
+		}
+	}
+
+	for _, panel := range prev {
+		if !after[panel.instance] {
+			events = append(events, fmt.Sprintf("panel %s exited", panel.instance))
+		}
+	}
+
+	return events
+}
+
+// topPrismAction runs prism/<action> through shined or the panel's prismctl
+func topPrismAction(panel, name, action string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), prismTimeout)
+		defer cancel()
+
+		var err error
+		var text string
+		switch action {
+		case "fg":
+			err = callPrism(ctx, panel,
+				func(c *rpc.ShinedClient) error { _, err := c.PrismFg(ctx, panel, name); return err },
+				func(c *rpc.PrismClient) error { _, err := c.Fg(ctx, name); return err })
+			text = fmt.Sprintf("brought %s to the foreground", name)
+		case "restart":
+			err = callPrism(ctx, panel,
+				func(c *rpc.ShinedClient) error { _, err := c.PrismRestart(ctx, panel, name); return err },
+				func(c *rpc.PrismClient) error { _, err := c.Restart(ctx, name); return err })
+			text = fmt.Sprintf("restarted %s", name)
+		case "down":
+			err = callPrism(ctx, panel,
+				func(c *rpc.ShinedClient) error { _, err := c.PrismDown(ctx, panel, name); return err },
+				func(c *rpc.PrismClient) error { _, err := c.Down(ctx, name); return err })
+			text = fmt.Sprintf("killed %s", name)
+		}
+		if err != nil {
+			err = fmt.Errorf("%s %s: %w", action, name, err)
+		}
+		return topActionMsg{text: text, err: err}
+	}
+}
+
+// topPanelAction shows, hides or kills a panel through shined
+func topPanelAction(panel, action string) tea.Cmd {
+	return func() tea.Msg {
+		if !isShinedRunning() {
+			return topActionMsg{err: fmt.Errorf("%s %s: shined is not running", action, panel)}
+		}
+		client, err := connectShined()
+		if err != nil {
+			return topActionMsg{err: fmt.Errorf("failed to connect to shined: %w", err)}
+		}
+		defer client.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), prismTimeout)
+		defer cancel()
+
+		var text string
+		switch action {
+		case "show":
+			_, err = client.ShowPanel(ctx, panel)
+			text = fmt.Sprintf("showed %s", panel)
+		case "hide":
+			_, err = client.HidePanel(ctx, panel)
+			text = fmt.Sprintf("hid %s", panel)
+		case "kill":
+			_, err = client.KillPanel(ctx, panel)
+			text = fmt.Sprintf("killed panel %s", panel)
+		}
+		if err != nil {
+			err = fmt.Errorf("%s %s: %w", action, panel, err)
+		}
+		return topActionMsg{text: text, err: err}
+	}
+}
+
+var topColumns = []string{"Panel / Prism", "PID", "State", "Health", "Uptime", "Restarts", "CPU", "Memory", "Threads", "Children", "Process"}
+
+func (m topModel) View() string {
+	var sb strings.Builder
+
+	prismCount := 0
+	for _, panel := range m.panels {
+		prismCount += len(panel.prisms)
+	}
+	title := fmt.Sprintf("shine top — %d panel(s), %d prism(s)", len(m.panels), prismCount)
+	sb.WriteString(styleBold.Render(title) + "  " + styleMuted.Render(time.Now().Format("15:04:05")) + "\n\n")
+
+	rows := m.rows()
+	cursor := m.cursor(rows)
+
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = topCells(row)
+	}
+
+	// Rows that fit between the title, the event log and the footer; the
+	// window follows the cursor
+	events := min(len(m.events), topEventLines)
+	visible := max(m.height-2-2-(events+2)-3, 1)
+	start := 0
+	if cursor >= visible {
+		start = cursor - visible + 1
+	}
+	end := min(start+visible, len(rows))
+
+	widths := make([]int, len(topColumns))
+	for i, header := range topColumns {
+		widths[i] = lipgloss.Width(header)
+	}
+	for _, row := range cells {
+		for i, cell := range row {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+	}
+
+	sb.WriteString(topLine(topColumns, widths, styleBold) + "\n")
+	if !m.loaded {
+		sb.WriteString(styleMuted.Render("reading state...") + "\n")
+	} else if len(rows) == 0 {
+		sb.WriteString(styleMuted.Render("No panels running. Start panels with: shine start") + "\n")
+	}
+	for i := start; i < end; i++ {
+		line := topLine(cells[i], widths, lipgloss.NewStyle())
+		if i == cursor {
+			// Restyled as a whole, padded to highlight the full width
+			line = ansi.Strip(line)
+			line = styleSelected.Render(line + strings.Repeat(" ", max(m.width-lipgloss.Width(line), 0)))
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	sb.WriteString("\n" + styleBold.Render("Events") + "\n")
+	for _, event := range m.events[len(m.events)-events:] {
+		sb.WriteString(styleMuted.Render(event.at.Format("15:04:05")) + "  " + event.text + "\n")
+	}
+
+	sb.WriteString("\n")
+	switch {
+	case m.confirm != nil:
+		sb.WriteString(styleWarning.Render(m.confirm.prompt+" [y/N]") + "\n")
+	case m.status != "":
+		sb.WriteString(m.status + "\n")
+	default:
+		sb.WriteString("\n")
+	}
+	sb.WriteString(styleMuted.Render("↑/↓ select  f fg  r restart  x kill  s show  h hide  q quit"))
+
+	return sb.String()
+}
+
+// topCells renders a row's columns
+func topCells(row topRow) []string {
+	panel := row.panel
+
+	if row.prism == nil {
+		health := styleMuted.Render("unmanaged")
+		switch panel.health {
+		case "healthy":
+			health = styleSuccess.Render("healthy")
+		case "unhealthy":
+			health = styleError.Render("unhealthy")
+		}
+		pid := "-"
+		if panel.pid != 0 {
+			pid = fmt.Sprintf("%d", panel.pid)
+		}
+		stateStr := styleMuted.Render("panel")
+		if panel.err != nil {
+			stateStr = styleError.Render("no state")
+		}
+		return []string{styleBold.Render(panel.instance), pid, stateStr, health, "", "", "", "", "", "", ""}
+	}
+
+	prism := row.prism
+	stateStr := styleMuted.Render("background")
+	if prism.fg {
+		stateStr = styleSuccess.Render("foreground")
+	}
+	restarts := fmt.Sprintf("%d", prism.restarts)
+	if prism.restarts > 0 {
+		restarts = styleWarning.Render(restarts)
+	}
+	cells := []string{"  " + prism.name, fmt.Sprintf("%d", prism.pid), stateStr, "", prism.uptime.Truncate(time.Second).String(), restarts}
+	return append(cells, usageCells(prism.usage)...)
+}
+
+// topLine joins cells padded to their column widths
+func topLine(cells []string, widths []int, style lipgloss.Style) string {
+	parts := make([]string, len(cells))
+	for i, cell := range cells {
+		parts[i] = style.Render(cell) + strings.Repeat(" ", widths[i]-lipgloss.Width(cell))
+	}
+	return strings.Join(parts, "  ")
+}
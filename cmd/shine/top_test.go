@@ -0,0 +1,152 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDiffTopSnapshots(t *testing.T) {
+	bar := topPanel{
+		instance: "bar",
+		health:   "healthy",
+		fg:       "clock",
+		prisms:   []topPrism{{name: "clock", pid: 10, fg: true}, {name: "sysinfo", pid: 11}},
+	}
+
+	tests := []struct {
+		name string
+		prev []topPanel
+		next []topPanel
+		want []string
+	}{
+		{"unchanged", []topPanel{bar}, []topPanel{bar}, nil},
+		{
+			"panel started",
+			nil,
+			[]topPanel{bar},
+			[]string{"panel bar started", "bar/clock started (PID 10)", "bar/sysinfo started (PID 11)"},
+		},
+		{"panel exited", []topPanel{bar}, nil, []string{"panel bar exited"}},
+		{
+			"health changed",
+			[]topPanel{bar},
+			[]topPanel{{instance: "bar", health: "unhealthy", fg: "clock", prisms: bar.prisms}},
+			[]string{"panel bar is unhealthy"},
+		},
+		{
+			"prisms changed",
+			[]topPanel{bar},
+			[]topPanel{{
+				instance: "bar",
+				health:   "healthy",
+				fg:       "chat",
+				prisms:   []topPrism{{name: "chat", pid: 12, fg: true}, {name: "sysinfo", pid: 13, restarts: 1}},
+			}},
+			[]string{
+				"bar/chat started (PID 12)",
+				"bar/sysinfo restarted (PID 13, restarts: 1)",
+				"bar/clock stopped",
+				"bar/chat in the foreground",
+			},
+		},
+		{
+			"unmanaged panel",
+			[]topPanel{{instance: "tray"}},
+			[]topPanel{{instance: "tray"}},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffTopSnapshots(tt.prev, tt.next); !slices.Equal(got, tt.want) {
+				t.Errorf("diffTopSnapshots() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopModel_Selection(t *testing.T) {
+	panels := []topPanel{
+		{instance: "bar", prisms: []topPrism{{name: "clock"}, {name: "sysinfo"}}},
+		{instance: "tray", prisms: []topPrism{{name: "weather"}}},
+	}
+
+	tests := []struct {
+		name     string
+		selected string
+		panels   []topPanel
+		want     string
+		cursor   int
+	}{
+		{"kept", "bar/sysinfo", panels, "bar/sysinfo", 2},
+		{"nothing selected", "", panels, "bar", 0},
+		{"prism gone", "bar/chat", panels, "bar", 0},
+		{"panel gone", "dock/clock", panels, "bar", 0},
+		{"second panel", "tray/weather", panels, "tray/weather", 4},
+		{"no panels", "bar/clock", nil, "bar/clock", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := topModel{panels: tt.panels, selected: tt.selected}
+			m.clampSelection()
+			if m.selected != tt.want {
+				t.Errorf("selected = %q, want %q", m.selected, tt.want)
+			}
+			if cursor := m.cursor(m.rows()); cursor != tt.cursor {
+				t.Errorf("cursor = %d, want %d", cursor, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestTopModel_Keys(t *testing.T) {
+	m := topModel{panels: []topPanel{{instance: "bar", prisms: []topPrism{{name: "clock"}}}}}
+	m.clampSelection()
+
+	steps := []struct {
+		key  tea.KeyMsg
+		want string
+	}{
+		{tea.KeyMsg{Type: tea.KeyDown}, "bar/clock"},
+		{tea.KeyMsg{Type: tea.KeyDown}, "bar/clock"}, // stays on the last row
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")}, "bar"},
+		{tea.KeyMsg{Type: tea.KeyUp}, "bar"}, // stays on the first row
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}, "bar/clock"},
+	}
+	for i, step := range steps {
+		model, _ := m.Update(step.key)
+		m = model.(topModel)
+		if m.selected != step.want {
+			t.Errorf("step %d (%s): selected = %q, want %q", i, step.key, m.selected, step.want)
+		}
+	}
+}
+
+func TestTopModel_TickLoop(t *testing.T) {
+	m := newTopModel()
+
+	// The tick-driven snapshot schedules the next tick
+	model, cmd := m.Update(topSnapshotMsg{at: time.Now()})
+	if cmd == nil {
+		t.Fatal("snapshot did not schedule a tick")
+	}
+	m = model.(topModel)
+
+	// An action refreshes without starting another loop
+	_, cmd = m.Update(topActionMsg{text: "restarted clock"})
+	if cmd == nil {
+		t.Fatal("action did not refresh")
+	}
+	msg, ok := cmd().(topSnapshotMsg)
+	if !ok || !msg.refresh {
+		t.Fatalf("action refresh = %#v, want a refresh snapshot", msg)
+	}
+	if _, cmd := m.Update(msg); cmd != nil {
+		t.Error("refresh snapshot scheduled another tick")
+	}
+}
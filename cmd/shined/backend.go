@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/kitty"
+)
+
+// Panel backends
+const (
+	BackendKitty = "kitty" // kitty layer-shell panels (default)
+	BackendLocal = "local" // prismctl under a local pseudo-terminal, no Wayland needed
+)
+
+// PanelBackend hosts panels: it starts prismctl with a terminal to draw on
+// and manages the surface that terminal lives in. Window IDs are opaque
+// strings owned by the backend.
+type PanelBackend interface {
+	Name() string
+
+	// Spawn starts prismctl for instance and returns the new window's ID.
+	// prismctl announces itself separately via panel/register.
+	Spawn(cfg *PrismEntry, instance string) (string, error)
+
+	// Close closes a window, which ends its prismctl
+	Close(windowID string) error
+
+	// List returns every live window ID mapped to the PID it runs. An error
+	// means the backend itself is unreachable.
+	List() (map[string]int, error)
+
+	// Resize sets a window's size in cells
+	Resize(windowID string, cols, rows int) error
+
+	Show(windowID string) error
+	Hide(windowID string) error
+}
+
+// PanelBackendFromConfig resolves [core] backend
+func PanelBackendFromConfig(core *config.CoreConfig) (string, error) {
+	if core == nil || core.Backend == "" {
+		return BackendKitty, nil
+	}
+
+	switch core.Backend {
+	case BackendKitty, BackendLocal:
+		return core.Backend, nil
+	}
+	return "", fmt.Errorf("unknown backend %q (want %q or %q)", core.Backend, BackendKitty, BackendLocal)
+}
+
+func newPanelBackend(name, prismctlBin string) (PanelBackend, error) {
+	switch name {
+	case BackendKitty, "":
+		client, err := kitty.NewClientFromEnv()
+		if err != nil {
+			// Keep shined up for the IPC socket and local commands; each
+			// panel operation reports why kitty cannot be reached
+			log.Printf("Warning: kitty backend unavailable, panels will fail to spawn: %v", err)
+			return &kittyBackend{prismctlBin: prismctlBin, unavailable: fmt.Errorf("kitty backend unavailable: %w", err)}, nil
+		}
+		return newKittyBackend(prismctlBin, client), nil
+	case BackendLocal:
+		return newLocalBackend(prismctlBin), nil
+	}
+	return nil, fmt.Errorf("unknown backend %q", name)
+}
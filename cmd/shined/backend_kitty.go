@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/starbased-co/shine/pkg/kitty"
+)
+
+// kittyBackend runs each panel in a kitty layer-shell panel, driven over
+// kitty's remote-control socket
+type kittyBackend struct {
+	prismctlBin string
+	client      *kitty.Client
+	unavailable error // why there is no client; every operation fails with it
+}
+
+func newKittyBackend(prismctlBin string, client *kitty.Client) *kittyBackend {
+	return &kittyBackend{prismctlBin: prismctlBin, client: client}
+}
+
+func (kb *kittyBackend) Name() string {
+	return BackendKitty
+}
+
+func (kb *kittyBackend) Spawn(cfg *PrismEntry, instance string) (string, error) {
+	if kb.unavailable != nil {
+		return "", kb.unavailable
+	}
+	req := cfg.ToPanelConfig().ToLaunchRequest(kb.prismctlBin, instance)
+	id, err := kb.client.Launch(context.Background(), req)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(id), nil
+}
+
+func (kb *kittyBackend) Close(windowID string) error {
+	match, err := kb.match(windowID)
+	if err != nil {
+		return err
+	}
+	return kb.client.CloseWindow(context.Background(), &kitty.CloseWindowRequest{Match: match})
+}
+
+func (kb *kittyBackend) List() (map[string]int, error) {
+	if kb.unavailable != nil {
+		return nil, kb.unavailable
+	}
+	osWindows, err := kb.client.LS(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return windowPIDs(osWindows), nil
+}
+
+func (kb *kittyBackend) Resize(windowID string, cols, rows int) error {
+	return kb.resize(windowID, &kitty.ResizeOSWindowRequest{
+		Action: kitty.ActionResize,
+		Unit:   "cells",
+		Width:  cols,
+		Height: rows,
+	})
+}
+
+func (kb *kittyBackend) Show(windowID string) error {
+	return kb.resize(windowID, &kitty.ResizeOSWindowRequest{Action: kitty.ActionShow})
+}
+
+func (kb *kittyBackend) Hide(windowID string) error {
+	return kb.resize(windowID, &kitty.ResizeOSWindowRequest{Action: kitty.ActionHide})
+}
+
+func (kb *kittyBackend) resize(windowID string, req *kitty.ResizeOSWindowRequest) error {
+	match, err := kb.match(windowID)
+	if err != nil {
+		return err
+	}
+	req.Match = match
+	return kb.client.ResizeOSWindow(context.Background(), req)
+}
+
+// match turns a window ID into a kitty match expression, rejecting anything
+// that is not a kitty window ID
+func (kb *kittyBackend) match(windowID string) (string, error) {
+	if kb.unavailable != nil {
+		return "", kb.unavailable
+	}
+	id, err := strconv.Atoi(windowID)
+	if err != nil {
+		return "", fmt.Errorf("invalid kitty window ID %q", windowID)
+	}
+	return kitty.MatchID(id), nil
+}
+
+func windowPIDs(osWindows []kitty.OSWindow) map[string]int {
+	windows := make(map[string]int)
+	for _, win := range kitty.Windows(osWindows) {
+		windows[strconv.Itoa(win.ID)] = win.PID
+	}
+	return windows
+}
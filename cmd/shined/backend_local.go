@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/creack/pty"
+	"github.com/starbased-co/shine/pkg/panel"
+	"golang.org/x/sys/unix"
+)
+
+// Local window size when the panel config gives none in cells, and the cell
+// size used to convert pixel dimensions
+const (
+	localDefaultCols = 80
+	localDefaultRows = 24
+	localCellWidth   = 8
+	localCellHeight  = 16
+)
+
+// localBackend runs each panel's prismctl under a pseudo-terminal owned by
+// shined in place of a kitty window, so shined works without Wayland or
+// kitty. Nothing draws the terminal; see panels with shine attach or
+// shine capture.
+type localBackend struct {
+	prismctlBin string
+
+	mu      sync.Mutex
+	nextID  int
+	windows map[string]*localWindow
+}
+
+type localWindow struct {
+	cmd *exec.Cmd
+	pty *os.File
+}
+
+func newLocalBackend(prismctlBin string) *localBackend {
+	return &localBackend{
+		prismctlBin: prismctlBin,
+		windows:     make(map[string]*localWindow),
+	}
+}
+
+func (lb *localBackend) Name() string {
+	return BackendLocal
+}
+
+func (lb *localBackend) Spawn(cfg *PrismEntry, instance string) (string, error) {
+	cols, rows := localWindowSize(cfg.ToPanelConfig())
+
+	cmd := exec.Command(lb.prismctlBin, instance)
+	cmd.Env = withoutKittyEnv(os.Environ())
+
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+	if err != nil {
+		return "", fmt.Errorf("failed to start prismctl: %w", err)
+	}
+
+	lb.mu.Lock()
+	lb.nextID++
+	windowID := fmt.Sprintf("local-%d", lb.nextID)
+	lb.windows[windowID] = &localWindow{cmd: cmd, pty: ptmx}
+	lb.mu.Unlock()
+
+	// Nobody reads the screen; drain it so prismctl never blocks on output
+	go io.Copy(io.Discard, ptmx)
+
+	go func() {
+		cmd.Wait()
+		lb.mu.Lock()
+		delete(lb.windows, windowID)
+		lb.mu.Unlock()
+		ptmx.Close()
+		log.Printf("Local window %s (PID %d) exited", windowID, cmd.Process.Pid)
+	}()
+
+	log.Printf("Started prismctl for %s in local window %s (%dx%d)", instance, windowID, cols, rows)
+	return windowID, nil
+}
+
+// Close hangs up the window's terminal, as kitty does when a window closes
+func (lb *localBackend) Close(windowID string) error {
+	win, err := lb.window(windowID)
+	if err != nil {
+		return err
+	}
+	if err := win.cmd.Process.Signal(unix.SIGHUP); err != nil {
+		return fmt.Errorf("failed to hang up window %s: %w", windowID, err)
+	}
+	return nil
+}
+
+func (lb *localBackend) List() (map[string]int, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	windows := make(map[string]int, len(lb.windows))
+	for id, win := range lb.windows {
+		windows[id] = win.cmd.Process.Pid
+	}
+	return windows, nil
+}
+
+// Resize sets the terminal size; the kernel sends prismctl SIGWINCH
+func (lb *localBackend) Resize(windowID string, cols, rows int) error {
+	win, err := lb.window(windowID)
+	if err != nil {
+		return err
+	}
+	return pty.Setsize(win.pty, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// Show and Hide only check the window exists; a local window has no surface
+func (lb *localBackend) Show(windowID string) error {
+	_, err := lb.window(windowID)
+	return err
+}
+
+func (lb *localBackend) Hide(windowID string) error {
+	_, err := lb.window(windowID)
+	return err
+}
+
+func (lb *localBackend) window(windowID string) (*localWindow, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	win, ok := lb.windows[windowID]
+	if !ok {
+		return nil, fmt.Errorf("window %s not found", windowID)
+	}
+	return win, nil
+}
+
+// localWindowSize converts a panel's width and height to cells, assuming an
+// 8x16 cell for pixel sizes. Edge panels span the screen, so a width of one
+// column or less falls back to 80.
+func localWindowSize(cfg *panel.Config) (int, int) {
+	cells := func(d panel.Dimension, cellPixels int) int {
+		if d.IsPixels {
+			return d.Value / cellPixels
+		}
+		return d.Value
+	}
+
+	cols, rows := cells(cfg.Width, localCellWidth), cells(cfg.Height, localCellHeight)
+	if cols <= 1 {
+		cols = localDefaultCols
+	}
+	if rows < 1 {
+		rows = localDefaultRows
+	}
+	return cols, rows
+}
+
+// withoutKittyEnv drops kitty's variables so prismctl does not report a
+// kitty window it is not running in
+func withoutKittyEnv(env []string) []string {
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "KITTY_") {
+			continue
+		}
+		out = append(out, kv)
+	}
+	return out
+}
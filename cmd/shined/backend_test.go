@@ -0,0 +1,189 @@
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/kitty"
+	"github.com/starbased-co/shine/pkg/kitty/kittytest"
+	"github.com/starbased-co/shine/pkg/panel"
+)
+
+func TestPanelBackendFromConfig(t *testing.T) {
+	tests := []struct {
+		core    *config.CoreConfig
+		want    string
+		wantErr bool
+	}{
+		{core: nil, want: BackendKitty},
+		{core: &config.CoreConfig{}, want: BackendKitty},
+		{core: &config.CoreConfig{Backend: "kitty"}, want: BackendKitty},
+		{core: &config.CoreConfig{Backend: "local"}, want: BackendLocal},
+		{core: &config.CoreConfig{Backend: "x11"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := PanelBackendFromConfig(tt.core)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("PanelBackendFromConfig(%+v) error = %v, wantErr %v", tt.core, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("PanelBackendFromConfig(%+v) = %q, want %q", tt.core, got, tt.want)
+		}
+	}
+}
+
+func TestLocalWindowSize(t *testing.T) {
+	tests := []struct {
+		name               string
+		width, height      panel.Dimension
+		wantCols, wantRows int
+	}{
+		{"cells", panel.Dimension{Value: 100}, panel.Dimension{Value: 3}, 100, 3},
+		{"pixels", panel.Dimension{Value: 800, IsPixels: true}, panel.Dimension{Value: 48, IsPixels: true}, 100, 3},
+		{"edge panel", panel.Dimension{Value: 1}, panel.Dimension{Value: 1}, localDefaultCols, 1},
+		{"unset", panel.Dimension{}, panel.Dimension{}, localDefaultCols, localDefaultRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, rows := localWindowSize(&panel.Config{Width: tt.width, Height: tt.height})
+			if cols != tt.wantCols || rows != tt.wantRows {
+				t.Errorf("localWindowSize() = %dx%d, want %dx%d", cols, rows, tt.wantCols, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestWithoutKittyEnv(t *testing.T) {
+	env := withoutKittyEnv([]string{"HOME=/home/me", "KITTY_WINDOW_ID=3", "KITTY_PID=42", "TERM=xterm-kitty"})
+	if len(env) != 2 || env[0] != "HOME=/home/me" || env[1] != "TERM=xterm-kitty" {
+		t.Errorf("withoutKittyEnv() = %v", env)
+	}
+}
+
+func TestLocalBackend_Lifecycle(t *testing.T) {
+	// sleep stands in for prismctl; the instance name is its only argument
+	bin, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	lb := newLocalBackend(bin)
+
+	windowID, err := lb.Spawn(&PrismEntry{PrismConfig: &config.PrismConfig{Name: "bar"}}, "30")
+	if err != nil {
+		t.Fatalf("Spawn() error: %v", err)
+	}
+
+	windows, err := lb.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if windows[windowID] == 0 {
+		t.Fatalf("List() = %v, want %s", windows, windowID)
+	}
+
+	if err := lb.Resize(windowID, 120, 2); err != nil {
+		t.Errorf("Resize() error: %v", err)
+	}
+	if err := lb.Hide(windowID); err != nil {
+		t.Errorf("Hide() error: %v", err)
+	}
+
+	if err := lb.Close(windowID); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		windows, _ := lb.List()
+		if _, ok := windows[windowID]; !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("window still listed after Close()")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := lb.Show(windowID); err == nil {
+		t.Error("Show() on a closed window should fail")
+	}
+}
+
+func TestKittyBackend_Lifecycle(t *testing.T) {
+	srv := kittytest.NewServer(t)
+	client, err := kitty.NewClient(srv.Addr)
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	kb := newKittyBackend("/usr/bin/prismctl", client)
+
+	entry := &PrismEntry{PrismConfig: &config.PrismConfig{Name: "bar", Origin: "top-center", Height: 1}}
+	windowID, err := kb.Spawn(entry, "bar-0")
+	if err != nil {
+		t.Fatalf("Spawn() error: %v", err)
+	}
+
+	id, _ := strconv.Atoi(windowID)
+	win, ok := srv.Window(id)
+	if !ok {
+		t.Fatalf("Spawn() returned %s, not an open window", windowID)
+	}
+	if win.Type != "os-panel" || len(win.Args) != 2 || win.Args[0] != "/usr/bin/prismctl" || win.Args[1] != "bar-0" {
+		t.Errorf("launched window = %+v", win)
+	}
+
+	windows, err := kb.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if windows[windowID] != win.PID {
+		t.Errorf("List() = %v, want %s -> %d", windows, windowID, win.PID)
+	}
+
+	if err := kb.Resize(windowID, 100, 2); err != nil {
+		t.Errorf("Resize() error: %v", err)
+	}
+	if err := kb.Hide(windowID); err != nil {
+		t.Errorf("Hide() error: %v", err)
+	}
+	if win, _ := srv.Window(id); win.Cols != 100 || win.Rows != 2 || !win.Hidden {
+		t.Errorf("window after Resize() and Hide() = %+v", win)
+	}
+
+	if err := kb.Close(windowID); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if windows, _ := kb.List(); len(windows) != 0 {
+		t.Errorf("List() after Close() = %v", windows)
+	}
+	if err := kb.Close("not-a-window"); err == nil {
+		t.Error("Close() with a non-kitty window ID should fail")
+	}
+}
+
+func TestKittyBackend_Unavailable(t *testing.T) {
+	t.Setenv(kitty.EnvListenOn, "")
+
+	backend, err := newPanelBackend(BackendKitty, "/usr/bin/prismctl")
+	if err != nil {
+		t.Fatalf("newPanelBackend() without %s error: %v", kitty.EnvListenOn, err)
+	}
+
+	entry := &PrismEntry{PrismConfig: &config.PrismConfig{Name: "bar"}}
+	if _, err := backend.Spawn(entry, "bar-0"); err == nil || !strings.Contains(err.Error(), kitty.EnvListenOn) {
+		t.Errorf("Spawn() error = %v, want one naming %s", err, kitty.EnvListenOn)
+	}
+	if _, err := backend.List(); err == nil {
+		t.Error("List() should fail without kitty")
+	}
+	if err := backend.Close("1"); err == nil {
+		t.Error("Close() should fail without kitty")
+	}
+}
@@ -1,20 +1,121 @@
 package main
 
 import (
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/paths"
+	"github.com/starbased-co/shine/pkg/rpc"
 )
 
-// PrismEntry wraps config.PrismConfig with restart policies
+// PrismEntry wraps config.PrismConfig with restart policies. Each entry is
+// one panel; a multi-instance prism yields one entry per instance.
 type PrismEntry struct {
 	*config.PrismConfig
 
+	Instance string // panel instance name: the prism name, or prism:instance
+	Optional bool   // started only because another prism wants it
+
 	Restart      string `toml:"restart"`       // always | on-failure | unless-stopped | no
 	RestartDelay string `toml:"restart_delay"` // Duration string (e.g., "5s")
 	MaxRestarts  int    `toml:"max_restarts"`  // Max restarts per hour (0 = unlimited)
 }
 
+// prismEntries expands the prisms to start into one entry per panel, with
+// instance overrides applied. Enabled prisms start along with any prisms
+// they want or require. Entries come in dependency order, ties broken by
+// name, so each prism's panels follow those of the prisms it depends on.
+// Instances keep the order they are declared in. Prisms that are neither
+// enabled nor required by one that must start are marked optional.
+func prismEntries(cfg *config.Config) ([]*PrismEntry, error) {
+	byName := make(map[string]*config.PrismConfig, len(cfg.Prisms))
+	for _, pc := range cfg.Prisms {
+		byName[pc.Name] = pc
+	}
+
+	order, err := config.DependencyOrder(byName)
+	if err != nil {
+		return nil, err
+	}
+
+	start := make(map[string]bool)
+	var pull func(name string)
+	pull = func(name string) {
+		pc, ok := byName[name]
+		if !ok || start[name] {
+			return
+		}
+		start[name] = true
+		for _, dep := range pc.PulledIn() {
+			pull(dep)
+		}
+	}
+	required := make(map[string]bool)
+	var require func(name string)
+	require = func(name string) {
+		pc, ok := byName[name]
+		if !ok || required[name] {
+			return
+		}
+		required[name] = true
+		for _, dep := range pc.Requires {
+			require(dep)
+		}
+	}
+
+	for _, pc := range byName {
+		if pc.Enabled {
+			pull(pc.Name)
+			require(pc.Name)
+		}
+	}
+
+	entries := make([]*PrismEntry, 0)
+	for _, name := range order {
+		pc := byName[name]
+		if !start[name] || pc.ResolvedPath == "" {
+			log.Printf("Skipping prism %q: enabled=%v, resolved=%q", name, pc.Enabled, pc.ResolvedPath)
+			continue
+		}
+
+		instances, err := pc.GetInstances()
+		if err != nil {
+			return nil, fmt.Errorf("prism %q: %w", name, err)
+		}
+
+		if len(instances) == 0 {
+			entries = append(entries, newPrismEntry(pc, pc.Name))
+			continue
+		}
+
+		for _, inst := range instances {
+			entries = append(entries, newPrismEntry(pc.ForInstance(inst), config.InstanceName(pc.Name, inst.Name)))
+		}
+	}
+
+	for _, entry := range entries {
+		if err := entry.ValidateRestartPolicy(); err != nil {
+			return nil, fmt.Errorf("invalid restart policy for %q: %w", entry.Instance, err)
+		}
+		entry.Optional = !required[entry.Name]
+	}
+
+	return entries, nil
+}
+
+func newPrismEntry(pc *config.PrismConfig, instance string) *PrismEntry {
+	return &PrismEntry{
+		PrismConfig: pc,
+		Instance:    instance,
+		// Restart policies default to "no"
+		Restart:      "no",
+		RestartDelay: "1s",
+		MaxRestarts:  0,
+	}
+}
+
 type RestartPolicy int
 
 const (
@@ -60,3 +161,148 @@ func (pe *PrismEntry) ValidateRestartPolicy() error {
 func (pe *PrismEntry) GetApps() map[string]*config.AppConfig {
 	return pe.PrismConfig.GetApps()
 }
+
+// SandboxSpec resolves the prism's [sandbox] section into the form prismctl
+// applies. Returns nil if no sandbox is configured.
+func (pe *PrismEntry) SandboxSpec() (*rpc.SandboxSpec, error) {
+	sc := pe.Sandbox
+	if sc == nil {
+		return nil, nil
+	}
+
+	spec := &rpc.SandboxSpec{
+		NoNewPrivs:   sc.NoNewPrivs,
+		NoNetwork:    sc.NoNetwork,
+		ReadOnlyHome: sc.ReadOnlyHome,
+	}
+
+	if sc.Memory != "" {
+		size, err := config.ParseSize(sc.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory: %w", err)
+		}
+		spec.MemoryBytes = size
+	}
+
+	if sc.CPUTime != "" {
+		d, err := time.ParseDuration(sc.CPUTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu_time: %w", err)
+		}
+		spec.CPUSeconds = uint64(d / time.Second)
+	}
+
+	if sc.OpenFiles > 0 {
+		spec.OpenFiles = uint64(sc.OpenFiles)
+	}
+
+	if sc.MemoryMax != "" {
+		size, err := config.ParseSize(sc.MemoryMax)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory_max: %w", err)
+		}
+		spec.MemoryMaxBytes = size
+	}
+
+	if sc.CPUMax != "" {
+		percent, err := config.ParseCPUPercent(sc.CPUMax)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu_max: %w", err)
+		}
+		spec.CPUMaxPercent = percent
+	}
+
+	for _, p := range sc.WritablePaths {
+		spec.WritablePaths = append(spec.WritablePaths, paths.ExpandHome(p))
+	}
+
+	return spec, nil
+}
+
+// IdleSpec resolves the prism's [idle] section. Returns nil if no idle screen
+// is configured.
+func (pe *PrismEntry) IdleSpec() *rpc.IdleSpec {
+	if pe.Idle == nil {
+		return nil
+	}
+
+	spec := &rpc.IdleSpec{Text: pe.Idle.Text}
+	if pe.Idle.App != "" {
+		spec.Path = paths.ExpandHome(pe.Idle.App)
+	}
+	return spec
+}
+
+// HealthCheckSpec resolves an app's [health] section into the form prismctl
+// runs, filling in defaults. Returns nil if no health check is configured.
+// defaultReadyTimeout is how long an app with a [ready] section has to
+// signal readiness
+const defaultReadyTimeout = 30 * time.Second
+
+// ReadySpec resolves an app's [ready] section into the form prismctl
+// applies. Returns nil if readiness is not configured.
+func ReadySpec(rc *config.ReadyConfig) (*rpc.ReadySpec, error) {
+	if rc == nil {
+		return nil, nil
+	}
+
+	timeout := defaultReadyTimeout
+	if rc.Timeout != "" {
+		parsed, err := time.ParseDuration(rc.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		timeout = parsed
+	}
+
+	return &rpc.ReadySpec{Type: rc.Type, TimeoutMs: timeout.Milliseconds()}, nil
+}
+
+func HealthCheckSpec(hc *config.HealthCheckConfig) (*rpc.HealthCheckSpec, error) {
+	if hc == nil {
+		return nil, nil
+	}
+
+	spec := &rpc.HealthCheckSpec{
+		Command:    hc.Command,
+		IntervalMs: (10 * time.Second).Milliseconds(),
+		TimeoutMs:  (5 * time.Second).Milliseconds(),
+		Retries:    3,
+		Restart:    hc.Restart,
+	}
+
+	if hc.File != "" {
+		spec.File = paths.ExpandHome(hc.File)
+	}
+
+	if hc.Retries > 0 {
+		spec.Retries = hc.Retries
+	}
+
+	durations := []struct {
+		key   string
+		value string
+		dst   *int64
+	}{
+		{"interval", hc.Interval, &spec.IntervalMs},
+		{"timeout", hc.Timeout, &spec.TimeoutMs},
+		{"max_age", hc.MaxAge, &spec.MaxAgeMs},
+		{"output_within", hc.OutputWithin, &spec.OutputWithinMs},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", d.key, err)
+		}
+		*d.dst = parsed.Milliseconds()
+	}
+
+	if spec.File != "" && spec.MaxAgeMs == 0 {
+		spec.MaxAgeMs = 3 * spec.IntervalMs
+	}
+
+	return spec, nil
+}
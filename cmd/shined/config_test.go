@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/starbased-co/shine/pkg/config"
+)
+
+func TestPrismEntries_ExpandsInstances(t *testing.T) {
+	cfg := &config.Config{
+		Prisms: map[string]*config.PrismConfig{
+			"bar": {
+				Name:         "bar",
+				Enabled:      true,
+				ResolvedPath: "/usr/bin/shine-bar",
+				Origin:       "top-center",
+				Instances: []map[string]interface{}{
+					{"name": "right", "output_name": "DP-2", "args": []interface{}{"--full"}},
+					{"name": "left", "output_name": "DP-1"},
+				},
+			},
+			"clock": {
+				Name:         "clock",
+				Enabled:      true,
+				ResolvedPath: "/usr/bin/shine-clock",
+			},
+			"chat": {
+				Name:    "chat",
+				Enabled: false,
+			},
+		},
+	}
+
+	entries, err := prismEntries(cfg)
+	if err != nil {
+		t.Fatalf("prismEntries() error: %v", err)
+	}
+
+	want := []string{"bar:right", "bar:left", "clock"}
+	if len(entries) != len(want) {
+		t.Fatalf("prismEntries() = %d entries, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.Instance != want[i] {
+			t.Errorf("entries[%d].Instance = %q, want %q", i, entry.Instance, want[i])
+		}
+	}
+
+	right, left := entries[0], entries[1]
+	if left.Name != "bar" || left.OutputName != "DP-1" || left.Origin != "top-center" {
+		t.Errorf("bar:left = %+v", left.PrismConfig)
+	}
+	if right.OutputName != "DP-2" || len(right.Args) != 1 || right.Args[0] != "--full" {
+		t.Errorf("bar:right = %+v", right.PrismConfig)
+	}
+	if left.GetRestartPolicy() != RestartNo {
+		t.Errorf("bar:left restart policy = %v, want no", left.GetRestartPolicy())
+	}
+
+	// Instances share the prism's config but not each other's overrides
+	if cfg.Prisms["bar"].OutputName != "" {
+		t.Errorf("instance override leaked into the prism config: %q", cfg.Prisms["bar"].OutputName)
+	}
+}
+
+func TestPrismEntries_DependencyOrder(t *testing.T) {
+	cfg := &config.Config{
+		Prisms: map[string]*config.PrismConfig{
+			"bar": {
+				Name:         "bar",
+				Enabled:      true,
+				ResolvedPath: "/usr/bin/shine-bar",
+				Requires:     []string{"daemon"},
+				Wants:        []string{"weather"},
+			},
+			"alpha": {
+				Name:         "alpha",
+				Enabled:      true,
+				ResolvedPath: "/usr/bin/shine-alpha",
+				After:        []string{"bar"},
+			},
+			// Pulled in by bar although disabled
+			"daemon":  {Name: "daemon", ResolvedPath: "/usr/bin/shine-daemon"},
+			"weather": {Name: "weather", ResolvedPath: "/usr/bin/shine-weather"},
+			// after does not pull a prism in
+			"tray": {Name: "tray", ResolvedPath: "/usr/bin/shine-tray"},
+		},
+	}
+
+	entries, err := prismEntries(cfg)
+	if err != nil {
+		t.Fatalf("prismEntries() error: %v", err)
+	}
+
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Instance)
+	}
+	want := []string{"daemon", "weather", "bar", "alpha"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("prismEntries() = %v, want %v", got, want)
+	}
+
+	// Only a prism that is merely wanted may fail to start
+	for _, entry := range entries {
+		if wantOptional := entry.Instance == "weather"; entry.Optional != wantOptional {
+			t.Errorf("%s Optional = %v, want %v", entry.Instance, entry.Optional, wantOptional)
+		}
+	}
+}
+
+func TestReadySpec(t *testing.T) {
+	spec, err := ReadySpec(nil)
+	if err != nil || spec != nil {
+		t.Errorf("ReadySpec(nil) = %+v, %v, want nil", spec, err)
+	}
+
+	spec, err = ReadySpec(&config.ReadyConfig{Type: "notify"})
+	if err != nil {
+		t.Fatalf("ReadySpec() error: %v", err)
+	}
+	if spec.Type != "notify" || spec.TimeoutMs != defaultReadyTimeout.Milliseconds() {
+		t.Errorf("ReadySpec() = %+v, want notify with the default timeout", spec)
+	}
+
+	spec, _ = ReadySpec(&config.ReadyConfig{Type: "osc", Timeout: "2s"})
+	if spec.TimeoutMs != 2000 {
+		t.Errorf("ReadySpec() timeout = %d, want 2000", spec.TimeoutMs)
+	}
+}
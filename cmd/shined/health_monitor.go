@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+// HealthSettings controls the panel health monitor
+type HealthSettings struct {
+	Interval         time.Duration
+	Timeout          time.Duration
+	FailureThreshold int
+}
+
+func DefaultHealthSettings() HealthSettings {
+	return HealthSettings{
+		Interval:         30 * time.Second,
+		Timeout:          2 * time.Second,
+		FailureThreshold: 1,
+	}
+}
+
+// HealthSettingsFromConfig resolves [core.health], falling back to defaults
+// for unset fields
+func HealthSettingsFromConfig(core *config.CoreConfig) (HealthSettings, error) {
+	settings := DefaultHealthSettings()
+	if core == nil || core.Health == nil {
+		return settings, nil
+	}
+	hc := core.Health
+
+	if hc.Interval != "" {
+		d, err := time.ParseDuration(hc.Interval)
+		if err != nil {
+			return settings, fmt.Errorf("invalid interval: %w", err)
+		}
+		settings.Interval = d
+	}
+
+	if hc.Timeout != "" {
+		d, err := time.ParseDuration(hc.Timeout)
+		if err != nil {
+			return settings, fmt.Errorf("invalid timeout: %w", err)
+		}
+		settings.Timeout = d
+	}
+
+	if hc.FailureThreshold > 0 {
+		settings.FailureThreshold = hc.FailureThreshold
+	}
+
+	return settings, nil
+}
+
+// panelHealth is the cached result of a panel's health checks, read by the
+// status RPCs so they never block on a panel
+type panelHealth struct {
+	mu          sync.Mutex
+	checked     bool
+	healthy     bool
+	failures    int
+	lastCheck   time.Time
+	lastHealthy time.Time
+	lastError   string
+}
+
+// record folds a check result into the cache. Returns the number of
+// consecutive failures and whether the healthy flag changed.
+func (ph *panelHealth) record(err error, now time.Time) (failures int, changed bool) {
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+
+	prev := ph.healthy
+	first := !ph.checked
+
+	ph.checked = true
+	ph.lastCheck = now
+	if err == nil {
+		ph.healthy = true
+		ph.failures = 0
+		ph.lastHealthy = now
+		ph.lastError = ""
+	} else {
+		ph.healthy = false
+		ph.failures++
+		ph.lastError = err.Error()
+	}
+
+	return ph.failures, first || ph.healthy != prev
+}
+
+func (ph *panelHealth) info(info *rpc.PanelInfo) {
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+
+	info.Healthy = ph.healthy
+	info.HealthError = ph.lastError
+	if !ph.lastCheck.IsZero() {
+		info.LastCheckMs = ph.lastCheck.UnixMilli()
+	}
+	if !ph.lastHealthy.IsZero() {
+		info.LastHealthyMs = ph.lastHealthy.UnixMilli()
+	}
+}
+
+func (pm *PanelManager) SetHealthSettings(settings HealthSettings) {
+	pm.mu.Lock()
+	pm.health = settings
+	pm.mu.Unlock()
+
+	// Wake the monitor so a new interval takes effect immediately
+	select {
+	case pm.healthReset <- struct{}{}:
+	default:
+	}
+}
+
+func (pm *PanelManager) healthSettings() HealthSettings {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.health
+}
+
+// CheckHealth pings a panel's prismctl and records the result
+func (pm *PanelManager) CheckHealth(panel *Panel) bool {
+	healthy, _ := pm.checkPanel(panel, pm.healthSettings())
+	return healthy
+}
+
+// checkPanel pings a panel and reports whether it is healthy and whether it
+// has reached the failure threshold
+func (pm *PanelManager) checkPanel(panel *Panel, settings HealthSettings) (healthy, failed bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), settings.Timeout)
+	defer cancel()
+
+	_, err := panel.RPCClient.Health(ctx)
+	failures := pm.recordHealth(panel, err)
+
+	return err == nil, failures >= settings.FailureThreshold
+}
+
+// recordHealth caches a check result and reports health transitions.
+// Returns the number of consecutive failures.
+func (pm *PanelManager) recordHealth(panel *Panel, err error) int {
+	failures, changed := panel.health.record(err, time.Now())
+	if err != nil {
+		panelHealthFailures.Inc(panel.Instance)
+	}
+	if !changed {
+		return failures
+	}
+
+	if err != nil {
+		log.Printf("Panel %s is not responsive: %v", panel.Instance, err)
+	}
+
+	if pm.OnHealthChanged != nil {
+		pm.OnHealthChanged(panel.Instance, err == nil)
+	}
+
+	return failures
+}
+
+// RunHealthMonitor checks all panels in parallel every interval until ctx is
+// cancelled. Panels that fail FailureThreshold consecutive checks are handled
+// as crashed.
+func (pm *PanelManager) RunHealthMonitor(ctx context.Context) {
+	for {
+		settings := pm.healthSettings()
+		timer := time.NewTimer(settings.Interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-pm.healthReset:
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		pm.MonitorPanels()
+	}
+}
+
+// MonitorPanels runs one round of health checks across all panels
+func (pm *PanelManager) MonitorPanels() {
+	settings := pm.healthSettings()
+	panels := pm.ListPanels()
+
+	var wg sync.WaitGroup
+	for _, panel := range panels {
+		wg.Add(1)
+		go func(panel *Panel) {
+			defer wg.Done()
+			if _, failed := pm.checkPanel(panel, settings); failed {
+				pm.handlePanelExit(panel, rpc.PanelExitUnresponsive)
+			}
+		}(panel)
+	}
+	wg.Wait()
+
+	pm.checkWindows()
+}
+
+// panelDisconnected is called when a panel's prismctl socket closes without
+// shined closing it, meaning prismctl has exited
+func (pm *PanelManager) panelDisconnected(panel *Panel, err error) {
+	pm.mu.Lock()
+	current := pm.panels[panel.Instance] == panel
+	pm.mu.Unlock()
+
+	if !current {
+		return
+	}
+
+	log.Printf("Panel %s disconnected: %v", panel.Instance, err)
+
+	pm.panelGone(panel)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+func newTestPanelManager() *PanelManager {
+	return &PanelManager{
+		panels:       make(map[string]*Panel),
+		restartState: make(map[string]map[string]*PrismRestartState),
+		health:       DefaultHealthSettings(),
+		healthReset:  make(chan struct{}, 1),
+		listWindows: func() (map[string]int, error) {
+			return map[string]int{}, nil
+		},
+		registerTimeout: defaultRegisterTimeout,
+		pending:         make(map[string]chan *rpc.PanelRegisterRequest),
+	}
+}
+
+// startFakePrismctl serves service/health on a temp socket and registers a
+// panel connected to it
+func startFakePrismctl(t *testing.T, pm *PanelManager, instance string, healthy *atomic.Bool) (*Panel, *rpc.Server) {
+	t.Helper()
+
+	sockPath := filepath.Join(t.TempDir(), "prism.sock")
+	srv := rpc.NewServer(sockPath, handler.Map{
+		"service/health": rpc.HandlerFunc(func(ctx context.Context) (*rpc.HealthResult, error) {
+			if !healthy.Load() {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &rpc.HealthResult{Healthy: true}, nil
+		}),
+	}, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	panel := &Panel{
+		Name:       instance,
+		Instance:   instance,
+		SocketPath: sockPath,
+		Config:     &PrismEntry{PrismConfig: &config.PrismConfig{Name: instance}, Restart: "no"},
+	}
+	client, err := rpc.NewPrismClient(sockPath, rpc.WithOnDisconnect(func(err error) {
+		go pm.panelDisconnected(panel, err)
+	}))
+	if err != nil {
+		t.Fatalf("NewPrismClient() error: %v", err)
+	}
+	panel.RPCClient = client
+	pm.panels[instance] = panel
+
+	return panel, srv
+}
+
+func TestPanelHealth_Record(t *testing.T) {
+	var ph panelHealth
+	now := time.Now()
+
+	if failures, changed := ph.record(nil, now); failures != 0 || !changed {
+		t.Errorf("first record = %d, %v; want 0, true", failures, changed)
+	}
+	if _, changed := ph.record(nil, now); changed {
+		t.Error("repeated healthy record should not report a change")
+	}
+
+	failures, changed := ph.record(errors.New("timeout"), now.Add(time.Second))
+	if failures != 1 || !changed {
+		t.Errorf("failure record = %d, %v; want 1, true", failures, changed)
+	}
+	if failures, _ := ph.record(errors.New("timeout"), now.Add(2*time.Second)); failures != 2 {
+		t.Errorf("failures = %d, want 2", failures)
+	}
+
+	var info rpc.PanelInfo
+	ph.info(&info)
+	if info.Healthy || info.HealthError != "timeout" {
+		t.Errorf("info = %+v, want unhealthy with error", info)
+	}
+	if info.LastHealthyMs != now.UnixMilli() || info.LastCheckMs != now.Add(2*time.Second).UnixMilli() {
+		t.Errorf("timestamps = %d/%d, want %d/%d", info.LastHealthyMs, info.LastCheckMs,
+			now.UnixMilli(), now.Add(2*time.Second).UnixMilli())
+	}
+}
+
+func TestHealthSettingsFromConfig(t *testing.T) {
+	settings, err := HealthSettingsFromConfig(nil)
+	if err != nil || settings != DefaultHealthSettings() {
+		t.Errorf("nil core = %+v, %v; want defaults", settings, err)
+	}
+
+	settings, err = HealthSettingsFromConfig(&config.CoreConfig{
+		Health: &config.PanelHealthConfig{Interval: "5s", FailureThreshold: 3},
+	})
+	if err != nil {
+		t.Fatalf("HealthSettingsFromConfig() error: %v", err)
+	}
+	want := HealthSettings{Interval: 5 * time.Second, Timeout: 2 * time.Second, FailureThreshold: 3}
+	if settings != want {
+		t.Errorf("settings = %+v, want %+v", settings, want)
+	}
+}
+
+func TestMonitorPanels_FailureThreshold(t *testing.T) {
+	pm := newTestPanelManager()
+	pm.SetHealthSettings(HealthSettings{Interval: time.Hour, Timeout: 50 * time.Millisecond, FailureThreshold: 2})
+
+	var good, bad atomic.Bool
+	good.Store(true)
+	startFakePrismctl(t, pm, "good", &good)
+	stuck, _ := startFakePrismctl(t, pm, "stuck", &bad)
+
+	start := time.Now()
+	pm.MonitorPanels()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("MonitorPanels() took %v, checks should run in parallel with timeout", elapsed)
+	}
+
+	if _, ok := pm.GetPanel("stuck"); !ok {
+		t.Fatal("stuck panel removed after one failure, threshold is 2")
+	}
+
+	var info rpc.PanelInfo
+	stuck.health.info(&info)
+	if info.Healthy || info.LastCheckMs == 0 {
+		t.Errorf("stuck panel info = %+v, want unhealthy with last check", info)
+	}
+
+	pm.MonitorPanels()
+
+	if _, ok := pm.GetPanel("stuck"); ok {
+		t.Error("stuck panel still registered after reaching failure threshold")
+	}
+	if _, ok := pm.GetPanel("good"); !ok {
+		t.Error("healthy panel was removed")
+	}
+}
+
+func TestPanelDisconnect(t *testing.T) {
+	pm := newTestPanelManager()
+
+	changed := make(chan bool, 4)
+	pm.OnHealthChanged = func(instance string, healthy bool) {
+		changed <- healthy
+	}
+
+	var healthy atomic.Bool
+	healthy.Store(true)
+	_, srv := startFakePrismctl(t, pm, "clock", &healthy)
+
+	// Let the server register the connection before stopping it
+	time.Sleep(10 * time.Millisecond)
+	srv.Stop(context.Background())
+
+	deadline := time.After(2 * time.Second)
+	for {
+		if _, ok := pm.GetPanel("clock"); !ok {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("panel not removed after socket disconnect")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	select {
+	case h := <-changed:
+		if h {
+			t.Error("OnHealthChanged(healthy=true), want false")
+		}
+	case <-time.After(time.Second):
+		t.Error("OnHealthChanged not called on disconnect")
+	}
+}
@@ -24,17 +24,33 @@ func startRPCServer(pm *PanelManager, stateMgr *StateManager, cfgPath string) er
 		cfgPath: cfgPath,
 	}
 
-	mux := handler.Map{
+	mux := rpc.Instrument(handler.Map{
 		"panel/list":      rpc.HandlerFunc(h.handlePanelList),
 		"panel/spawn":     rpc.Handler(h.handlePanelSpawn),
 		"panel/kill":      rpc.Handler(h.handlePanelKill),
+		"panel/register":  rpc.Handler(h.handlePanelRegister),
+		"panel/show":      rpc.Handler(h.handlePanelShow),
+		"panel/hide":      rpc.Handler(h.handlePanelHide),
+		"panel/resize":    rpc.Handler(h.handlePanelResize),
 		"service/status":  rpc.HandlerFunc(h.handleServiceStatus),
+		"service/metrics": rpc.HandlerFunc(h.handleServiceMetrics),
 		"config/reload":   rpc.HandlerFunc(h.handleConfigReload),
+		"prism/up":        rpc.Handler(h.handlePrismUp),
+		"prism/down":      rpc.Handler(h.handlePrismDown),
+		"prism/fg":        rpc.Handler(h.handlePrismFg),
+		"prism/bg":        rpc.Handler(h.handlePrismBg),
+		"prism/restart":   rpc.Handler(h.handlePrismRestart),
+		"prism/signal":    rpc.Handler(h.handlePrismSignal),
+		"prism/send":      rpc.Handler(h.handlePrismSend),
+		"prism/capture":   rpc.Handler(h.handlePrismCapture),
+		"prism/list":      rpc.Handler(h.handlePrismList),
 		"prism/started":   rpc.Handler(h.handlePrismStarted),
 		"prism/stopped":   rpc.Handler(h.handlePrismStopped),
 		"prism/crashed":   rpc.Handler(h.handlePrismCrashed),
+		"prism/health":    rpc.Handler(h.handlePrismHealth),
+		"panel/closing":   rpc.Handler(h.handlePanelClosing),
 		"foreground/changed": rpc.Handler(h.handleForegroundChanged),
-	}
+	}, observeRPC)
 
 	rpcServer = rpc.NewServer(paths.ShinedSocket(), mux, nil)
 	if err := rpcServer.Start(); err != nil {
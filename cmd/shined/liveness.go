@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+	"golang.org/x/sys/unix"
+)
+
+// panelLiveness tracks how a panel's prismctl is going away
+type panelLiveness struct {
+	mu      sync.Mutex
+	closing string // reason from panel/closing, empty until prismctl reports
+	stopFd  int    // eventfd that wakes the pidfd watcher, 0 if not watching
+}
+
+func (pl *panelLiveness) setClosing(reason string) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.closing = reason
+}
+
+func (pl *panelLiveness) closingReason() string {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	return pl.closing
+}
+
+// stop wakes and ends the pidfd watcher, if any
+func (pl *panelLiveness) stop() {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	if pl.stopFd > 0 {
+		unix.Write(pl.stopFd, []byte{1, 0, 0, 0, 0, 0, 0, 0})
+	}
+}
+
+// release closes the watcher's eventfd once the watcher is done with it
+func (pl *panelLiveness) release() {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	if pl.stopFd > 0 {
+		unix.Close(pl.stopFd)
+		pl.stopFd = 0
+	}
+}
+
+// watchPanel waits on a pidfd for the panel's prismctl to exit, so shined
+// notices immediately instead of on the next health check. Panels whose PID
+// is unknown are covered by socket disconnect detection instead.
+// Assumes caller holds pm.mu lock
+func (pm *PanelManager) watchPanel(panel *Panel) {
+	if panel.PID <= 0 {
+		return
+	}
+
+	pidfd, err := unix.PidfdOpen(panel.PID, 0)
+	if err != nil {
+		log.Printf("Warning: cannot watch panel %s (PID %d): %v", panel.Instance, panel.PID, err)
+		return
+	}
+
+	stopFd, err := unix.Eventfd(0, unix.EFD_CLOEXEC)
+	if err != nil {
+		unix.Close(pidfd)
+		log.Printf("Warning: cannot watch panel %s: %v", panel.Instance, err)
+		return
+	}
+
+	panel.liveness.mu.Lock()
+	panel.liveness.stopFd = stopFd
+	panel.liveness.mu.Unlock()
+
+	go func() {
+		defer unix.Close(pidfd)
+		defer panel.liveness.release()
+
+		exited, err := waitPidfd(pidfd, stopFd)
+		if err != nil {
+			log.Printf("Warning: pidfd watch for panel %s failed: %v", panel.Instance, err)
+			return
+		}
+		if !exited {
+			return
+		}
+
+		log.Printf("Panel %s prismctl (PID %d) exited", panel.Instance, panel.PID)
+		pm.panelGone(panel)
+	}()
+}
+
+// waitPidfd blocks until the process behind pidfd exits (true) or stopFd is
+// signalled (false)
+func waitPidfd(pidfd, stopFd int) (bool, error) {
+	fds := []unix.PollFd{
+		{Fd: int32(pidfd), Events: unix.POLLIN},
+		{Fd: int32(stopFd), Events: unix.POLLIN},
+	}
+
+	for {
+		_, err := unix.Poll(fds, -1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		if fds[1].Revents != 0 {
+			return false, nil
+		}
+		if fds[0].Revents != 0 {
+			return true, nil
+		}
+	}
+}
+
+// panelGone handles a panel whose prismctl has exited, detected via pidfd or
+// socket disconnect
+func (pm *PanelManager) panelGone(panel *Panel) {
+	pm.mu.Lock()
+	current := pm.panels[panel.Instance] == panel
+	stopping := pm.stopping
+	pm.mu.Unlock()
+
+	if !current || stopping {
+		return
+	}
+
+	reason := pm.classifyPanelExit(panel)
+	if panelExitIsFailure(reason) {
+		pm.recordHealth(panel, errors.New("prismctl exited"))
+	}
+
+	pm.handlePanelExit(panel, reason)
+}
+
+// classifyPanelExit decides why a panel went away. prismctl's own report
+// wins; otherwise an unreachable kitty means kitty itself exited, and
+// anything else is a crash.
+func (pm *PanelManager) classifyPanelExit(panel *Panel) string {
+	if reason := panel.liveness.closingReason(); reason != "" {
+		return reason
+	}
+
+	if _, err := pm.listWindows(); err != nil {
+		return rpc.PanelExitKittyExited
+	}
+
+	return rpc.PanelExitCrashed
+}
+
+// checkWindows closes out panels whose kitty window is gone while prismctl is
+// still running, e.g. a prismctl that ignored the SIGHUP from the close
+func (pm *PanelManager) checkWindows() {
+	windows, err := pm.listWindows()
+	if err != nil {
+		return
+	}
+
+	for _, panel := range pm.ListPanels() {
+		if panel.WindowID == "" {
+			continue
+		}
+		if _, ok := windows[panel.WindowID]; ok {
+			continue
+		}
+
+		log.Printf("Panel %s window %s is gone", panel.Instance, panel.WindowID)
+		if panel.PID > 0 {
+			unix.Kill(panel.PID, unix.SIGKILL)
+		}
+		pm.handlePanelExit(panel, rpc.PanelExitWindowClosed)
+	}
+}
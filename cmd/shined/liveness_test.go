@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/kitty"
+	"github.com/starbased-co/shine/pkg/kitty/kittytest"
+	"github.com/starbased-co/shine/pkg/rpc"
+	"golang.org/x/sys/unix"
+)
+
+func TestKittyBackend_ListWindows(t *testing.T) {
+	srv := kittytest.NewServer(t)
+	srv.AddWindow(kittytest.Window{PID: 1001})
+	srv.AddWindow(kittytest.Window{PID: 1002})
+	srv.AddWindow(kittytest.Window{PID: 2001})
+
+	client, err := kitty.NewClient(srv.Addr)
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	windows, err := newKittyBackend("prismctl", client).List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+
+	want := map[string]int{"1": 1001, "2": 1002, "3": 2001}
+	if len(windows) != len(want) {
+		t.Fatalf("windows = %v, want %v", windows, want)
+	}
+	for id, pid := range want {
+		if windows[id] != pid {
+			t.Errorf("windows[%s] = %d, want %d", id, windows[id], pid)
+		}
+	}
+
+	// An unreachable kitty is an error, not an empty listing
+	srv.Close()
+	if _, err := newKittyBackend("prismctl", client).List(); err == nil {
+		t.Error("List() with kitty gone should fail")
+	}
+}
+
+func TestShouldRestartPanel(t *testing.T) {
+	tests := []struct {
+		policy RestartPolicy
+		reason string
+		want   bool
+	}{
+		{RestartAlways, rpc.PanelExitCrashed, true},
+		{RestartAlways, rpc.PanelExitEmpty, true},
+		{RestartAlways, rpc.PanelExitWindowClosed, false},
+		{RestartAlways, rpc.PanelExitKittyExited, false},
+		{RestartUnlessStopped, rpc.PanelExitTerminated, true},
+		{RestartUnlessStopped, rpc.PanelExitRequested, false},
+		{RestartOnFailure, rpc.PanelExitUnresponsive, true},
+		{RestartOnFailure, rpc.PanelExitEmpty, false},
+		{RestartOnFailure, rpc.PanelExitWindowClosed, false},
+		{RestartNo, rpc.PanelExitCrashed, false},
+	}
+
+	for _, tt := range tests {
+		if got := shouldRestartPanel(tt.policy, tt.reason); got != tt.want {
+			t.Errorf("shouldRestartPanel(%d, %q) = %v, want %v", tt.policy, tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestWaitPidfd(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+	defer cmd.Wait()
+
+	pidfd, err := unix.PidfdOpen(cmd.Process.Pid, 0)
+	if err != nil {
+		cmd.Process.Kill()
+		t.Skipf("pidfd_open unsupported: %v", err)
+	}
+	defer unix.Close(pidfd)
+
+	stopFd, err := unix.Eventfd(0, unix.EFD_CLOEXEC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(stopFd)
+
+	result := make(chan bool, 1)
+	go func() {
+		exited, _ := waitPidfd(pidfd, stopFd)
+		result <- exited
+	}()
+
+	cmd.Process.Kill()
+
+	select {
+	case exited := <-result:
+		if !exited {
+			t.Error("waitPidfd() = false, want true after process exit")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("waitPidfd() did not return after process exit")
+	}
+}
+
+func TestWaitPidfd_Stop(t *testing.T) {
+	pidfd, err := unix.PidfdOpen(unix.Getpid(), 0)
+	if err != nil {
+		t.Skipf("pidfd_open unsupported: %v", err)
+	}
+	defer unix.Close(pidfd)
+
+	pl := &panelLiveness{}
+	pl.stopFd, err = unix.Eventfd(0, unix.EFD_CLOEXEC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pl.release()
+
+	result := make(chan bool, 1)
+	go func() {
+		exited, _ := waitPidfd(pidfd, pl.stopFd)
+		result <- exited
+	}()
+
+	pl.stop()
+
+	select {
+	case exited := <-result:
+		if exited {
+			t.Error("waitPidfd() = true, want false when stopped")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("waitPidfd() did not return after stop")
+	}
+}
+
+func TestPanelGone_ClosedByUser(t *testing.T) {
+	pm := newTestPanelManager()
+
+	exits := make(chan string, 1)
+	pm.OnPanelExited = func(instance, reason string) {
+		exits <- reason
+	}
+
+	var healthy atomic.Bool
+	healthy.Store(true)
+	panel, srv := startFakePrismctl(t, pm, "clock", &healthy)
+	panel.Config.Restart = "always"
+
+	panel.liveness.setClosing(rpc.PanelExitWindowClosed)
+
+	time.Sleep(10 * time.Millisecond)
+	srv.Stop(t.Context())
+
+	select {
+	case reason := <-exits:
+		if reason != rpc.PanelExitWindowClosed {
+			t.Errorf("exit reason = %q, want %q", reason, rpc.PanelExitWindowClosed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("panel exit not handled after disconnect")
+	}
+
+	if panel.CrashCount != 0 {
+		t.Errorf("CrashCount = %d, want 0 for a user close", panel.CrashCount)
+	}
+}
+
+func TestClassifyPanelExit(t *testing.T) {
+	pm := newTestPanelManager()
+	panel := &Panel{Instance: "clock"}
+
+	if got := pm.classifyPanelExit(panel); got != rpc.PanelExitCrashed {
+		t.Errorf("unreported exit = %q, want %q", got, rpc.PanelExitCrashed)
+	}
+
+	pm.listWindows = func() (map[string]int, error) {
+		return nil, errors.New("kitty not running")
+	}
+	if got := pm.classifyPanelExit(panel); got != rpc.PanelExitKittyExited {
+		t.Errorf("exit with kitty gone = %q, want %q", got, rpc.PanelExitKittyExited)
+	}
+
+	panel.liveness.setClosing(rpc.PanelExitEmpty)
+	if got := pm.classifyPanelExit(panel); got != rpc.PanelExitEmpty {
+		t.Errorf("reported exit = %q, want %q", got, rpc.PanelExitEmpty)
+	}
+}
+
+func TestCheckWindows(t *testing.T) {
+	pm := newTestPanelManager()
+	pm.listWindows = func() (map[string]int, error) {
+		return map[string]int{"7": 0}, nil
+	}
+
+	var healthy atomic.Bool
+	healthy.Store(true)
+	kept, _ := startFakePrismctl(t, pm, "kept", &healthy)
+	kept.WindowID = "7"
+	closed, _ := startFakePrismctl(t, pm, "closed", &healthy)
+	closed.WindowID = "8"
+
+	pm.checkWindows()
+
+	if _, ok := pm.GetPanel("kept"); !ok {
+		t.Error("panel with a live window was removed")
+	}
+	if _, ok := pm.GetPanel("closed"); ok {
+		t.Error("panel whose window is gone is still registered")
+	}
+}
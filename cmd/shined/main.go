@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -8,7 +10,6 @@ import (
 	"os/signal"
 	"path/filepath"
 	"syscall"
-	"time"
 
 	"github.com/starbased-co/shine/pkg/config"
 	"github.com/starbased-co/shine/pkg/paths"
@@ -64,30 +65,12 @@ func main() {
 		log.Fatalf("Invalid configuration: %v", err)
 	}
 
-	// Convert to PrismEntry slice for shined
-	prismEntries := make([]*PrismEntry, 0)
-	for name, pc := range pkgCfg.Prisms {
-		if !pc.Enabled || pc.ResolvedPath == "" {
-			log.Printf("Skipping prism %q: enabled=%v, resolved=%q", name, pc.Enabled, pc.ResolvedPath)
-			continue
-		}
-
-		entry := &PrismEntry{
-			PrismConfig: pc,
-			// Restart policies default to "no"
-			Restart:      "no",
-			RestartDelay: "1s",
-			MaxRestarts:  0,
-		}
-
-		if err := entry.ValidateRestartPolicy(); err != nil {
-			log.Fatalf("Invalid restart policy for prism %q: %v", name, err)
-		}
-
-		prismEntries = append(prismEntries, entry)
+	entries, err := prismEntries(pkgCfg)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
 
-	log.Printf("Loaded configuration with %d prism(s)", len(prismEntries))
+	log.Printf("Loaded configuration with %d panel(s)", len(entries))
 
 	stateMgr, err := newStateManager()
 	if err != nil {
@@ -95,25 +78,59 @@ func main() {
 	}
 	defer stateMgr.Close()
 
-	pm, err := NewPanelManager()
+	backend, err := PanelBackendFromConfig(pkgCfg.Core)
+	if err != nil {
+		log.Fatalf("Invalid core.backend: %v", err)
+	}
+
+	pm, err := NewPanelManager(backend)
 	if err != nil {
 		log.Fatalf("Failed to create panel manager: %v", err)
 	}
 
+	healthSettings, err := HealthSettingsFromConfig(pkgCfg.Core)
+	if err != nil {
+		log.Fatalf("Invalid core.health: %v", err)
+	}
+	pm.SetHealthSettings(healthSettings)
+
+	registerTimeout, err := RegisterTimeoutFromConfig(pkgCfg.Core)
+	if err != nil {
+		log.Fatalf("Invalid core.register_timeout: %v", err)
+	}
+	pm.SetRegisterTimeout(registerTimeout)
+
+	pm.OnHealthChanged = stateMgr.OnPanelHealthChanged
+	pm.OnPanelExited = stateMgr.OnPanelExited
+	pm.OnPanelSpawned = stateMgr.OnPanelRespawned
+	metricsRegistry.OnGather(pm.sampleUsage)
+
 	if err := startRPCServer(pm, stateMgr, cfgPath); err != nil {
 		log.Fatalf("Failed to start RPC server: %v", err)
 	}
 	defer stopRPCServer()
 
-	if err := spawnConfiguredPanels(pm, prismEntries, stateMgr); err != nil {
+	if err := startMetricsServer(pm, MetricsSocketFromConfig(pkgCfg.Core)); err != nil {
+		log.Printf("Warning: metrics endpoint disabled: %v", err)
+	}
+	defer stopMetricsServer()
+
+	failed, err := spawnConfiguredPanels(pm, entries, stateMgr)
+	if err != nil {
+		pm.Shutdown()
 		log.Fatalf("Failed to spawn panels: %v", err)
 	}
+	if failed != nil {
+		log.Printf("Warning: some optional panels failed to start: %v", failed)
+	}
+	stateMgr.OnStartupComplete(failed)
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGINT)
 
-	healthTicker := time.NewTicker(30 * time.Second)
-	defer healthTicker.Stop()
+	monitorCtx, stopMonitor := context.WithCancel(context.Background())
+	defer stopMonitor()
+	go pm.RunHealthMonitor(monitorCtx)
 
 	log.Println("shined is running (Ctrl+C to stop)")
 
@@ -129,15 +146,14 @@ func main() {
 
 			case syscall.SIGTERM, syscall.SIGINT:
 				log.Println("Received shutdown signal - stopping all panels")
+				stopMonitor()
 				stopRPCServer()
+				stopMetricsServer()
 				pm.Shutdown()
 				stateMgr.Remove() // Clean up state file on shutdown
 				log.Println("shined stopped")
 				return
 			}
-
-		case <-healthTicker.C:
-			pm.MonitorPanels()
 		}
 	}
 }
@@ -160,16 +176,25 @@ func setupLogging() *os.File {
 	return logFile
 }
 
-func spawnConfiguredPanels(pm *PanelManager, entries []*PrismEntry, stateMgr *StateManager) error {
+// spawnConfiguredPanels spawns entries in dependency order. An optional
+// panel, one only wanted by another prism, may fail without stopping the
+// rest; those failures are returned as failed. Any other failure stops
+// startup and is returned as err.
+func spawnConfiguredPanels(pm *PanelManager, entries []*PrismEntry, stateMgr *StateManager) (failed error, err error) {
+	var errs []error
 	for _, entry := range entries {
-		instanceName := entry.Name
-
 		log.Printf("Spawning panel for prism: %s (instance: %s, binary: %s)",
-			entry.Name, instanceName, entry.ResolvedPath)
+			entry.Name, entry.Instance, entry.ResolvedPath)
 
-		panel, err := pm.SpawnPanel(entry, instanceName)
+		panel, err := spawnEntry(pm, entry)
 		if err != nil {
-			return fmt.Errorf("failed to spawn panel for %s: %w", entry.Name, err)
+			err = fmt.Errorf("failed to spawn panel %s: %w", entry.Instance, err)
+			if !entry.Optional {
+				return errors.Join(errs...), err
+			}
+			log.Printf("Warning: %v (optional, continuing)", err)
+			errs = append(errs, err)
+			continue
 		}
 
 		healthy := pm.CheckHealth(panel)
@@ -179,7 +204,17 @@ func spawnConfiguredPanels(pm *PanelManager, entries []*PrismEntry, stateMgr *St
 			panel.Instance, panel.SocketPath)
 	}
 
-	return nil
+	return errors.Join(errs...), nil
+}
+
+// spawnEntry spawns entry once every prism it requires has a running panel
+func spawnEntry(pm *PanelManager, entry *PrismEntry) (*Panel, error) {
+	for _, dep := range entry.Requires {
+		if !pm.PrismRunning(dep) {
+			return nil, fmt.Errorf("required prism %s is not running", dep)
+		}
+	}
+	return pm.SpawnPanel(entry, entry.Instance)
 }
 
 func reloadConfig(pm *PanelManager, configPath string) error {
@@ -194,60 +229,61 @@ func reloadConfig(pm *PanelManager, configPath string) error {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	newEntries := make([]*PrismEntry, 0)
-	for name, pc := range pkgCfg.Prisms {
-		if !pc.Enabled || pc.ResolvedPath == "" {
-			continue
-		}
-
-		entry := &PrismEntry{
-			PrismConfig:  pc,
-			Restart:      "no",
-			RestartDelay: "1s",
-			MaxRestarts:  0,
-		}
+	healthSettings, err := HealthSettingsFromConfig(pkgCfg.Core)
+	if err != nil {
+		return fmt.Errorf("invalid core.health: %w", err)
+	}
+	pm.SetHealthSettings(healthSettings)
 
-		if err := entry.ValidateRestartPolicy(); err != nil {
-			log.Printf("Invalid restart policy for prism %q: %v", name, err)
-			continue
-		}
+	registerTimeout, err := RegisterTimeoutFromConfig(pkgCfg.Core)
+	if err != nil {
+		return fmt.Errorf("invalid core.register_timeout: %w", err)
+	}
+	pm.SetRegisterTimeout(registerTimeout)
 
-		newEntries = append(newEntries, entry)
+	// Panels cannot move between backends; a new backend needs a restart
+	if backend, err := PanelBackendFromConfig(pkgCfg.Core); err == nil && backend != pm.BackendName() {
+		log.Printf("Warning: core.backend changed to %q; restart shined to apply", backend)
+	}
+	if sockPath := MetricsSocketFromConfig(pkgCfg.Core); sockPath != metricsSocketPath() {
+		log.Printf("Warning: core.metrics_socket changed to %q; restart shined to apply", sockPath)
 	}
 
-	currentPanels := pm.ListPanels()
+	newEntries, err := prismEntries(pkgCfg)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
 
-	// Build maps of current and new prism names and compare
-	currentPrisms := make(map[string]*Panel)
-	for _, panel := range currentPanels {
-		currentPrisms[panel.Name] = panel
+	// Panels are keyed by instance, so each instance of a prism is added or
+	// removed on its own
+	currentPanels := make(map[string]*Panel)
+	for _, panel := range pm.ListPanels() {
+		currentPanels[panel.Instance] = panel
 	}
 
-	newPrisms := make(map[string]*PrismEntry)
+	newPanels := make(map[string]*PrismEntry)
 	for _, entry := range newEntries {
-		newPrisms[entry.Name] = entry
+		newPanels[entry.Instance] = entry
 	}
 
-  // kill   = {x ∈ current : x ∉ new}
-	for name, panel := range currentPrisms {
-		if _, exists := newPrisms[name]; !exists {
-			log.Printf("Removing panel for prism %s (no longer in config)", name)
+	// kill   = {x ∈ current : x ∉ new}, dependents first
+	for _, panel := range stopOrder(pm.ListPanels()) {
+		if _, exists := newPanels[panel.Instance]; !exists {
+			log.Printf("Removing panel %s (no longer in config)", panel.Instance)
 			if err := pm.KillPanel(panel.Instance); err != nil {
 				log.Printf("Failed to kill panel %s: %v", panel.Instance, err)
 			}
 		}
 	}
 
-  // spawn  = {x ∈ new : x ∉ current}
-	for name, entry := range newPrisms {
-		if _, exists := currentPrisms[name]; !exists {
-			instanceName := entry.Name
-
-			log.Printf("Adding new panel for prism: %s (instance: %s)", name, instanceName)
+	// spawn  = {x ∈ new : x ∉ current}
+	for _, entry := range newEntries {
+		if _, exists := currentPanels[entry.Instance]; !exists {
+			log.Printf("Adding new panel for prism: %s (instance: %s)", entry.Name, entry.Instance)
 
-			panel, err := pm.SpawnPanel(entry, instanceName)
+			panel, err := spawnEntry(pm, entry)
 			if err != nil {
-				log.Printf("Failed to spawn panel for %s: %v", name, err)
+				log.Printf("Failed to spawn panel %s: %v", entry.Instance, err)
 				continue
 			}
 
@@ -255,6 +291,15 @@ func reloadConfig(pm *PanelManager, configPath string) error {
 		}
 	}
 
+	// update = {x ∈ new : x ∈ current}
+	for _, entry := range newEntries {
+		if _, exists := currentPanels[entry.Instance]; exists {
+			if err := pm.ReconfigurePanel(entry); err != nil {
+				log.Printf("Failed to reconfigure panel %s: %v", entry.Instance, err)
+			}
+		}
+	}
+
 	log.Println("Configuration reloaded successfully")
 	return nil
 }
@@ -0,0 +1,144 @@
+// metrics.go defines the metrics shined exports over service/metrics and the
+// optional OpenMetrics socket. Each panel's prismctl metrics are gathered
+// alongside shined's own, labelled with the panel instance.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/metrics"
+	"github.com/starbased-co/shine/pkg/paths"
+)
+
+// panelMetricsTimeout bounds how long a gather waits for one panel's
+// prismctl, so a wedged panel cannot stall a scrape
+const panelMetricsTimeout = time.Second
+
+var (
+	metricsRegistry = metrics.NewRegistry()
+
+	rpcDuration = metricsRegistry.Histogram("shined_rpc_duration_seconds",
+		"Time taken to handle RPC calls", metrics.LatencyBuckets, "method")
+	rpcErrors = metricsRegistry.Counter("shined_rpc_errors_total",
+		"RPC calls that returned an error", "method")
+	panelExits = metricsRegistry.Counter("shined_panel_exits_total",
+		"Panels that exited outside a requested kill", "panel", "reason")
+	panelRestarts = metricsRegistry.Counter("shined_panel_restarts_total",
+		"Panels respawned by their restart policy", "panel")
+	panelHealthFailures = metricsRegistry.Counter("shined_health_check_failures_total",
+		"Failed panel health checks", "panel")
+	panelCPU = metricsRegistry.Gauge("shined_panel_cpu_seconds",
+		"CPU time consumed by each panel's prismctl", "panel")
+	panelRSS = metricsRegistry.Gauge("shined_panel_rss_bytes",
+		"Resident memory of each panel's prismctl", "panel")
+)
+
+// MetricsSocketFromConfig resolves [core] metrics_socket. An empty path
+// means the OpenMetrics endpoint is disabled.
+func MetricsSocketFromConfig(core *config.CoreConfig) string {
+	if core == nil || core.MetricsSocket == "" {
+		return ""
+	}
+	return paths.ExpandHome(os.ExpandEnv(core.MetricsSocket))
+}
+
+var metricsServer *metrics.Server
+
+// startMetricsServer serves the OpenMetrics endpoint on sockPath, if set
+func startMetricsServer(pm *PanelManager, sockPath string) error {
+	if sockPath == "" {
+		return nil
+	}
+
+	server, err := metrics.Serve(sockPath, pm.GatherMetrics)
+	if err != nil {
+		return err
+	}
+	metricsServer = server
+
+	log.Printf("Metrics endpoint listening on %s", server.SocketPath())
+	return nil
+}
+
+func stopMetricsServer() {
+	if metricsServer != nil {
+		log.Println("Stopping metrics endpoint")
+		metricsServer.Close()
+		metricsServer = nil
+	}
+}
+
+// metricsSocketPath returns the socket the endpoint is serving on, or ""
+func metricsSocketPath() string {
+	if metricsServer == nil {
+		return ""
+	}
+	return metricsServer.SocketPath()
+}
+
+// observeRPC records an RPC call's latency and outcome
+func observeRPC(method string, elapsed time.Duration, err error) {
+	rpcDuration.Observe(elapsed.Seconds(), method)
+	if err != nil {
+		rpcErrors.Inc(method)
+	}
+}
+
+// sampleUsage refreshes the per-panel CPU and memory gauges from /proc
+func (pm *PanelManager) sampleUsage() {
+	panelCPU.Reset()
+	panelRSS.Reset()
+	for _, panel := range pm.ListPanels() {
+		if panel.PID == 0 {
+			continue
+		}
+		usage, err := metrics.ReadProcessUsage(panel.PID)
+		if err != nil {
+			log.Printf("Metrics: failed to sample %s (PID %d): %v", panel.Instance, panel.PID, err)
+			continue
+		}
+		panelCPU.Set(usage.CPUSeconds, panel.Instance)
+		panelRSS.Set(float64(usage.RSSBytes), panel.Instance)
+	}
+}
+
+// GatherMetrics returns shined's metrics merged with those of every panel's
+// prismctl. Panels that do not answer in time are left out.
+func (pm *PanelManager) GatherMetrics() []metrics.Family {
+	panels := pm.ListPanels()
+	slices.SortFunc(panels, func(a, b *Panel) int {
+		return cmp.Compare(a.Instance, b.Instance)
+	})
+
+	sets := make([][]metrics.Family, len(panels)+1)
+	sets[0] = metricsRegistry.Gather()
+
+	var wg sync.WaitGroup
+	for i, panel := range panels {
+		wg.Add(1)
+		go func(i int, panel *Panel) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), panelMetricsTimeout)
+			defer cancel()
+
+			result, err := panel.RPCClient.Metrics(ctx)
+			if err != nil {
+				log.Printf("Metrics: failed to gather from %s: %v", panel.Instance, err)
+				return
+			}
+			sets[i+1] = metrics.WithLabel(result.Families, "panel", panel.Instance)
+		}(i, panel)
+	}
+	wg.Wait()
+
+	return metrics.Merge(sets...)
+}
@@ -32,10 +32,14 @@ func (h *Handlers) handlePrismStopped(ctx context.Context, n *rpc.PrismStoppedNo
 }
 
 func (h *Handlers) handlePrismCrashed(ctx context.Context, n *rpc.PrismCrashedNotification) (*NotificationAck, error) {
-	log.Printf("[%s] prism CRASHED: %s (exit=%d, signal=%d)", n.Panel, n.Name, n.ExitCode, n.Signal)
+	if n.Reason != "" {
+		log.Printf("[%s] prism CRASHED: %s (exit=%d, signal=%d, reason=%s)", n.Panel, n.Name, n.ExitCode, n.Signal, n.Reason)
+	} else {
+		log.Printf("[%s] prism CRASHED: %s (exit=%d, signal=%d)", n.Panel, n.Name, n.ExitCode, n.Signal)
+	}
 
 	if h.state != nil {
-		h.state.OnPanelPrismCrashed(n.Panel, n.Name, n.ExitCode, n.Signal)
+		h.state.OnPanelPrismCrashed(n.Panel, n.Name, n.ExitCode, n.Signal, n.Reason)
 	}
 
 	h.pm.TriggerRestartPolicy(n.Panel, n.Name, n.ExitCode)
@@ -43,6 +47,30 @@ func (h *Handlers) handlePrismCrashed(ctx context.Context, n *rpc.PrismCrashedNo
 	return &NotificationAck{}, nil
 }
 
+func (h *Handlers) handlePrismHealth(ctx context.Context, n *rpc.PrismHealthNotification) (*NotificationAck, error) {
+	if n.Message != "" {
+		log.Printf("[%s] prism health: %s is %s (%s)", n.Panel, n.Name, n.Status, n.Message)
+	} else {
+		log.Printf("[%s] prism health: %s is %s", n.Panel, n.Name, n.Status)
+	}
+
+	if h.state != nil {
+		h.state.OnPanelPrismHealthChanged(n.Panel, n.Name, n.Status, n.Message)
+	}
+
+	return &NotificationAck{}, nil
+}
+
+func (h *Handlers) handlePanelClosing(ctx context.Context, n *rpc.PanelClosingNotification) (*NotificationAck, error) {
+	log.Printf("[%s] panel closing: %s", n.Panel, n.Reason)
+
+	if panel, ok := h.pm.GetPanel(n.Panel); ok {
+		panel.liveness.setClosing(n.Reason)
+	}
+
+	return &NotificationAck{}, nil
+}
+
 func (h *Handlers) handleForegroundChanged(ctx context.Context, n *rpc.ForegroundChangedNotification) (*NotificationAck, error) {
 	log.Printf("[%s] foreground changed: %s → %s", n.Panel, n.From, n.To)
 
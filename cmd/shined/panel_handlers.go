@@ -24,19 +24,24 @@ func (h *Handlers) handlePanelList(ctx context.Context) (*rpc.PanelListResult, e
 	}
 
 	for i, panel := range panels {
-		healthy := h.pm.CheckHealth(panel)
-		result.Panels[i] = rpc.PanelInfo{
-			Instance: panel.Instance,
-			Name:     panel.Name,
-			PID:      panel.PID,
-			Socket:   panel.SocketPath,
-			Healthy:  healthy,
-		}
+		result.Panels[i] = panelInfo(panel)
 	}
 
 	return result, nil
 }
 
+// panelInfo reports a panel with its cached health; it never contacts the panel
+func panelInfo(panel *Panel) rpc.PanelInfo {
+	info := rpc.PanelInfo{
+		Instance: panel.Instance,
+		Name:     panel.Name,
+		PID:      panel.PID,
+		Socket:   panel.SocketPath,
+	}
+	panel.health.info(&info)
+	return info
+}
+
 func (h *Handlers) handlePanelSpawn(ctx context.Context, req *rpc.PanelSpawnRequest) (*rpc.PanelSpawnResult, error) {
 	configJSON, err := json.Marshal(req.Config)
 	if err != nil {
@@ -74,6 +79,7 @@ func (h *Handlers) handlePanelSpawn(ctx context.Context, req *rpc.PanelSpawnRequ
 	if instance, ok := req.Config["instance"].(string); ok && instance != "" {
 		instanceName = instance
 	}
+	entry.Instance = instanceName
 
 	if _, exists := h.pm.GetPanel(instanceName); exists {
 		return nil, rpc.ErrResourceBusy(fmt.Sprintf("panel instance %s already exists", instanceName))
@@ -111,6 +117,37 @@ func (h *Handlers) handlePanelKill(ctx context.Context, req *rpc.PanelKillReques
 	return &rpc.PanelKillResult{Killed: true}, nil
 }
 
+func (h *Handlers) handlePanelShow(ctx context.Context, req *rpc.PanelWindowRequest) (*rpc.PanelWindowResult, error) {
+	return h.panelWindow(req.Instance, "show panel", h.pm.ShowPanel)
+}
+
+func (h *Handlers) handlePanelHide(ctx context.Context, req *rpc.PanelWindowRequest) (*rpc.PanelWindowResult, error) {
+	return h.panelWindow(req.Instance, "hide panel", h.pm.HidePanel)
+}
+
+func (h *Handlers) handlePanelResize(ctx context.Context, req *rpc.PanelResizeRequest) (*rpc.PanelWindowResult, error) {
+	if req.Cols <= 0 || req.Rows <= 0 {
+		return nil, rpc.ErrInvalidParams("cols and rows must be positive")
+	}
+	return h.panelWindow(req.Instance, "resize panel", func(instance string) error {
+		return h.pm.ResizePanel(instance, req.Cols, req.Rows)
+	})
+}
+
+// panelWindow runs a window operation on a known panel
+func (h *Handlers) panelWindow(instance, op string, fn func(instance string) error) (*rpc.PanelWindowResult, error) {
+	if instance == "" {
+		return nil, rpc.ErrInvalidParams("instance name required")
+	}
+	if _, ok := h.pm.GetPanel(instance); !ok {
+		return nil, rpc.ErrPanelNotFound(instance)
+	}
+	if err := fn(instance); err != nil {
+		return nil, rpc.ErrOperationFailed(op, err)
+	}
+	return &rpc.PanelWindowResult{OK: true}, nil
+}
+
 func (h *Handlers) handleServiceStatus(ctx context.Context) (*rpc.ServiceStatusResult, error) {
 	panels := h.pm.ListPanels()
 
@@ -118,22 +155,21 @@ func (h *Handlers) handleServiceStatus(ctx context.Context) (*rpc.ServiceStatusR
 		Panels:  make([]rpc.PanelInfo, len(panels)),
 		Uptime:  h.state.Uptime().Milliseconds(),
 		Version: version,
+		Backend: h.pm.BackendName(),
 	}
+	result.Starting, result.StartupErrors = h.state.Startup()
 
 	for i, panel := range panels {
-		healthy := h.pm.CheckHealth(panel)
-		result.Panels[i] = rpc.PanelInfo{
-			Instance: panel.Instance,
-			Name:     panel.Name,
-			PID:      panel.PID,
-			Socket:   panel.SocketPath,
-			Healthy:  healthy,
-		}
+		result.Panels[i] = panelInfo(panel)
 	}
 
 	return result, nil
 }
 
+func (h *Handlers) handleServiceMetrics(ctx context.Context) (*rpc.MetricsResult, error) {
+	return &rpc.MetricsResult{Families: h.pm.GatherMetrics()}, nil
+}
+
 func (h *Handlers) handleConfigReload(ctx context.Context) (*rpc.ConfigReloadResult, error) {
 	log.Println("config/reload via RPC")
 
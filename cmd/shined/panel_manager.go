@@ -1,18 +1,19 @@
 package main
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/starbased-co/shine/pkg/paths"
+	"github.com/starbased-co/shine/pkg/config"
 	"github.com/starbased-co/shine/pkg/rpc"
 )
 
@@ -26,6 +27,9 @@ type Panel struct {
 	Config     *PrismEntry
 	CrashCount int
 	LastCrash  time.Time
+
+	health   panelHealth
+	liveness panelLiveness
 }
 
 type PrismRestartState struct {
@@ -38,47 +42,30 @@ type PanelManager struct {
 	mu       sync.Mutex
 	panels   map[string]*Panel
 	logDir   string
-	prismctlBin string
+	backend  PanelBackend
 	restartState map[string]map[string]*PrismRestartState
-}
 
-func getPIDFromWindowID(windowID string) (int, error) {
-	cmd := exec.Command("kitten", "@", "ls")
-	output, err := cmd.Output()
-	if err != nil {
-		return 0, fmt.Errorf("failed to list kitty windows: %w", err)
-	}
+	health      HealthSettings
+	healthReset chan struct{}
+	stopping    bool
 
-	var osWindows []struct {
-		ID   int `json:"id"`
-		Tabs []struct {
-			ID      int `json:"id"`
-			Windows []struct {
-				ID  int `json:"id"`
-				PID int `json:"pid"`
-			} `json:"windows"`
-		} `json:"tabs"`
-	}
+	// OnHealthChanged is called when a panel's cached health flips
+	OnHealthChanged func(instance string, healthy bool)
+	// OnPanelExited is called when a panel dies or is closed outside KillPanel
+	OnPanelExited func(instance, reason string)
+	// OnPanelSpawned is called when a panel is respawned by its restart policy
+	OnPanelSpawned func(panel *Panel)
 
-	if err := json.Unmarshal(output, &osWindows); err != nil {
-		return 0, fmt.Errorf("failed to parse kitty ls output: %w", err)
-	}
+	listWindows func() (map[string]int, error)
 
-	targetID := windowID
-	for _, osWin := range osWindows {
-		for _, tab := range osWin.Tabs {
-			for _, win := range tab.Windows {
-				if fmt.Sprintf("%d", win.ID) == targetID {
-					return win.PID, nil
-				}
-			}
-		}
-	}
-
-	return 0, fmt.Errorf("window ID %s not found", windowID)
+	registerTimeout time.Duration
+	pendingMu       sync.Mutex
+	pending         map[string]chan *rpc.PanelRegisterRequest // instance → spawn awaiting panel/register
 }
 
-func NewPanelManager() (*PanelManager, error) {
+// NewPanelManager creates a panel manager hosting panels on the named
+// backend (see PanelBackendFromConfig)
+func NewPanelManager(backendName string) (*PanelManager, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
@@ -101,11 +88,22 @@ func NewPanelManager() (*PanelManager, error) {
 		}
 	}
 
+	backend, err := newPanelBackend(backendName, prismctlBin)
+	if err != nil {
+		return nil, err
+	}
+
 	return &PanelManager{
 		panels:       make(map[string]*Panel),
 		logDir:       logDir,
-		prismctlBin:  prismctlBin,
+		backend:      backend,
 		restartState: make(map[string]map[string]*PrismRestartState),
+		health:       DefaultHealthSettings(),
+		healthReset:  make(chan struct{}, 1),
+		listWindows:  backend.List,
+
+		registerTimeout: defaultRegisterTimeout,
+		pending:         make(map[string]chan *rpc.PanelRegisterRequest),
 	}, nil
 }
 
@@ -117,101 +115,110 @@ func (pm *PanelManager) SpawnPanel(config *PrismEntry, instanceName string) (*Pa
 		return existing, nil
 	}
 
-	panelCfg := config.ToPanelConfig()
-	prismctlArgs := []string{instanceName}
-	kittenArgs := panelCfg.ToPanelArgs(pm.prismctlBin)
-	kittenArgs = append(kittenArgs, prismctlArgs...)
-	cmd := exec.Command("kitten", kittenArgs...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("failed to spawn panel: %w\nOutput: %s", err, string(output))
-	}
-
-	windowID := strings.TrimSpace(string(output))
-	if windowID == "" {
-		return nil, fmt.Errorf("failed to get window ID from Kitty")
-	}
-
-	log.Printf("Spawned panel %s (window ID: %s)", instanceName, windowID)
-
-	pid, err := getPIDFromWindowID(windowID)
-	if err != nil {
-		log.Printf("Warning: failed to get PID for window %s: %v", windowID, err)
-		pid = 0
-	}
-
-	socketPath := paths.PrismSocket(instanceName)
-
-	for i := 0; i < 50; i++ {
-		if _, err := os.Stat(socketPath); err == nil {
-			break
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-
-	if _, err := os.Stat(socketPath); err != nil {
-		return nil, fmt.Errorf("prismctl socket not created within timeout")
-	}
+	return pm.spawnPanelUnlocked(config, instanceName)
+}
 
-	rpcClient, err := rpc.NewPrismClient(socketPath)
+func (pm *PanelManager) configureApps(panel *Panel, config *PrismEntry) error {
+	sandbox, err := config.SandboxSpec()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create RPC client: %w", err)
+		return fmt.Errorf("invalid sandbox: %w", err)
 	}
 
-	panel := &Panel{
-		Name:       config.Name,
-		Instance:   instanceName,
-		WindowID:   windowID,
-		PID:        pid,
-		SocketPath: socketPath,
-		RPCClient:  rpcClient,
-		Config:     config,
-		CrashCount: 0,
-	}
-
-	pm.panels[instanceName] = panel
-
-	if err := pm.configureApps(panel, config); err != nil {
-		return nil, fmt.Errorf("failed to configure apps: %w", err)
-	}
+	apps := make([]rpc.AppInfo, 0)
 
-	return panel, nil
-}
+	// prismctl answers once every app is ready, so allow for each app's
+	// start timeout on top of the usual round trip
+	timeout := 10 * time.Second
 
-func (pm *PanelManager) configureApps(panel *Panel, config *PrismEntry) error {
-	apps := make([]rpc.AppInfo, 0)
+	appCfgs := config.GetApps()
+	sent := make(map[string]bool, len(appCfgs))
 
-	for name, appCfg := range config.GetApps() {
+	for _, name := range config.AppOrder() {
+		appCfg := appCfgs[name]
 		if appCfg == nil || !appCfg.Enabled || appCfg.ResolvedPath == "" {
 			continue
 		}
+		health, err := HealthCheckSpec(appCfg.Health)
+		if err != nil {
+			return fmt.Errorf("invalid health check for %s: %w", name, err)
+		}
+		ready, err := ReadySpec(appCfg.Ready)
+		if err != nil {
+			return fmt.Errorf("invalid ready for %s: %w", name, err)
+		}
+		if ready != nil {
+			timeout += time.Duration(ready.TimeoutMs) * time.Millisecond
+		}
 		apps = append(apps, rpc.AppInfo{
 			Name:    name,
 			Path:    appCfg.ResolvedPath,
+			Args:    config.Args,
+			Env:     config.AppEnv(name),
 			Enabled: appCfg.Enabled,
+			Sandbox: sandbox,
+			Health:  health,
+			Ready:   ready,
+			Lazy:    appCfg.Lazy,
 		})
+		sent[name] = true
 	}
 
 	if len(apps) == 0 {
 		return fmt.Errorf("no enabled apps with resolved paths")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defaultApp := config.DefaultApp()
+	if defaultApp != "" && !sent[defaultApp] {
+		log.Printf("Warning: default app %s of panel %s has no resolved path", defaultApp, panel.Instance)
+		defaultApp = ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	result, err := panel.RPCClient.Configure(ctx, apps)
+	result, err := panel.RPCClient.Configure(ctx, &rpc.ConfigureRequest{
+		Apps:    apps,
+		Idle:    config.IdleSpec(),
+		Default: defaultApp,
+	})
 	if err != nil {
 		return err
 	}
 
-	if len(result.Failed) > 0 {
-		return fmt.Errorf("failed to start apps: %v", result.Failed)
+	var failed []string
+	for _, app := range result.Apps {
+		if app.Reason != "" {
+			log.Printf("Panel %s: app %s %s (%s)", panel.Instance, app.Name, app.Action, app.Reason)
+		} else {
+			log.Printf("Panel %s: app %s %s", panel.Instance, app.Name, app.Action)
+		}
+		if app.Action == rpc.AppFailed {
+			failed = append(failed, fmt.Sprintf("%s: %s", app.Name, app.Reason))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to start apps: %s", strings.Join(failed, "; "))
 	}
 
-	log.Printf("Configured panel %s with %d apps: %v", panel.Instance, len(result.Started), result.Started)
 	return nil
 }
 
+// ReconfigurePanel applies a changed config to a running panel's apps.
+// prismctl reconciles the app set in place, so only apps whose launch
+// settings changed are restarted. Layout changes need the panel restarted.
+func (pm *PanelManager) ReconfigurePanel(config *PrismEntry) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	panel, ok := pm.panels[config.Instance]
+	if !ok {
+		return fmt.Errorf("panel %s not found", config.Instance)
+	}
+
+	panel.Config = config
+	return pm.configureApps(panel, config)
+}
+
 func (pm *PanelManager) KillPanel(instanceName string) error {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
@@ -221,14 +228,95 @@ func (pm *PanelManager) KillPanel(instanceName string) error {
 		return fmt.Errorf("panel %s not found", instanceName)
 	}
 
-	cmd := exec.Command("kitten", "@", "close-window", "--match", fmt.Sprintf("id:%s", panel.WindowID))
-	if err := cmd.Run(); err != nil {
+	pm.killPanelUnlocked(panel)
+	return nil
+}
+
+// Assumes caller holds pm.mu lock
+func (pm *PanelManager) killPanelUnlocked(panel *Panel) {
+	if err := pm.backend.Close(panel.WindowID); err != nil {
 		log.Printf("Warning: failed to close window %s: %v", panel.WindowID, err)
 	}
 
-	delete(pm.panels, instanceName)
-	log.Printf("Killed panel %s (window ID: %s)", instanceName, panel.WindowID)
-	return nil
+	delete(pm.panels, panel.Instance)
+	panel.liveness.stop()
+	panel.RPCClient.Close()
+	log.Printf("Killed panel %s (window ID: %s)", panel.Instance, panel.WindowID)
+}
+
+// PrismRunning reports whether any panel of the named prism is running
+func (pm *PanelManager) PrismRunning(prismName string) bool {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.prismRunningUnlocked(prismName)
+}
+
+func (pm *PanelManager) prismRunningUnlocked(prismName string) bool {
+	for _, panel := range pm.panels {
+		if panel.Name == prismName {
+			return true
+		}
+	}
+	return false
+}
+
+// stopDependentsUnlocked stops the panels that require prismName once it
+// has no running panel left, then their dependents in turn.
+// Assumes caller holds pm.mu lock
+func (pm *PanelManager) stopDependentsUnlocked(prismName string) {
+	if pm.prismRunningUnlocked(prismName) {
+		return
+	}
+
+	stopped := make(map[string]bool)
+	for _, panel := range stopOrder(pm.listPanelsUnlocked()) {
+		if !slices.Contains(panel.Config.Requires, prismName) {
+			continue
+		}
+
+		log.Printf("Stopping panel %s: required prism %s failed", panel.Instance, prismName)
+		pm.killPanelUnlocked(panel)
+		if pm.OnPanelExited != nil {
+			pm.OnPanelExited(panel.Instance, rpc.PanelExitDependencyFailed)
+		}
+		stopped[panel.Name] = true
+	}
+
+	for name := range stopped {
+		pm.stopDependentsUnlocked(name)
+	}
+}
+
+// BackendName reports which backend hosts the panels
+func (pm *PanelManager) BackendName() string {
+	return pm.backend.Name()
+}
+
+// ShowPanel and HidePanel toggle a panel's window without stopping it
+func (pm *PanelManager) ShowPanel(instanceName string) error {
+	return pm.withWindow(instanceName, pm.backend.Show)
+}
+
+func (pm *PanelManager) HidePanel(instanceName string) error {
+	return pm.withWindow(instanceName, pm.backend.Hide)
+}
+
+// ResizePanel sets a panel's window size in cells; prismctl follows with
+// SIGWINCH
+func (pm *PanelManager) ResizePanel(instanceName string, cols, rows int) error {
+	return pm.withWindow(instanceName, func(windowID string) error {
+		return pm.backend.Resize(windowID, cols, rows)
+	})
+}
+
+func (pm *PanelManager) withWindow(instanceName string, fn func(windowID string) error) error {
+	pm.mu.Lock()
+	panel, ok := pm.panels[instanceName]
+	pm.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("panel %s not found", instanceName)
+	}
+	return fn(panel.WindowID)
 }
 
 func (pm *PanelManager) GetPanel(instanceName string) (*Panel, bool) {
@@ -242,7 +330,11 @@ func (pm *PanelManager) GetPanel(instanceName string) (*Panel, bool) {
 func (pm *PanelManager) ListPanels() []*Panel {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
+	return pm.listPanelsUnlocked()
+}
 
+// Assumes caller holds pm.mu lock
+func (pm *PanelManager) listPanelsUnlocked() []*Panel {
 	panels := make([]*Panel, 0, len(pm.panels))
 	for _, panel := range pm.panels {
 		panels = append(panels, panel)
@@ -250,59 +342,78 @@ func (pm *PanelManager) ListPanels() []*Panel {
 	return panels
 }
 
-func (pm *PanelManager) CheckHealth(panel *Panel) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-
-	_, err := panel.RPCClient.Health(ctx)
-	return err == nil
-}
-
-func (pm *PanelManager) MonitorPanels() {
-	panels := pm.ListPanels()
-
+// stopOrder sorts panels so that each stops before the prisms it depends
+// on: reverse dependency order, instances of one prism in reverse name order
+func stopOrder(panels []*Panel) []*Panel {
+	byName := make(map[string]*config.PrismConfig)
 	for _, panel := range panels {
-		if !pm.CheckHealth(panel) {
-			log.Printf("Panel %s is not responsive", panel.Instance)
-			pm.handlePanelCrash(panel)
+		byName[panel.Name] = panel.Config.PrismConfig
+	}
+
+	rank := make(map[string]int, len(byName))
+	if order, err := config.DependencyOrder(byName); err == nil {
+		for i, name := range order {
+			rank[name] = i
 		}
 	}
+
+	sorted := slices.Clone(panels)
+	slices.SortStableFunc(sorted, func(a, b *Panel) int {
+		if c := cmp.Compare(rank[b.Name], rank[a.Name]); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Instance, a.Instance)
+	})
+	return sorted
 }
 
-func (pm *PanelManager) handlePanelCrash(panel *Panel) {
+// handlePanelExit removes a panel that died or was closed outside KillPanel
+// and applies its restart policy. reason is one of the rpc.PanelExit*
+// constants.
+func (pm *PanelManager) handlePanelExit(panel *Panel, reason string) {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
-	delete(pm.panels, panel.Instance)
-
-	now := time.Now()
-	if now.Sub(panel.LastCrash) > time.Hour {
-		panel.CrashCount = 0
+	// Already handled via another path (pidfd, disconnect, failed check), or
+	// replaced by a restart
+	if pm.panels[panel.Instance] != panel || pm.stopping {
+		return
 	}
-	panel.CrashCount++
-	panel.LastCrash = now
 
-	log.Printf("Panel %s crashed (crash count: %d)", panel.Instance, panel.CrashCount)
+	delete(pm.panels, panel.Instance)
+	panel.liveness.stop()
+	panel.RPCClient.Close()
+	panelExits.Inc(panel.Instance, reason)
+
+	if panelExitIsFailure(reason) {
+		now := time.Now()
+		if now.Sub(panel.LastCrash) > time.Hour {
+			panel.CrashCount = 0
+		}
+		panel.CrashCount++
+		panel.LastCrash = now
 
-	policy := panel.Config.GetRestartPolicy()
-	shouldRestart := false
+		log.Printf("Panel %s crashed: %s (crash count: %d)", panel.Instance, reason, panel.CrashCount)
+	} else {
+		log.Printf("Panel %s exited: %s", panel.Instance, reason)
+	}
 
-	switch policy {
-	case RestartAlways:
-		shouldRestart = true
-	case RestartOnFailure:
-		shouldRestart = true
-	case RestartUnlessStopped:
-		shouldRestart = true
-	case RestartNo:
-		shouldRestart = false
+	if pm.OnPanelExited != nil {
+		pm.OnPanelExited(panel.Instance, reason)
 	}
 
+	shouldRestart := shouldRestartPanel(panel.Config.GetRestartPolicy(), reason)
+
 	if shouldRestart && panel.Config.MaxRestarts > 0 && panel.CrashCount > panel.Config.MaxRestarts {
 		log.Printf("Panel %s exceeded max_restarts (%d), not restarting", panel.Instance, panel.Config.MaxRestarts)
 		shouldRestart = false
 	}
 
+	// A failure that will not be restarted is permanent
+	if panelExitIsFailure(reason) && !shouldRestart {
+		pm.stopDependentsUnlocked(panel.Name)
+	}
+
 	if shouldRestart {
 		delay := panel.Config.GetRestartDelay()
 		log.Printf("Restarting panel %s after %v delay", panel.Instance, delay)
@@ -312,82 +423,128 @@ func (pm *PanelManager) handlePanelCrash(panel *Panel) {
 			pm.mu.Lock()
 			defer pm.mu.Unlock()
 
+			if pm.stopping {
+				return
+			}
+
 			newPanel, err := pm.spawnPanelUnlocked(panel.Config, panel.Instance)
 			if err != nil {
 				log.Printf("Failed to restart panel %s: %v", panel.Instance, err)
+				pm.stopDependentsUnlocked(panel.Name)
 				return
 			}
 
 			newPanel.CrashCount = panel.CrashCount
 			newPanel.LastCrash = panel.LastCrash
+			panelRestarts.Inc(panel.Instance)
+
+			if pm.OnPanelSpawned != nil {
+				pm.OnPanelSpawned(newPanel)
+			}
 
 			log.Printf("Successfully restarted panel %s", panel.Instance)
 		}()
 	}
 }
 
-func (pm *PanelManager) spawnPanelUnlocked(config *PrismEntry, instanceName string) (*Panel, error) {
-	panelCfg := config.ToPanelConfig()
-	prismctlArgs := []string{instanceName}
-	kittenArgs := panelCfg.ToPanelArgs(pm.prismctlBin)
-	kittenArgs = append(kittenArgs, prismctlArgs...)
-	cmd := exec.Command("kitten", kittenArgs...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("failed to spawn panel: %w\nOutput: %s", err, string(output))
+// panelExitIsFailure reports whether an exit reason counts as a crash
+func panelExitIsFailure(reason string) bool {
+	switch reason {
+	case rpc.PanelExitCrashed, rpc.PanelExitUnresponsive:
+		return true
 	}
+	return false
+}
 
-	windowID := strings.TrimSpace(string(output))
-	if windowID == "" {
-		return nil, fmt.Errorf("failed to get window ID from Kitty")
+// shouldRestartPanel applies a restart policy to an exit reason. A window the
+// user closed, or a panel stopped on request, is never restarted; neither is
+// a panel whose kitty has exited, since there is nothing to respawn it into.
+func shouldRestartPanel(policy RestartPolicy, reason string) bool {
+	switch reason {
+	case rpc.PanelExitWindowClosed, rpc.PanelExitRequested, rpc.PanelExitKittyExited:
+		return false
 	}
 
-	pid, err := getPIDFromWindowID(windowID)
-	if err != nil {
-		log.Printf("Warning: failed to get PID for window %s: %v", windowID, err)
-		pid = 0
+	switch policy {
+	case RestartAlways, RestartUnlessStopped:
+		return true
+	case RestartOnFailure:
+		return panelExitIsFailure(reason)
 	}
+	return false
+}
 
-	socketPath := paths.PrismSocket(instanceName)
+// spawnPanelUnlocked launches prismctl in a new window of the backend and
+// waits for it to register via panel/register.
+// Assumes caller holds pm.mu lock
+func (pm *PanelManager) spawnPanelUnlocked(config *PrismEntry, instanceName string) (*Panel, error) {
+	registered := pm.expectRegistration(instanceName)
+	defer pm.cancelRegistration(instanceName, registered)
 
-	for i := 0; i < 50; i++ {
-		if _, err := os.Stat(socketPath); err == nil {
-			break
-		}
-		time.Sleep(100 * time.Millisecond)
+	windowID, err := pm.backend.Spawn(config, instanceName)
+	if err != nil {
+		return nil, err
 	}
 
-	if _, err := os.Stat(socketPath); err != nil {
-		return nil, fmt.Errorf("prismctl socket not created within timeout")
+	log.Printf("Spawned panel %s (%s window ID: %s), awaiting registration", instanceName, pm.backend.Name(), windowID)
+
+	var reg *rpc.PanelRegisterRequest
+	select {
+	case reg = <-registered:
+	case <-time.After(pm.registerTimeout):
+		return nil, fmt.Errorf("prismctl did not register within %v", pm.registerTimeout)
 	}
 
-	rpcClient, err := rpc.NewPrismClient(socketPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create RPC client: %w", err)
+	if reg.WindowID != "" && reg.WindowID != windowID {
+		log.Printf("Warning: panel %s registered window %s, %s reported %s", instanceName, reg.WindowID, pm.backend.Name(), windowID)
 	}
 
+	pid := reg.PID
+	socketPath := reg.Socket
+
+	// The panel exists before its client so a disconnect can never see it
+	// nil. RPCClient is set and the panel registered under pm.mu, which
+	// panelDisconnected acquires before looking the panel up.
 	panel := &Panel{
 		Name:       config.Name,
 		Instance:   instanceName,
 		WindowID:   windowID,
 		PID:        pid,
 		SocketPath: socketPath,
-		RPCClient:  rpcClient,
 		Config:     config,
 		CrashCount: 0,
 	}
+	rpcClient, err := rpc.NewPrismClient(socketPath, rpc.WithOnDisconnect(func(err error) {
+		go pm.panelDisconnected(panel, err)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RPC client: %w", err)
+	}
+	panel.RPCClient = rpcClient
 
 	pm.panels[instanceName] = panel
 
+	// A panel whose apps failed to start, or to become ready, did not spawn
 	if err := pm.configureApps(panel, config); err != nil {
+		pm.killPanelUnlocked(panel)
 		return nil, fmt.Errorf("failed to configure apps: %w", err)
 	}
 
+	// A successful prism/configure round trip proves prismctl is responsive
+	pm.recordHealth(panel, nil)
+
+	pm.watchPanel(panel)
+
 	return panel, nil
 }
 
 func (pm *PanelManager) Shutdown() {
-	panels := pm.ListPanels()
+	pm.mu.Lock()
+	pm.stopping = true
+	pm.mu.Unlock()
+
+	// Dependents stop before the prisms they depend on
+	panels := stopOrder(pm.ListPanels())
 
 	for _, panel := range panels {
 		log.Printf("Stopping panel %s", panel.Instance)
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+// addTestPanel registers a panel that has no window or prismctl behind it
+func addTestPanel(pm *PanelManager, pc *config.PrismConfig, instance string) *Panel {
+	panel := &Panel{
+		Name:      pc.Name,
+		Instance:  instance,
+		WindowID:  "-1",
+		Config:    newPrismEntry(pc, instance),
+		RPCClient: &rpc.PrismClient{Client: &rpc.Client{}},
+	}
+	pm.panels[instance] = panel
+	return panel
+}
+
+func TestStopOrder(t *testing.T) {
+	pm := newTestPanelManager()
+	addTestPanel(pm, &config.PrismConfig{Name: "bar", Requires: []string{"daemon"}}, "bar:left")
+	addTestPanel(pm, &config.PrismConfig{Name: "bar", Requires: []string{"daemon"}}, "bar:right")
+	addTestPanel(pm, &config.PrismConfig{Name: "clock", After: []string{"bar"}}, "clock")
+	addTestPanel(pm, &config.PrismConfig{Name: "daemon"}, "daemon")
+
+	var got []string
+	for _, panel := range stopOrder(pm.ListPanels()) {
+		got = append(got, panel.Instance)
+	}
+
+	want := []string{"clock", "bar:right", "bar:left", "daemon"}
+	if !slices.Equal(got, want) {
+		t.Errorf("stopOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestHandlePanelExit_StopsDependents(t *testing.T) {
+	pm := newTestPanelManager()
+	pm.backend = newLocalBackend("true")
+
+	var exited []string
+	pm.OnPanelExited = func(instance, reason string) {
+		exited = append(exited, instance+"="+reason)
+	}
+
+	daemon := addTestPanel(pm, &config.PrismConfig{Name: "daemon"}, "daemon")
+	addTestPanel(pm, &config.PrismConfig{Name: "bar", Requires: []string{"daemon"}}, "bar")
+	addTestPanel(pm, &config.PrismConfig{Name: "tray", Requires: []string{"bar"}}, "tray")
+	addTestPanel(pm, &config.PrismConfig{Name: "clock", Wants: []string{"daemon"}}, "clock")
+
+	pm.handlePanelExit(daemon, rpc.PanelExitCrashed)
+
+	want := []string{
+		"daemon=" + rpc.PanelExitCrashed,
+		"bar=" + rpc.PanelExitDependencyFailed,
+		"tray=" + rpc.PanelExitDependencyFailed,
+	}
+	if !slices.Equal(exited, want) {
+		t.Errorf("exits = %v, want %v", exited, want)
+	}
+
+	// wants is not a hard dependency
+	if _, ok := pm.GetPanel("clock"); !ok {
+		t.Error("clock stopped along with a prism it only wants")
+	}
+}
+
+func TestHandlePanelExit_CleanExitKeepsDependents(t *testing.T) {
+	pm := newTestPanelManager()
+	pm.backend = newLocalBackend("true")
+
+	daemon := addTestPanel(pm, &config.PrismConfig{Name: "daemon"}, "daemon")
+	addTestPanel(pm, &config.PrismConfig{Name: "bar", Requires: []string{"daemon"}}, "bar")
+
+	pm.handlePanelExit(daemon, rpc.PanelExitEmpty)
+
+	if _, ok := pm.GetPanel("bar"); !ok {
+		t.Error("bar stopped after daemon exited cleanly")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+// Prism control methods route a request to the prismctl of the named panel,
+// so clients only need shined's socket.
+
+func (h *Handlers) panelClient(panel string) (*rpc.PrismClient, error) {
+	if panel == "" {
+		return nil, rpc.ErrInvalidParams("panel is required")
+	}
+
+	p, ok := h.pm.GetPanel(panel)
+	if !ok {
+		return nil, rpc.ErrPanelNotFound(panel)
+	}
+	return p.RPCClient, nil
+}
+
+func (h *Handlers) panelPrismClient(req *rpc.PanelPrismRequest) (*rpc.PrismClient, error) {
+	if req.Name == "" {
+		return nil, rpc.ErrInvalidParams("name is required")
+	}
+	return h.panelClient(req.Panel)
+}
+
+func (h *Handlers) handlePrismUp(ctx context.Context, req *rpc.PanelPrismRequest) (*rpc.UpResult, error) {
+	client, err := h.panelPrismClient(req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("prism/up: %s in %s", req.Name, req.Panel)
+	return client.Up(ctx, req.Name)
+}
+
+func (h *Handlers) handlePrismDown(ctx context.Context, req *rpc.PanelPrismRequest) (*rpc.DownResult, error) {
+	client, err := h.panelPrismClient(req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("prism/down: %s in %s", req.Name, req.Panel)
+	return client.Down(ctx, req.Name)
+}
+
+func (h *Handlers) handlePrismFg(ctx context.Context, req *rpc.PanelPrismRequest) (*rpc.FgResult, error) {
+	client, err := h.panelPrismClient(req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("prism/fg: %s in %s", req.Name, req.Panel)
+	return client.Fg(ctx, req.Name)
+}
+
+func (h *Handlers) handlePrismBg(ctx context.Context, req *rpc.PanelPrismRequest) (*rpc.BgResult, error) {
+	client, err := h.panelPrismClient(req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("prism/bg: %s in %s", req.Name, req.Panel)
+	return client.Bg(ctx, req.Name)
+}
+
+func (h *Handlers) handlePrismRestart(ctx context.Context, req *rpc.PanelPrismRequest) (*rpc.RestartResult, error) {
+	client, err := h.panelPrismClient(req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("prism/restart: %s in %s", req.Name, req.Panel)
+	return client.Restart(ctx, req.Name)
+}
+
+func (h *Handlers) handlePrismSignal(ctx context.Context, req *rpc.PanelSignalRequest) (*rpc.SignalResult, error) {
+	client, err := h.panelPrismClient(&rpc.PanelPrismRequest{Panel: req.Panel, Name: req.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("prism/signal: %s %s in %s", req.Signal, req.Name, req.Panel)
+	return client.Signal(ctx, req.Name, req.Signal)
+}
+
+func (h *Handlers) handlePrismSend(ctx context.Context, req *rpc.PanelSendRequest) (*rpc.SendResult, error) {
+	client, err := h.panelPrismClient(&rpc.PanelPrismRequest{Panel: req.Panel, Name: req.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Send(ctx, &rpc.SendRequest{
+		Name:  req.Name,
+		Text:  req.Text,
+		Keys:  req.Keys,
+		Data:  req.Data,
+		Paste: req.Paste,
+	})
+}
+
+func (h *Handlers) handlePrismCapture(ctx context.Context, req *rpc.PanelCaptureRequest) (*rpc.CaptureResult, error) {
+	client, err := h.panelClient(req.Panel)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Capture(ctx, &rpc.CaptureRequest{
+		Name:       req.Name,
+		Format:     req.Format,
+		Scrollback: req.Scrollback,
+	})
+}
+
+func (h *Handlers) handlePrismList(ctx context.Context, req *rpc.PanelRequest) (*rpc.ListResult, error) {
+	client, err := h.panelClient(req.Panel)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.List(ctx)
+}
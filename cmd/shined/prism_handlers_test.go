@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+func TestPrismHandlers_RouteToPanel(t *testing.T) {
+	pm := newTestPanelManager()
+	h := &Handlers{pm: pm}
+
+	var gotFg string
+	sockPath := filepath.Join(t.TempDir(), "prism.sock")
+	srv := rpc.NewServer(sockPath, handler.Map{
+		"prism/fg": rpc.Handler(func(ctx context.Context, req *rpc.FgRequest) (*rpc.FgResult, error) {
+			gotFg = req.Name
+			return &rpc.FgResult{OK: true}, nil
+		}),
+		"prism/list": rpc.HandlerFunc(func(ctx context.Context) (*rpc.ListResult, error) {
+			return &rpc.ListResult{Prisms: []rpc.PrismInfo{{Name: "clock", PID: 42, State: "fg"}}}, nil
+		}),
+	}, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+
+	client, err := rpc.NewPrismClient(sockPath)
+	if err != nil {
+		t.Fatalf("NewPrismClient() error: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	pm.panels["bar"] = &Panel{
+		Name:       "bar",
+		Instance:   "bar",
+		SocketPath: sockPath,
+		RPCClient:  client,
+		Config:     &PrismEntry{PrismConfig: &config.PrismConfig{Name: "bar"}, Restart: "no"},
+	}
+
+	ctx := context.Background()
+
+	fg, err := h.handlePrismFg(ctx, &rpc.PanelPrismRequest{Panel: "bar", Name: "clock"})
+	if err != nil {
+		t.Fatalf("handlePrismFg() error: %v", err)
+	}
+	if !fg.OK || gotFg != "clock" {
+		t.Errorf("fg result = %+v, forwarded name %q; want ok for clock", fg, gotFg)
+	}
+
+	list, err := h.handlePrismList(ctx, &rpc.PanelRequest{Panel: "bar"})
+	if err != nil {
+		t.Fatalf("handlePrismList() error: %v", err)
+	}
+	if len(list.Prisms) != 1 || list.Prisms[0].PID != 42 {
+		t.Errorf("list = %+v, want clock with PID 42", list.Prisms)
+	}
+
+	_, err = h.handlePrismUp(ctx, &rpc.PanelPrismRequest{Panel: "missing", Name: "clock"})
+	if code := rpc.ErrorCode(err); code != rpc.CodePanelNotFound {
+		t.Errorf("unknown panel error code = %d, want %d", code, rpc.CodePanelNotFound)
+	}
+
+	_, err = h.handlePrismRestart(ctx, &rpc.PanelPrismRequest{Panel: "bar"})
+	if code := rpc.ErrorCode(err); code != rpc.CodeInvalidParams {
+		t.Errorf("missing name error code = %d, want %d", code, rpc.CodeInvalidParams)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+const defaultRegisterTimeout = 5 * time.Second
+
+// RegisterTimeoutFromConfig resolves [core] register_timeout
+func RegisterTimeoutFromConfig(core *config.CoreConfig) (time.Duration, error) {
+	if core == nil || core.RegisterTimeout == "" {
+		return defaultRegisterTimeout, nil
+	}
+	return time.ParseDuration(core.RegisterTimeout)
+}
+
+// SetRegisterTimeout sets how long a spawn waits for prismctl's panel/register
+func (pm *PanelManager) SetRegisterTimeout(d time.Duration) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.registerTimeout = d
+}
+
+// expectRegistration records a pending spawn so that panel/register for the
+// instance can be matched to it
+func (pm *PanelManager) expectRegistration(instance string) chan *rpc.PanelRegisterRequest {
+	pm.pendingMu.Lock()
+	defer pm.pendingMu.Unlock()
+
+	ch := make(chan *rpc.PanelRegisterRequest, 1)
+	pm.pending[instance] = ch
+	return ch
+}
+
+// cancelRegistration drops a pending spawn once it has completed or timed out
+func (pm *PanelManager) cancelRegistration(instance string, ch chan *rpc.PanelRegisterRequest) {
+	pm.pendingMu.Lock()
+	defer pm.pendingMu.Unlock()
+
+	if pm.pending[instance] == ch {
+		delete(pm.pending, instance)
+	}
+}
+
+// Register completes the pending spawn for req.Instance. Does not take pm.mu,
+// which the spawning goroutine holds while it waits.
+func (pm *PanelManager) Register(req *rpc.PanelRegisterRequest) error {
+	pm.pendingMu.Lock()
+	defer pm.pendingMu.Unlock()
+
+	ch, ok := pm.pending[req.Instance]
+	if !ok {
+		return fmt.Errorf("no pending spawn for panel %s", req.Instance)
+	}
+	delete(pm.pending, req.Instance)
+
+	ch <- req
+	return nil
+}
+
+func (h *Handlers) handlePanelRegister(ctx context.Context, req *rpc.PanelRegisterRequest) (*rpc.PanelRegisterResult, error) {
+	if req.Instance == "" || req.Socket == "" || req.PID <= 0 {
+		return nil, rpc.ErrInvalidParams("instance, pid and socket are required")
+	}
+
+	log.Printf("panel/register: %s (PID %d, window %s, socket %s)", req.Instance, req.PID, req.WindowID, req.Socket)
+
+	if err := h.pm.Register(req); err != nil {
+		log.Printf("panel/register: %v", err)
+		return &rpc.PanelRegisterResult{Registered: false}, nil
+	}
+
+	return &rpc.PanelRegisterResult{Registered: true}, nil
+}
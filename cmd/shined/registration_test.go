@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/starbased-co/shine/pkg/config"
+	"github.com/starbased-co/shine/pkg/rpc"
+)
+
+func TestRegister_MatchesPendingSpawn(t *testing.T) {
+	pm := newTestPanelManager()
+
+	if err := pm.Register(&rpc.PanelRegisterRequest{Instance: "clock", PID: 42}); err == nil {
+		t.Error("Register() without a pending spawn should fail")
+	}
+
+	ch := pm.expectRegistration("clock")
+	defer pm.cancelRegistration("clock", ch)
+
+	req := &rpc.PanelRegisterRequest{Instance: "clock", PID: 42, WindowID: "7", Socket: "/tmp/clock.sock"}
+	if err := pm.Register(req); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+
+	select {
+	case got := <-ch:
+		if got.PID != 42 || got.WindowID != "7" {
+			t.Errorf("registration = %+v, want PID 42 window 7", got)
+		}
+	default:
+		t.Fatal("registration not delivered to pending spawn")
+	}
+
+	if err := pm.Register(req); err == nil {
+		t.Error("second Register() for the same spawn should fail")
+	}
+}
+
+func TestCancelRegistration_KeepsNewerSpawn(t *testing.T) {
+	pm := newTestPanelManager()
+
+	stale := pm.expectRegistration("clock")
+	fresh := pm.expectRegistration("clock")
+	pm.cancelRegistration("clock", stale)
+
+	if err := pm.Register(&rpc.PanelRegisterRequest{Instance: "clock", PID: 1, Socket: "/s"}); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	if len(fresh) != 1 {
+		t.Error("registration not delivered to the newer pending spawn")
+	}
+}
+
+func TestHandlePanelRegister(t *testing.T) {
+	pm := newTestPanelManager()
+	h := &Handlers{pm: pm}
+
+	sockPath := filepath.Join(t.TempDir(), "shine.sock")
+	srv := rpc.NewServer(sockPath, handler.Map{
+		"panel/register": rpc.Handler(h.handlePanelRegister),
+	}, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer srv.Stop(context.Background())
+
+	client, err := rpc.NewShinedClient(sockPath)
+	if err != nil {
+		t.Fatalf("NewShinedClient() error: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+
+	if _, err := client.RegisterPanel(ctx, &rpc.PanelRegisterRequest{Instance: "clock"}); err == nil {
+		t.Error("RegisterPanel() without pid/socket should fail")
+	}
+
+	result, err := client.RegisterPanel(ctx, &rpc.PanelRegisterRequest{Instance: "clock", PID: 42, Socket: "/s"})
+	if err != nil {
+		t.Fatalf("RegisterPanel() error: %v", err)
+	}
+	if result.Registered {
+		t.Error("Registered = true for an unexpected instance")
+	}
+
+	ch := pm.expectRegistration("clock")
+	result, err = client.RegisterPanel(ctx, &rpc.PanelRegisterRequest{Instance: "clock", PID: 42, Socket: "/s"})
+	if err != nil {
+		t.Fatalf("RegisterPanel() error: %v", err)
+	}
+	if !result.Registered {
+		t.Error("Registered = false for a pending spawn")
+	}
+	if len(ch) != 1 {
+		t.Error("registration not delivered")
+	}
+}
+
+func TestRegisterTimeoutFromConfig(t *testing.T) {
+	d, err := RegisterTimeoutFromConfig(nil)
+	if err != nil || d != defaultRegisterTimeout {
+		t.Errorf("nil core = %v, %v; want %v", d, err, defaultRegisterTimeout)
+	}
+
+	d, err = RegisterTimeoutFromConfig(&config.CoreConfig{RegisterTimeout: "15s"})
+	if err != nil || d != 15*time.Second {
+		t.Errorf("register_timeout=15s = %v, %v", d, err)
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/starbased-co/shine/pkg/paths"
@@ -11,6 +13,10 @@ import (
 type StateManager struct {
 	writer    *state.ShinedStateWriter
 	startTime time.Time
+
+	mu            sync.Mutex
+	starting      bool     // configured panels are still being spawned
+	startupErrors []string // panels that failed to spawn at startup
 }
 
 func newStateManager() (*StateManager, error) {
@@ -22,6 +28,7 @@ func newStateManager() (*StateManager, error) {
 	return &StateManager{
 		writer:    writer,
 		startTime: time.Now(),
+		starting:  true,
 	}, nil
 }
 
@@ -36,6 +43,15 @@ func (sm *StateManager) OnPanelKilled(instance string) {
 	sm.writer.RemovePanel(instance)
 }
 
+func (sm *StateManager) OnPanelExited(instance, reason string) {
+	log.Printf("State: panel %s exited (%s)", instance, reason)
+	sm.writer.RemovePanel(instance)
+}
+
+func (sm *StateManager) OnPanelRespawned(panel *Panel) {
+	sm.OnPanelSpawned(panel.Instance, panel.Name, panel.PID, true)
+}
+
 func (sm *StateManager) OnPanelHealthChanged(instance string, healthy bool) {
 	sm.writer.SetPanelHealth(instance, healthy)
 }
@@ -50,16 +66,40 @@ func (sm *StateManager) OnPanelPrismStopped(panel, name string, exitCode int) {
 	// Future: could update prism state in panel metadata
 }
 
-func (sm *StateManager) OnPanelPrismCrashed(panel, name string, exitCode, signal int) {
-	log.Printf("State: panel %s - prism crashed: %s (exit=%d, signal=%d)", panel, name, exitCode, signal)
+func (sm *StateManager) OnPanelPrismCrashed(panel, name string, exitCode, signal int, reason string) {
+	log.Printf("State: panel %s - prism crashed: %s (exit=%d, signal=%d, reason=%q)", panel, name, exitCode, signal, reason)
 	// Future: could trigger restart policy or mark panel unhealthy
 }
 
+func (sm *StateManager) OnPanelPrismHealthChanged(panel, name, status, message string) {
+	log.Printf("State: panel %s - prism health: %s is %s (%s)", panel, name, status, message)
+}
+
 func (sm *StateManager) OnPanelForegroundChanged(panel, from, to string) {
 	log.Printf("State: panel %s - foreground changed: %s → %s", panel, from, to)
 	// Future: could track current foreground prism in panel metadata
 }
 
+// OnStartupComplete records that the configured panels have been spawned.
+// err joins the failures, one per line.
+func (sm *StateManager) OnStartupComplete(err error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.starting = false
+	if err != nil {
+		sm.startupErrors = strings.Split(err.Error(), "\n")
+	}
+}
+
+// Startup reports whether shined is still spawning its configured panels
+// and which failed to spawn
+func (sm *StateManager) Startup() (starting bool, errors []string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.starting, sm.startupErrors
+}
+
 func (sm *StateManager) Uptime() time.Duration {
 	return time.Since(sm.startTime)
 }
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dependencies returns the prisms pc starts after: the union of after,
+// wants and requires, sorted and without duplicates
+func (pc *PrismConfig) Dependencies() []string {
+	seen := make(map[string]bool)
+	var deps []string
+	for _, list := range [][]string{pc.After, pc.Wants, pc.Requires} {
+		for _, name := range list {
+			if !seen[name] {
+				seen[name] = true
+				deps = append(deps, name)
+			}
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// PulledIn returns the prisms pc starts along with itself: wants and
+// requires, which apply even if those prisms are not enabled
+func (pc *PrismConfig) PulledIn() []string {
+	return append(append([]string(nil), pc.Wants...), pc.Requires...)
+}
+
+// DependencyOrder sorts prisms, keyed by name, so that every prism comes
+// after its dependencies. Unrelated prisms are ordered by name, so the
+// result is stable. Dependencies on names outside prisms are ignored.
+// Returns an error naming the cycle if there is one.
+func DependencyOrder(prisms map[string]*PrismConfig) ([]string, error) {
+	names := make([]string, 0, len(prisms))
+	for name := range prisms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(prisms))
+	order := make([]string, 0, len(prisms))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for i, n := range path {
+				if n == name {
+					start = i
+				}
+			}
+			cycle := append(append([]string(nil), path[start:]...), name)
+			return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range prisms[name].Dependencies() {
+			if _, ok := prisms[dep]; !ok {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// validateDependencies checks that dependencies name configured prisms and
+// form no cycle. prisms is keyed by prism name.
+func validateDependencies(prisms map[string]*PrismConfig) error {
+	names := make([]string, 0, len(prisms))
+	for name := range prisms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pc := prisms[name]
+		fields := []struct {
+			key  string
+			deps []string
+		}{{"after", pc.After}, {"wants", pc.Wants}, {"requires", pc.Requires}}
+
+		for _, field := range fields {
+			for _, dep := range field.deps {
+				if dep == name {
+					return fmt.Errorf("prism %q: %s: cannot depend on itself", name, field.key)
+				}
+				if _, ok := prisms[dep]; !ok {
+					return fmt.Errorf("prism %q: %s: unknown prism %q", name, field.key, dep)
+				}
+			}
+		}
+	}
+
+	_, err := DependencyOrder(prisms)
+	return err
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDependencyOrder(t *testing.T) {
+	prisms := map[string]*PrismConfig{
+		"bar":    {Name: "bar", Requires: []string{"daemon"}},
+		"clock":  {Name: "clock", After: []string{"bar"}, Wants: []string{"weather"}},
+		"daemon": {Name: "daemon"},
+		"alpha":  {Name: "alpha"},
+		// Dependencies outside the set are ignored
+		"weather": {Name: "weather", After: []string{"network"}},
+	}
+
+	got, err := DependencyOrder(prisms)
+	if err != nil {
+		t.Fatalf("DependencyOrder() error: %v", err)
+	}
+
+	want := []string{"alpha", "daemon", "bar", "weather", "clock"}
+	if !slices.Equal(got, want) {
+		t.Errorf("DependencyOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestDependencyOrder_Cycle(t *testing.T) {
+	prisms := map[string]*PrismConfig{
+		"a": {Name: "a", After: []string{"b"}},
+		"b": {Name: "b", Wants: []string{"c"}},
+		"c": {Name: "c", Requires: []string{"a"}},
+	}
+
+	_, err := DependencyOrder(prisms)
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("DependencyOrder() error = %v, want the cycle a -> b -> c -> a", err)
+	}
+}
+
+func TestConfig_ValidateDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		prisms  map[string]*PrismConfig
+		wantErr string
+	}{
+		{
+			name: "valid",
+			prisms: map[string]*PrismConfig{
+				"bar":   {Name: "bar", Requires: []string{"clock"}},
+				"clock": {Name: "clock"},
+			},
+		},
+		{
+			name: "unknown prism",
+			prisms: map[string]*PrismConfig{
+				"bar": {Name: "bar", Wants: []string{"tray"}},
+			},
+			wantErr: `wants: unknown prism "tray"`,
+		},
+		{
+			name: "self",
+			prisms: map[string]*PrismConfig{
+				"bar": {Name: "bar", After: []string{"bar"}},
+			},
+			wantErr: "after: cannot depend on itself",
+		},
+		{
+			name: "cycle",
+			prisms: map[string]*PrismConfig{
+				"bar":   {Name: "bar", Requires: []string{"clock"}},
+				"clock": {Name: "clock", After: []string{"bar"}},
+			},
+			wantErr: "dependency cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Core: &CoreConfig{}, Prisms: tt.prisms}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
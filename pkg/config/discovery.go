@@ -71,6 +71,16 @@ func DiscoverPrisms(prismDirs []string, extraPaths []string) (map[string]*Discov
 	return discovered, nil
 }
 
+// LoadPrismDir loads the prism in a directory with a prism.toml, resolving
+// its binaries as discovery does
+func LoadPrismDir(dir string) (*DiscoveredPrism, error) {
+	dir, err := filepath.Abs(paths.ExpandHome(dir))
+	if err != nil {
+		return nil, err
+	}
+	return discoverDirectoryPrism(filepath.Dir(dir), filepath.Base(dir), nil)
+}
+
 // discoverDirectoryPrism handles directory with prism.toml.
 // Binary resolution: checks prism directory first, then falls back to PATH.
 func discoverDirectoryPrism(baseDir, dirName string, extraPaths []string) (*DiscoveredPrism, error) {
@@ -199,6 +209,16 @@ func MergePrismConfigs(prismSource, userConfig *PrismConfig) *PrismConfig {
 		merged.Apps = prismSource.Apps
 	}
 
+	merged.Order = prismSource.Order
+	if userConfig.Order != nil {
+		merged.Order = userConfig.Order
+	}
+
+	merged.Default = prismSource.Default
+	if userConfig.Default != "" {
+		merged.Default = userConfig.Default
+	}
+
 	merged.Enabled = userConfig.Enabled || prismSource.Enabled
 
 	merged.Origin = prismSource.Origin
@@ -236,7 +256,58 @@ func MergePrismConfigs(prismSource, userConfig *PrismConfig) *PrismConfig {
 		merged.OutputName = userConfig.OutputName
 	}
 
-	// Metadata from user config is intentionally skipped
+	merged.Args = prismSource.Args
+	if userConfig.Args != nil {
+		merged.Args = userConfig.Args
+	}
+
+	merged.Env = prismSource.Env
+	if userConfig.Env != nil {
+		merged.Env = userConfig.Env
+	}
+
+	merged.After = prismSource.After
+	if userConfig.After != nil {
+		merged.After = userConfig.After
+	}
+
+	merged.Wants = prismSource.Wants
+	if userConfig.Wants != nil {
+		merged.Wants = userConfig.Wants
+	}
+
+	merged.Requires = prismSource.Requires
+	if userConfig.Requires != nil {
+		merged.Requires = userConfig.Requires
+	}
+
+	merged.Instances = prismSource.Instances
+	if userConfig.Instances != nil {
+		merged.Instances = userConfig.Instances
+	}
+
+	merged.Health = prismSource.Health
+	if userConfig.Health != nil {
+		merged.Health = userConfig.Health
+	}
+
+	merged.Ready = prismSource.Ready
+	if userConfig.Ready != nil {
+		merged.Ready = userConfig.Ready
+	}
+
+	merged.Sandbox = prismSource.Sandbox
+	if userConfig.Sandbox != nil {
+		merged.Sandbox = userConfig.Sandbox
+	}
+
+	merged.Idle = prismSource.Idle
+	if userConfig.Idle != nil {
+		merged.Idle = userConfig.Idle
+	}
+
+	// Dev and metadata from user config are intentionally skipped
+	merged.Dev = prismSource.Dev
 	merged.Metadata = prismSource.Metadata
 	merged.ResolvedPath = prismSource.ResolvedPath
 
@@ -67,6 +67,44 @@ license = "MIT"
 	}
 }
 
+func TestLoadPrismDir(t *testing.T) {
+	prismDir := filepath.Join(t.TempDir(), "clock")
+	if err := os.Mkdir(prismDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := `name = "clock"
+
+[dev]
+build = "go build -o shine-clock ."
+watch = ["*.go"]
+`
+	if err := os.WriteFile(filepath.Join(prismDir, "prism.toml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	binary := filepath.Join(prismDir, "shine-clock")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	prism, err := LoadPrismDir(prismDir)
+	if err != nil {
+		t.Fatalf("LoadPrismDir() error: %v", err)
+	}
+	if prism.Config.ResolvedPath != binary {
+		t.Errorf("ResolvedPath = %q, want %q", prism.Config.ResolvedPath, binary)
+	}
+	if dev := prism.Config.Dev; dev == nil || dev.Build != "go build -o shine-clock ." || len(dev.Watch) != 1 {
+		t.Errorf("Dev = %+v, want build and watch from prism.toml", dev)
+	}
+
+	// Dev settings only come from the prism source
+	merged := MergePrismConfigs(prism.Config, &PrismConfig{Dev: &DevConfig{Build: "make"}})
+	if merged.Dev.Build != "go build -o shine-clock ." {
+		t.Errorf("merged Dev.Build = %q, want the prism source's", merged.Dev.Build)
+	}
+}
+
 func TestDiscoverStandalonePrism(t *testing.T) {
 	// Create temp directory
 	tmpDir := t.TempDir()
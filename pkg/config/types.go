@@ -1,6 +1,11 @@
 package config
 
-import "github.com/starbased-co/shine/pkg/panel"
+import (
+	"fmt"
+	"sort"
+
+	"github.com/starbased-co/shine/pkg/panel"
+)
 
 type AppConfig struct {
 	// Path specifies the binary name or path
@@ -10,10 +15,54 @@ type AppConfig struct {
 	// Enabled controls whether this app should be launched
 	Enabled bool `toml:"enabled"`
 
+	// Health configures liveness checks for this app (optional)
+	Health *HealthCheckConfig `toml:"health,omitempty"`
+
+	// Ready makes this app signal when it has finished starting (optional)
+	Ready *ReadyConfig `toml:"ready,omitempty"`
+
+	// Lazy registers this app at startup but only launches it on its first
+	// prism/fg
+	Lazy bool `toml:"lazy,omitempty"`
+
+	// Env sets environment variables for this app, overriding the prism's
+	// (optional)
+	Env map[string]string `toml:"env,omitempty"`
+
 	// ResolvedPath is set during discovery (not from TOML)
 	ResolvedPath string `toml:"-"`
 }
 
+// HealthCheckConfig defines how prismctl decides whether an app is alive.
+// All configured checks must pass. Checks only run while the app is in the
+// foreground, since background apps are suspended.
+type HealthCheckConfig struct {
+	Command      string `toml:"command,omitempty"`       // Shell command, exit 0 = healthy
+	File         string `toml:"file,omitempty"`          // Liveness file the app touches periodically
+	MaxAge       string `toml:"max_age,omitempty"`       // Max age of file's mtime (default: 3 × interval)
+	OutputWithin string `toml:"output_within,omitempty"` // PTY must have produced output within this duration
+
+	Interval string `toml:"interval,omitempty"` // Time between checks (default: 10s)
+	Timeout  string `toml:"timeout,omitempty"`  // Command timeout (default: 5s)
+	Retries  int    `toml:"retries,omitempty"`  // Consecutive failures before unhealthy (default: 3)
+	Restart  bool   `toml:"restart,omitempty"`  // Restart the app when it becomes unhealthy
+}
+
+// ReadyConfig makes an app signal readiness instead of counting as started
+// as soon as it is launched. Dependents, prism/configure and shine start
+// wait for the signal; not signalling within the timeout is a failed start.
+type ReadyConfig struct {
+	Type    string `toml:"type"`              // "notify" (sd_notify READY=1) or "osc" (terminal escape)
+	Timeout string `toml:"timeout,omitempty"` // Time allowed to become ready (default: 30s)
+}
+
+// DevConfig tells `shine dev` how to rebuild a prism from source. Without
+// it, shine dev only watches the prism's binary.
+type DevConfig struct {
+	Build string   `toml:"build,omitempty"` // Shell command run in the prism directory on source changes
+	Watch []string `toml:"watch,omitempty"` // Globs of source files to watch (default: all but hidden files)
+}
+
 type Config struct {
 	Core   *CoreConfig             `toml:"core"`
 	Prisms map[string]*PrismConfig `toml:"prisms"`
@@ -24,6 +73,31 @@ type CoreConfig struct {
 	// Can be a single string or array of strings
 	// Example: "~/.local/share/shine/bin" or ["~/.local/share/shine/bin", "~/.config/shine/bin"]
 	Path interface{} `toml:"path"`
+
+	// Health configures shined's panel health monitor
+	Health *PanelHealthConfig `toml:"health"`
+
+	// RegisterTimeout bounds how long shined waits for a newly spawned
+	// prismctl to register itself (default: 5s)
+	RegisterTimeout string `toml:"register_timeout,omitempty"`
+
+	// Backend selects where panels run: "kitty" layer-shell panels (default)
+	// or "local" pseudo-terminals for machines without Wayland or kitty
+	Backend string `toml:"backend,omitempty"`
+
+	// MetricsSocket, if set, is a unix socket on which shined serves its
+	// metrics and those of every panel in the OpenMetrics text format
+	// Example: "$XDG_RUNTIME_DIR/shine/metrics.sock"
+	MetricsSocket string `toml:"metrics_socket,omitempty"`
+}
+
+// PanelHealthConfig controls how shined checks that each panel's prismctl
+// is responsive. A panel disconnecting its socket is detected immediately;
+// these settings govern the periodic backstop checks.
+type PanelHealthConfig struct {
+	Interval         string `toml:"interval,omitempty"`          // Time between checks (default: 30s)
+	Timeout          string `toml:"timeout,omitempty"`           // Per-check RPC timeout (default: 2s)
+	FailureThreshold int    `toml:"failure_threshold,omitempty"` // Consecutive failures before the panel is treated as crashed (default: 1)
 }
 
 func (cc *CoreConfig) GetPaths() []string {
@@ -69,6 +143,14 @@ type PrismConfig struct {
 	// The key is the app name, value is the app configuration
 	Apps map[string]*AppConfig `toml:"apps,omitempty"`
 
+	// Order lists app names in launch order (optional). Apps not listed
+	// launch after those that are, by name. See AppOrder.
+	Order []string `toml:"order,omitempty"`
+
+	// Default names the app in the foreground once the prism has started
+	// (optional). Defaults to the first app in launch order that is not lazy.
+	Default string `toml:"default,omitempty"`
+
 	// === Runtime State ===
 	// Enabled controls whether this prism should be launched
 	Enabled bool `toml:"enabled"`
@@ -84,6 +166,50 @@ type PrismConfig struct {
 	FocusPolicy     string `toml:"focus_policy,omitempty"`
 	OutputName      string `toml:"output_name,omitempty"`
 
+	// Args are passed to every app of this prism (optional)
+	Args []string `toml:"args,omitempty"`
+
+	// Env sets environment variables for every app of this prism (optional)
+	Env map[string]string `toml:"env,omitempty"`
+
+	// === Dependencies ===
+	// Prism names this prism starts after. wants and requires also start
+	// those prisms, even if not enabled; requires additionally stops this
+	// prism when they fail for good. See Dependencies and DependencyOrder.
+	After    []string `toml:"after,omitempty"`
+	Wants    []string `toml:"wants,omitempty"`
+	Requires []string `toml:"requires,omitempty"`
+
+	// === Instances ===
+	// Instances runs this prism as several panels (optional). Either a list
+	// of names (instances = ["left", "right"]) or [[prisms.*.instances]]
+	// tables overriding layout and args per instance. See GetInstances.
+	Instances interface{} `toml:"instances,omitempty"`
+
+	// === Health ===
+	// Health configures liveness checks for single-app prisms (optional)
+	// Multi-app prisms configure checks per app in [prisms.*.apps.*.health]
+	Health *HealthCheckConfig `toml:"health,omitempty"`
+
+	// === Readiness ===
+	// Ready makes single-app prisms signal when they have finished starting
+	// (optional). Multi-app prisms configure it per app in [prisms.*.apps.*.ready]
+	Ready *ReadyConfig `toml:"ready,omitempty"`
+
+	// === Sandbox ===
+	// Sandbox restricts resources and privileges of this prism's apps (optional)
+	Sandbox *SandboxConfig `toml:"sandbox,omitempty"`
+
+	// === Idle Screen ===
+	// Idle is shown when the foreground app is sent to the background without
+	// bringing another app forward (optional)
+	Idle *IdleConfig `toml:"idle,omitempty"`
+
+	// === Development (ONLY meaningful in prism sources) ===
+	// Dev configures `shine dev` for this prism (optional). Like Metadata it
+	// always comes from the prism source during merge.
+	Dev *DevConfig `toml:"dev,omitempty"`
+
 	// === Metadata (ONLY meaningful in prism sources) ===
 	// Metadata contains prism-specific information like description, author, license, etc.
 	// During merge, metadata ALWAYS comes from prism source (prism.toml, standalone .toml).
@@ -96,6 +222,32 @@ type PrismConfig struct {
 	ResolvedPath string `toml:"-"`
 }
 
+// SandboxConfig restricts the resources and privileges of a prism's apps.
+// Applied by prismctl when launching each app; unset fields impose no limit.
+type SandboxConfig struct {
+	// === Resource Limits (setrlimit) ===
+	Memory    string `toml:"memory,omitempty"`     // RLIMIT_AS, size with K/M/G suffix (e.g., "512M")
+	CPUTime   string `toml:"cpu_time,omitempty"`   // RLIMIT_CPU, duration string (e.g., "10m")
+	OpenFiles int    `toml:"open_files,omitempty"` // RLIMIT_NOFILE
+
+	// === cgroup v2 ===
+	MemoryMax string `toml:"memory_max,omitempty"` // memory.max, size with K/M/G suffix
+	CPUMax    string `toml:"cpu_max,omitempty"`    // cpu.max as a percentage of one CPU (e.g., "50%")
+
+	// === Privileges & Namespaces ===
+	NoNewPrivs    bool     `toml:"no_new_privs,omitempty"`   // PR_SET_NO_NEW_PRIVS
+	NoNetwork     bool     `toml:"no_network,omitempty"`     // Private network namespace (loopback only)
+	ReadOnlyHome  bool     `toml:"read_only_home,omitempty"` // Mount $HOME read-only
+	WritablePaths []string `toml:"writable_paths,omitempty"` // Paths kept writable when read_only_home is set
+}
+
+// IdleConfig selects what a panel shows while no app is in the foreground.
+// App takes precedence over Text; with neither set the panel is blank.
+type IdleConfig struct {
+	Text string `toml:"text,omitempty"` // Message printed on the idle screen
+	App  string `toml:"app,omitempty"`  // Binary name or path run as the idle screen
+}
+
 func (pc *PrismConfig) IsMultiApp() bool {
 	return len(pc.Apps) > 0
 }
@@ -113,6 +265,8 @@ func (pc *PrismConfig) GetApps() map[string]*AppConfig {
 			name: {
 				Path:         pc.Path,
 				Enabled:      true,
+				Health:       pc.Health,
+				Ready:        pc.Ready,
 				ResolvedPath: pc.ResolvedPath,
 			},
 		}
@@ -120,6 +274,215 @@ func (pc *PrismConfig) GetApps() map[string]*AppConfig {
 	return nil
 }
 
+// AppEnv returns the extra environment for an app as sorted KEY=VALUE
+// entries: the prism's Env overridden by the app's own
+func (pc *PrismConfig) AppEnv(name string) []string {
+	env := make(map[string]string, len(pc.Env))
+	for k, v := range pc.Env {
+		env[k] = v
+	}
+	if app := pc.GetApps()[name]; app != nil {
+		for k, v := range app.Env {
+			env[k] = v
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+
+	entries := make([]string, 0, len(env))
+	for k, v := range env {
+		entries = append(entries, k+"="+v)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// AppOrder returns the names from GetApps in launch order: those listed in
+// Order first, then the rest by name
+func (pc *PrismConfig) AppOrder() []string {
+	apps := pc.GetApps()
+
+	names := make([]string, 0, len(apps))
+	listed := make(map[string]bool, len(pc.Order))
+	for _, name := range pc.Order {
+		if _, ok := apps[name]; ok && !listed[name] {
+			listed[name] = true
+			names = append(names, name)
+		}
+	}
+
+	rest := make([]string, 0, len(apps))
+	for name := range apps {
+		if !listed[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(names, rest...)
+}
+
+// DefaultApp returns the app to bring to the foreground once the prism has
+// started: Default if set, otherwise the first enabled app in launch order
+// that is not lazy. Returns an empty string if there is none.
+func (pc *PrismConfig) DefaultApp() string {
+	if pc.Default != "" {
+		return pc.Default
+	}
+	apps := pc.GetApps()
+	for _, name := range pc.AppOrder() {
+		if app := apps[name]; app != nil && app.Enabled && !app.Lazy {
+			return name
+		}
+	}
+	return ""
+}
+
+// InstanceConfig is one panel of a multi-instance prism. Empty fields
+// inherit the prism's settings.
+type InstanceConfig struct {
+	Name       string      `toml:"name"`
+	Origin     string      `toml:"origin,omitempty"`
+	Position   string      `toml:"position,omitempty"`
+	Width      interface{} `toml:"width,omitempty"`
+	Height     interface{} `toml:"height,omitempty"`
+	OutputName string      `toml:"output_name,omitempty"`
+	Args       []string    `toml:"args,omitempty"`
+}
+
+// InstanceName is the panel instance name of a prism's named instance
+func InstanceName(prism, instance string) string {
+	return prism + ":" + instance
+}
+
+// GetInstances returns normalized instance configurations, or nil for a
+// prism that runs as a single panel.
+// instances = ["left", "right"] yields instances with only names set.
+func (pc *PrismConfig) GetInstances() ([]*InstanceConfig, error) {
+	if pc.Instances == nil {
+		return nil, nil
+	}
+
+	var items []interface{}
+	switch v := pc.Instances.(type) {
+	case []interface{}:
+		items = v
+	case []map[string]interface{}:
+		for _, m := range v {
+			items = append(items, m)
+		}
+	case []string:
+		for _, name := range v {
+			items = append(items, name)
+		}
+	case []*InstanceConfig:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("instances must be a list of names or tables, got %T", pc.Instances)
+	}
+
+	instances := make([]*InstanceConfig, 0, len(items))
+	for i, item := range items {
+		switch v := item.(type) {
+		case string:
+			instances = append(instances, &InstanceConfig{Name: v})
+		case map[string]interface{}:
+			inst, err := instanceFromTable(v)
+			if err != nil {
+				return nil, fmt.Errorf("instances[%d]: %w", i, err)
+			}
+			instances = append(instances, inst)
+		default:
+			return nil, fmt.Errorf("instances[%d]: must be a name or table, got %T", i, item)
+		}
+	}
+	return instances, nil
+}
+
+func instanceFromTable(table map[string]interface{}) (*InstanceConfig, error) {
+	inst := &InstanceConfig{}
+
+	str := func(key string, v interface{}) (string, error) {
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("%s must be a string, got %T", key, v)
+		}
+		return s, nil
+	}
+
+	var err error
+	for key, v := range table {
+		switch key {
+		case "name":
+			inst.Name, err = str(key, v)
+		case "origin":
+			inst.Origin, err = str(key, v)
+		case "position":
+			inst.Position, err = str(key, v)
+		case "output_name":
+			inst.OutputName, err = str(key, v)
+		case "width":
+			inst.Width = v
+		case "height":
+			inst.Height = v
+		case "args":
+			inst.Args, err = stringList(key, v)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inst, nil
+}
+
+func stringList(key string, v interface{}) ([]string, error) {
+	switch list := v.(type) {
+	case []string:
+		return list, nil
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of strings, got %T", key, item)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%s must be a list of strings, got %T", key, v)
+}
+
+// ForInstance returns a copy of the prism with inst's overrides applied and
+// no instances of its own
+func (pc *PrismConfig) ForInstance(inst *InstanceConfig) *PrismConfig {
+	merged := *pc
+	merged.Instances = nil
+
+	if inst.Origin != "" {
+		merged.Origin = inst.Origin
+	}
+	if inst.Position != "" {
+		merged.Position = inst.Position
+	}
+	if inst.Width != nil {
+		merged.Width = inst.Width
+	}
+	if inst.Height != nil {
+		merged.Height = inst.Height
+	}
+	if inst.OutputName != "" {
+		merged.OutputName = inst.OutputName
+	}
+	if inst.Args != nil {
+		merged.Args = inst.Args
+	}
+	return &merged
+}
+
 func (pc *PrismConfig) ToPanelConfig() *panel.Config {
 	cfg := panel.NewConfig()
 
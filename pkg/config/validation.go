@@ -2,27 +2,55 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/starbased-co/shine/pkg/panel"
 )
 
 func (c *Config) Validate() error {
-	seen := make(map[string]bool)
+	if c.Core != nil && c.Core.Health != nil {
+		if err := c.Core.Health.Validate(); err != nil {
+			return fmt.Errorf("core.health: %w", err)
+		}
+	}
+
+	if c.Core != nil && c.Core.RegisterTimeout != "" {
+		d, err := time.ParseDuration(c.Core.RegisterTimeout)
+		if err != nil {
+			return fmt.Errorf("core: invalid register_timeout %q: %w", c.Core.RegisterTimeout, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("core: invalid register_timeout %q: must be positive", c.Core.RegisterTimeout)
+		}
+	}
+
+	if c.Core != nil {
+		switch c.Core.Backend {
+		case "", "kitty", "local":
+		default:
+			return fmt.Errorf("core: invalid backend %q: must be \"kitty\" or \"local\"", c.Core.Backend)
+		}
+	}
+
+	byName := make(map[string]*PrismConfig)
 	for name, prism := range c.Prisms {
 		if prism.Name == "" {
 			return fmt.Errorf("prism %q: name is required", name)
 		}
-		if seen[prism.Name] {
+		if _, dup := byName[prism.Name]; dup {
 			return fmt.Errorf("prism %q: duplicate name", prism.Name)
 		}
-		seen[prism.Name] = true
+		byName[prism.Name] = prism
 
 		if err := prism.Validate(); err != nil {
 			return fmt.Errorf("prism %q: %w", name, err)
 		}
 	}
-	return nil
+
+	return validateDependencies(byName)
 }
 
 func (pc *PrismConfig) Validate() error {
@@ -37,6 +65,14 @@ func (pc *PrismConfig) Validate() error {
 		}
 	}
 
+	if err := pc.validateAppOrder(); err != nil {
+		return err
+	}
+
+	if err := validateEnv(pc.Env); err != nil {
+		return err
+	}
+
 	if pc.Origin != "" {
 		_ = panel.ParseOrigin(pc.Origin)
 	}
@@ -63,10 +99,322 @@ func (pc *PrismConfig) Validate() error {
 		_ = panel.ParseFocusPolicy(pc.FocusPolicy)
 	}
 
+	if pc.Health != nil {
+		if err := pc.Health.Validate(); err != nil {
+			return fmt.Errorf("health: %w", err)
+		}
+	}
+
+	if pc.Ready != nil {
+		if err := pc.Ready.Validate(); err != nil {
+			return fmt.Errorf("ready: %w", err)
+		}
+	}
+
+	instances, err := pc.GetInstances()
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, inst := range instances {
+		if err := inst.Validate(); err != nil {
+			return fmt.Errorf("instance %q: %w", inst.Name, err)
+		}
+		if seen[inst.Name] {
+			return fmt.Errorf("instance %q: duplicate name", inst.Name)
+		}
+		seen[inst.Name] = true
+	}
+
+	if pc.Sandbox != nil {
+		if err := pc.Sandbox.Validate(); err != nil {
+			return fmt.Errorf("sandbox: %w", err)
+		}
+	}
+
+	if pc.Dev != nil {
+		if err := pc.Dev.Validate(); err != nil {
+			return fmt.Errorf("dev: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (dc *DevConfig) Validate() error {
+	if len(dc.Watch) > 0 && dc.Build == "" {
+		return fmt.Errorf("watch requires build")
+	}
+	for _, pattern := range dc.Watch {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid watch pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks an instance's name and overrides. Names end up in socket
+// paths, so they may not contain '/' or ':'.
+func (ic *InstanceConfig) Validate() error {
+	if ic.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.ContainsAny(ic.Name, "/:") || strings.TrimSpace(ic.Name) != ic.Name {
+		return fmt.Errorf("invalid name: must not contain '/', ':' or surrounding spaces")
+	}
+
+	if ic.Position != "" {
+		if _, err := panel.ParsePosition(ic.Position); err != nil {
+			return fmt.Errorf("invalid position %q: %w", ic.Position, err)
+		}
+	}
+	if ic.Width != nil {
+		if _, err := panel.ParseDimension(ic.Width); err != nil {
+			return fmt.Errorf("invalid width %v: %w", ic.Width, err)
+		}
+	}
+	if ic.Height != nil {
+		if _, err := panel.ParseDimension(ic.Height); err != nil {
+			return fmt.Errorf("invalid height %v: %w", ic.Height, err)
+		}
+	}
+	return nil
+}
+
+func (sc *SandboxConfig) Validate() error {
+	if sc.Memory != "" {
+		if _, err := ParseSize(sc.Memory); err != nil {
+			return fmt.Errorf("invalid memory %q: %w", sc.Memory, err)
+		}
+	}
+
+	if sc.CPUTime != "" {
+		d, err := time.ParseDuration(sc.CPUTime)
+		if err != nil {
+			return fmt.Errorf("invalid cpu_time %q: %w", sc.CPUTime, err)
+		}
+		if d < time.Second {
+			return fmt.Errorf("invalid cpu_time %q: must be at least 1s", sc.CPUTime)
+		}
+	}
+
+	if sc.OpenFiles < 0 {
+		return fmt.Errorf("invalid open_files %d: must not be negative", sc.OpenFiles)
+	}
+
+	if sc.MemoryMax != "" {
+		if _, err := ParseSize(sc.MemoryMax); err != nil {
+			return fmt.Errorf("invalid memory_max %q: %w", sc.MemoryMax, err)
+		}
+	}
+
+	if sc.CPUMax != "" {
+		if _, err := ParseCPUPercent(sc.CPUMax); err != nil {
+			return fmt.Errorf("invalid cpu_max %q: %w", sc.CPUMax, err)
+		}
+	}
+
+	for _, p := range sc.WritablePaths {
+		if !filepath.IsAbs(p) && !strings.HasPrefix(p, "~") {
+			return fmt.Errorf("writable path %q must be absolute or start with ~", p)
+		}
+	}
+
+	return nil
+}
+
+// ParseSize parses a byte size with an optional binary K/M/G/T suffix (e.g., "512M")
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(str, "B")
+	str = strings.TrimSuffix(str, "I")
+
+	multiplier := int64(1)
+	if n := len(str); n > 0 {
+		switch str[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			str = str[:n-1]
+		}
+	}
+
+	value, err := strconv.ParseInt(strings.TrimSpace(str), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("size must be positive: %s", s)
+	}
+
+	return value * multiplier, nil
+}
+
+// ParseCPUPercent parses a CPU share as a percentage of one CPU (e.g., "50%" or "150")
+func ParseCPUPercent(s string) (int, error) {
+	str := strings.TrimSuffix(strings.TrimSpace(s), "%")
+	value, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage: %s", s)
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("percentage must be positive: %s", s)
+	}
+	return value, nil
+}
+
+// validateAppOrder checks that order and default name apps of a multi-app
+// prism
+func (pc *PrismConfig) validateAppOrder() error {
+	if !pc.IsMultiApp() {
+		if len(pc.Order) > 0 {
+			return fmt.Errorf("order requires apps")
+		}
+		if pc.Default != "" {
+			return fmt.Errorf("default requires apps")
+		}
+		return nil
+	}
+
+	seen := make(map[string]bool, len(pc.Order))
+	for _, name := range pc.Order {
+		if _, ok := pc.Apps[name]; !ok {
+			return fmt.Errorf("order: unknown app %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("order: duplicate app %q", name)
+		}
+		seen[name] = true
+	}
+
+	if pc.Default != "" {
+		app, ok := pc.Apps[pc.Default]
+		if !ok {
+			return fmt.Errorf("default: unknown app %q", pc.Default)
+		}
+		if !app.Enabled {
+			return fmt.Errorf("default: app %q is not enabled", pc.Default)
+		}
+	}
+
 	return nil
 }
 
 func (ac *AppConfig) Validate() error {
+	if ac.Health != nil {
+		if err := ac.Health.Validate(); err != nil {
+			return fmt.Errorf("health: %w", err)
+		}
+	}
+	if ac.Ready != nil {
+		if err := ac.Ready.Validate(); err != nil {
+			return fmt.Errorf("ready: %w", err)
+		}
+	}
+	return validateEnv(ac.Env)
+}
+
+// validateEnv checks that environment variable names are usable
+func validateEnv(env map[string]string) error {
+	for name := range env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("env: invalid variable name %q", name)
+		}
+	}
+	return nil
+}
+
+func (rc *ReadyConfig) Validate() error {
+	switch rc.Type {
+	case "notify", "osc":
+	case "":
+		return fmt.Errorf("type is required (notify or osc)")
+	default:
+		return fmt.Errorf("invalid type %q: must be notify or osc", rc.Type)
+	}
+
+	if rc.Timeout != "" {
+		parsed, err := time.ParseDuration(rc.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", rc.Timeout, err)
+		}
+		if parsed <= 0 {
+			return fmt.Errorf("invalid timeout %q: must be positive", rc.Timeout)
+		}
+	}
+
+	return nil
+}
+
+func (hc *HealthCheckConfig) Validate() error {
+	if hc.Command == "" && hc.File == "" && hc.OutputWithin == "" {
+		return fmt.Errorf("at least one of command, file or output_within is required")
+	}
+
+	durations := []struct {
+		field string
+		value string
+	}{
+		{"max_age", hc.MaxAge},
+		{"output_within", hc.OutputWithin},
+		{"interval", hc.Interval},
+		{"timeout", hc.Timeout},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", d.field, d.value, err)
+		}
+		if parsed <= 0 {
+			return fmt.Errorf("invalid %s %q: must be positive", d.field, d.value)
+		}
+	}
+
+	if hc.MaxAge != "" && hc.File == "" {
+		return fmt.Errorf("max_age requires file")
+	}
+
+	if hc.Retries < 0 {
+		return fmt.Errorf("invalid retries %d: must not be negative", hc.Retries)
+	}
+
+	return nil
+}
+
+func (ph *PanelHealthConfig) Validate() error {
+	for _, d := range []struct {
+		field string
+		value string
+	}{
+		{"interval", ph.Interval},
+		{"timeout", ph.Timeout},
+	} {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", d.field, d.value, err)
+		}
+		if parsed <= 0 {
+			return fmt.Errorf("invalid %s %q: must be positive", d.field, d.value)
+		}
+	}
+
+	if ph.FailureThreshold < 0 {
+		return fmt.Errorf("invalid failure_threshold %d: must not be negative", ph.FailureThreshold)
+	}
+
 	return nil
 }
 
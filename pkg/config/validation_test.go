@@ -0,0 +1,411 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoad_SandboxConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "test.toml")
+
+	configContent := `[prisms.weather]
+name = "weather"
+enabled = true
+
+[prisms.weather.sandbox]
+memory = "512M"
+cpu_time = "10m"
+open_files = 256
+memory_max = "128M"
+cpu_max = "50%"
+no_new_privs = true
+no_network = true
+read_only_home = true
+writable_paths = ["~/.cache/shine-weather"]
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	sb := cfg.Prisms["weather"].Sandbox
+	if sb == nil {
+		t.Fatal("Expected sandbox section to be loaded")
+	}
+
+	if sb.Memory != "512M" || sb.MemoryMax != "128M" || sb.CPUMax != "50%" {
+		t.Errorf("Unexpected limits: %+v", sb)
+	}
+
+	if sb.OpenFiles != 256 {
+		t.Errorf("Expected open_files=256, got %d", sb.OpenFiles)
+	}
+
+	if !sb.NoNewPrivs || !sb.NoNetwork || !sb.ReadOnlyHome {
+		t.Errorf("Expected all privilege flags set, got %+v", sb)
+	}
+
+	if len(sb.WritablePaths) != 1 || sb.WritablePaths[0] != "~/.cache/shine-weather" {
+		t.Errorf("Unexpected writable_paths: %v", sb.WritablePaths)
+	}
+}
+
+func TestSandboxConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sandbox SandboxConfig
+		wantErr bool
+	}{
+		{"empty", SandboxConfig{}, false},
+		{"valid limits", SandboxConfig{Memory: "1G", CPUTime: "30s", MemoryMax: "256M", CPUMax: "25%"}, false},
+		{"bad memory", SandboxConfig{Memory: "lots"}, true},
+		{"bad cpu_time", SandboxConfig{CPUTime: "soon"}, true},
+		{"sub-second cpu_time", SandboxConfig{CPUTime: "500ms"}, true},
+		{"negative open_files", SandboxConfig{OpenFiles: -1}, true},
+		{"bad cpu_max", SandboxConfig{CPUMax: "half"}, true},
+		{"relative writable path", SandboxConfig{WritablePaths: []string{"cache"}}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.sandbox.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHealthCheckConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		health  HealthCheckConfig
+		wantErr bool
+	}{
+		{"no checks", HealthCheckConfig{Interval: "5s"}, true},
+		{"command", HealthCheckConfig{Command: "true", Interval: "5s", Timeout: "1s"}, false},
+		{"file with max_age", HealthCheckConfig{File: "/tmp/hb", MaxAge: "30s"}, false},
+		{"output_within", HealthCheckConfig{OutputWithin: "1m"}, false},
+		{"max_age without file", HealthCheckConfig{Command: "true", MaxAge: "30s"}, true},
+		{"bad interval", HealthCheckConfig{Command: "true", Interval: "often"}, true},
+		{"zero timeout", HealthCheckConfig{Command: "true", Timeout: "0s"}, true},
+		{"negative retries", HealthCheckConfig{Command: "true", Retries: -1}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.health.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReadyConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		ready   ReadyConfig
+		wantErr bool
+	}{
+		{"notify", ReadyConfig{Type: "notify"}, false},
+		{"osc with timeout", ReadyConfig{Type: "osc", Timeout: "5s"}, false},
+		{"missing type", ReadyConfig{Timeout: "5s"}, true},
+		{"unknown type", ReadyConfig{Type: "pidfile"}, true},
+		{"bad timeout", ReadyConfig{Type: "notify", Timeout: "soon"}, true},
+		{"zero timeout", ReadyConfig{Type: "notify", Timeout: "0s"}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.ready.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"1024", 1024, false},
+		{"4K", 4 << 10, false},
+		{"512M", 512 << 20, false},
+		{"512MiB", 512 << 20, false},
+		{"2G", 2 << 30, false},
+		{"1gb", 1 << 30, false},
+		{"", 0, true},
+		{"0", 0, true},
+		{"-5M", 0, true},
+		{"M", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSize(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPanelHealthConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		health  PanelHealthConfig
+		wantErr bool
+	}{
+		{"empty", PanelHealthConfig{}, false},
+		{"valid", PanelHealthConfig{Interval: "5s", Timeout: "500ms", FailureThreshold: 3}, false},
+		{"bad interval", PanelHealthConfig{Interval: "soon"}, true},
+		{"zero timeout", PanelHealthConfig{Timeout: "0s"}, true},
+		{"negative threshold", PanelHealthConfig{FailureThreshold: -1}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.health.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	cfg := &Config{Core: &CoreConfig{Health: &PanelHealthConfig{Interval: "-1s"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Config.Validate() should reject invalid core.health")
+	}
+}
+
+func TestLoad_Instances(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "test.toml")
+
+	configContent := `[prisms.bar]
+name = "bar"
+enabled = true
+origin = "top-center"
+height = 1
+args = ["--compact"]
+
+[[prisms.bar.instances]]
+name = "left"
+output_name = "DP-1"
+
+[[prisms.bar.instances]]
+name = "right"
+output_name = "DP-2"
+origin = "bottom-center"
+height = "30px"
+args = ["--full"]
+
+[prisms.clock]
+name = "clock"
+enabled = true
+instances = ["utc", "local"]
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	bar, err := cfg.Prisms["bar"].GetInstances()
+	if err != nil {
+		t.Fatalf("GetInstances() error: %v", err)
+	}
+	if len(bar) != 2 || bar[0].Name != "left" || bar[1].Name != "right" {
+		t.Fatalf("bar instances = %+v", bar)
+	}
+
+	left := cfg.Prisms["bar"].ForInstance(bar[0])
+	if left.Origin != "top-center" || left.OutputName != "DP-1" || left.Args[0] != "--compact" || left.Instances != nil {
+		t.Errorf("left = %+v, want prism settings with output DP-1", left)
+	}
+
+	right := cfg.Prisms["bar"].ForInstance(bar[1])
+	if right.Origin != "bottom-center" || right.Height != "30px" || right.OutputName != "DP-2" || right.Args[0] != "--full" {
+		t.Errorf("right = %+v, want its overrides", right)
+	}
+
+	clock, err := cfg.Prisms["clock"].GetInstances()
+	if err != nil {
+		t.Fatalf("GetInstances() error: %v", err)
+	}
+	if len(clock) != 2 || clock[0].Name != "utc" || clock[1].Name != "local" {
+		t.Errorf("clock instances = %+v", clock)
+	}
+
+	if got := InstanceName("clock", "utc"); got != "clock:utc" {
+		t.Errorf("InstanceName() = %q, want %q", got, "clock:utc")
+	}
+}
+
+func TestPrismConfig_ValidateInstances(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances interface{}
+		wantErr   bool
+	}{
+		{"none", nil, false},
+		{"names", []interface{}{"a", "b"}, false},
+		{"duplicate", []interface{}{"a", "a"}, true},
+		{"empty name", []interface{}{""}, true},
+		{"colon in name", []interface{}{"a:b"}, true},
+		{"slash in name", []interface{}{"a/b"}, true},
+		{"not a list", "a", true},
+		{"unknown key", []map[string]interface{}{{"name": "a", "colour": "red"}}, true},
+		{"bad width", []map[string]interface{}{{"name": "a", "width": "wide"}}, true},
+		{"bad args", []map[string]interface{}{{"name": "a", "args": []interface{}{1}}}, true},
+	}
+
+	for _, tt := range tests {
+		pc := &PrismConfig{Name: "bar", Instances: tt.instances}
+		err := pc.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPrismConfig_AppOrder(t *testing.T) {
+	pc := &PrismConfig{
+		Name: "desk",
+		Apps: map[string]*AppConfig{
+			"chat":  {Enabled: true, Lazy: true},
+			"mail":  {Enabled: true},
+			"music": {Enabled: true},
+			"news":  {Enabled: true},
+		},
+		Order: []string{"chat", "news"},
+	}
+
+	want := []string{"chat", "news", "mail", "music"}
+	if got := pc.AppOrder(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("AppOrder() = %v, want %v", got, want)
+	}
+
+	// The first app in order that is not lazy
+	if got := pc.DefaultApp(); got != "news" {
+		t.Errorf("DefaultApp() = %q, want news", got)
+	}
+
+	pc.Default = "chat"
+	if got := pc.DefaultApp(); got != "chat" {
+		t.Errorf("DefaultApp() = %q, want chat", got)
+	}
+
+	single := &PrismConfig{Name: "clock", ResolvedPath: "/usr/bin/shine-clock"}
+	if got := single.AppOrder(); len(got) != 1 || got[0] != "clock" {
+		t.Errorf("single-app AppOrder() = %v, want [clock]", got)
+	}
+	if got := single.DefaultApp(); got != "clock" {
+		t.Errorf("single-app DefaultApp() = %q, want clock", got)
+	}
+}
+
+func TestPrismConfig_ValidateAppOrder(t *testing.T) {
+	apps := map[string]*AppConfig{
+		"chat": {Enabled: true},
+		"mail": {Enabled: false},
+	}
+
+	tests := []struct {
+		name    string
+		pc      PrismConfig
+		wantErr string
+	}{
+		{"valid", PrismConfig{Apps: apps, Order: []string{"mail", "chat"}, Default: "chat"}, ""},
+		{"unknown in order", PrismConfig{Apps: apps, Order: []string{"news"}}, `order: unknown app "news"`},
+		{"duplicate in order", PrismConfig{Apps: apps, Order: []string{"chat", "chat"}}, `order: duplicate app "chat"`},
+		{"unknown default", PrismConfig{Apps: apps, Default: "news"}, `default: unknown app "news"`},
+		{"disabled default", PrismConfig{Apps: apps, Default: "mail"}, "not enabled"},
+		{"order without apps", PrismConfig{Order: []string{"chat"}}, "order requires apps"},
+	}
+
+	for _, tt := range tests {
+		err := tt.pc.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: Validate() error: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Validate() error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPrismConfig_AppEnv(t *testing.T) {
+	pc := &PrismConfig{
+		Name: "desk",
+		Env:  map[string]string{"THEME": "dark", "LANG": "C"},
+		Apps: map[string]*AppConfig{
+			"mail": {Enabled: true, Env: map[string]string{"THEME": "light", "ACCOUNT": "work"}},
+			"chat": {Enabled: true},
+		},
+	}
+
+	if got, want := pc.AppEnv("mail"), []string{"ACCOUNT=work", "LANG=C", "THEME=light"}; !slices.Equal(got, want) {
+		t.Errorf("AppEnv(mail) = %v, want %v", got, want)
+	}
+	if got, want := pc.AppEnv("chat"), []string{"LANG=C", "THEME=dark"}; !slices.Equal(got, want) {
+		t.Errorf("AppEnv(chat) = %v, want %v", got, want)
+	}
+	if got := (&PrismConfig{Name: "bar", Path: "bar"}).AppEnv("bar"); got != nil {
+		t.Errorf("AppEnv() without env = %v, want nil", got)
+	}
+
+	pc.Apps["chat"].Env = map[string]string{"A=B": "c"}
+	if err := pc.Validate(); err == nil || !strings.Contains(err.Error(), "invalid variable name") {
+		t.Errorf("Validate() with a bad env name = %v, want invalid variable name", err)
+	}
+}
+
+func TestDevConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		dev     DevConfig
+		wantErr string
+	}{
+		{name: "build only", dev: DevConfig{Build: "make"}},
+		{name: "build and watch", dev: DevConfig{Build: "make", Watch: []string{"*.go", "cmd/*.go"}}},
+		{name: "watch without build", dev: DevConfig{Watch: []string{"*.go"}}, wantErr: "watch requires build"},
+		{name: "bad pattern", dev: DevConfig{Build: "make", Watch: []string{"[*.go"}}, wantErr: "invalid watch pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.dev.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
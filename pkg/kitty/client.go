@@ -0,0 +1,206 @@
+package kitty
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdh"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment kitty sets in its children, and kitten @'s password variable
+const (
+	EnvListenOn  = "KITTY_LISTEN_ON"
+	EnvPublicKey = "KITTY_PUBLIC_KEY"
+	EnvPassword  = "KITTY_RC_PASSWORD"
+)
+
+// Client sends remote-control commands to one kitty. Each command uses its
+// own connection, as kitten @ does.
+type Client struct {
+	network string
+	address string
+	timeout time.Duration
+
+	password string
+	pubkey   *ecdh.PublicKey
+}
+
+type ClientOption func(*Client)
+
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithPassword encrypts commands with kitty's remote_control_password to
+// kitty's public key
+func WithPassword(password string, pubkey *ecdh.PublicKey) ClientOption {
+	return func(c *Client) {
+		c.password = password
+		c.pubkey = pubkey
+	}
+}
+
+// NewClient creates a client for a kitty listen_on address: unix:/path,
+// unix:@abstract or tcp:host:port
+func NewClient(addr string, opts ...ClientOption) (*Client, error) {
+	network, address, ok := strings.Cut(addr, ":")
+	if !ok || address == "" || (network != "unix" && network != "tcp") {
+		return nil, fmt.Errorf("unsupported kitty address %q (want unix:<path> or tcp:<host>:<port>)", addr)
+	}
+
+	c := &Client{
+		network: network,
+		address: address,
+		timeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.password != "" && c.pubkey == nil {
+		return nil, fmt.Errorf("a remote control password needs kitty's public key")
+	}
+	return c, nil
+}
+
+// NewClientFromEnv creates a client for the kitty in KITTY_LISTEN_ON,
+// encrypting with KITTY_RC_PASSWORD when it is set
+func NewClientFromEnv(opts ...ClientOption) (*Client, error) {
+	addr := os.Getenv(EnvListenOn)
+	if addr == "" {
+		return nil, fmt.Errorf("%s is not set; run under kitty with allow_remote_control and listen_on configured", EnvListenOn)
+	}
+
+	if password := os.Getenv(EnvPassword); password != "" {
+		encoded := os.Getenv(EnvPublicKey)
+		if encoded == "" {
+			return nil, fmt.Errorf("%s is set but kitty exported no %s", EnvPassword, EnvPublicKey)
+		}
+		pubkey, err := ParsePublicKey(encoded)
+		if err != nil {
+			return nil, err
+		}
+		opts = append([]ClientOption{WithPassword(password, pubkey)}, opts...)
+	}
+
+	return NewClient(addr, opts...)
+}
+
+// Call runs cmd with payload and decodes the response data into result, if
+// non-nil. A command kitty rejects returns an *Error.
+func (c *Client) Call(ctx context.Context, cmd string, payload, result any) error {
+	msg := &Command{Cmd: cmd, Version: ProtocolVersion}
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s payload: %w", cmd, err)
+		}
+		msg.Payload = raw
+	}
+
+	var frame any = msg
+	if c.password != "" {
+		encrypted, err := encryptCommand(msg, c.password, c.pubkey)
+		if err != nil {
+			return err
+		}
+		frame = encrypted
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to kitty at %s: %w", c.address, err)
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	if err := WriteFrame(conn, frame); err != nil {
+		return fmt.Errorf("failed to send %s: %w", cmd, err)
+	}
+
+	data, err := ReadFrame(bufio.NewReader(conn))
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", cmd, err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", cmd, err)
+	}
+	if !resp.OK {
+		return &Error{Cmd: cmd, Message: resp.Error}
+	}
+
+	if result != nil && len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, result); err != nil {
+			return fmt.Errorf("failed to decode %s response: %w", cmd, err)
+		}
+	}
+	return nil
+}
+
+// LS lists kitty's windows
+func (c *Client) LS(ctx context.Context, req *LSRequest) ([]OSWindow, error) {
+	if req == nil {
+		req = &LSRequest{}
+	}
+
+	// kitty returns the listing as a JSON document inside a string
+	var data string
+	if err := c.Call(ctx, "ls", req, &data); err != nil {
+		return nil, err
+	}
+	return ParseLS([]byte(data))
+}
+
+// ParseLS decodes kitty @ ls output
+func ParseLS(data []byte) ([]OSWindow, error) {
+	var osWindows []OSWindow
+	if err := json.Unmarshal(data, &osWindows); err != nil {
+		return nil, fmt.Errorf("failed to parse kitty ls output: %w", err)
+	}
+	return osWindows, nil
+}
+
+// Launch opens a window and returns its ID
+func (c *Client) Launch(ctx context.Context, req *LaunchRequest) (int, error) {
+	// The ID comes back as a string, or a number from some versions
+	var data json.RawMessage
+	if err := c.Call(ctx, "launch", req, &data); err != nil {
+		return 0, err
+	}
+	id, err := strconv.Atoi(strings.Trim(string(data), "\" \n"))
+	if err != nil {
+		return 0, fmt.Errorf("kitty returned an invalid window ID %q", data)
+	}
+	return id, nil
+}
+
+func (c *Client) CloseWindow(ctx context.Context, req *CloseWindowRequest) error {
+	return c.Call(ctx, "close-window", req, nil)
+}
+
+func (c *Client) FocusWindow(ctx context.Context, req *FocusWindowRequest) error {
+	return c.Call(ctx, "focus-window", req, nil)
+}
+
+func (c *Client) ResizeOSWindow(ctx context.Context, req *ResizeOSWindowRequest) error {
+	return c.Call(ctx, "resize-os-window", req, nil)
+}
@@ -0,0 +1,150 @@
+package kitty_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/starbased-co/shine/pkg/kitty"
+	"github.com/starbased-co/shine/pkg/kitty/kittytest"
+)
+
+func TestClient_WindowLifecycle(t *testing.T) {
+	srv := kittytest.NewServer(t)
+	client, err := kitty.NewClient(srv.Addr)
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	ctx := context.Background()
+
+	id, err := client.Launch(ctx, &kitty.LaunchRequest{
+		Type:        "os-panel",
+		Args:        []string{"prismctl", "bar-0"},
+		WindowTitle: "bar",
+		OSPanel:     []string{"edge=top", "lines=1"},
+	})
+	if err != nil {
+		t.Fatalf("Launch() error: %v", err)
+	}
+
+	win, ok := srv.Window(id)
+	if !ok || win.Type != "os-panel" || win.Title != "bar" || len(win.OSPanel) != 2 {
+		t.Fatalf("launched window = %+v", win)
+	}
+
+	osWindows, err := client.LS(ctx, nil)
+	if err != nil {
+		t.Fatalf("LS() error: %v", err)
+	}
+	windows := kitty.Windows(osWindows)
+	if len(windows) != 1 || windows[0].ID != id || windows[0].PID != win.PID {
+		t.Fatalf("LS() windows = %+v", windows)
+	}
+	if got := windows[0].Cmdline; len(got) != 2 || got[1] != "bar-0" {
+		t.Errorf("LS() cmdline = %v", got)
+	}
+
+	if err := client.ResizeOSWindow(ctx, &kitty.ResizeOSWindowRequest{
+		Match: kitty.MatchID(id), Unit: "cells", Width: 120, Height: 2,
+	}); err != nil {
+		t.Fatalf("ResizeOSWindow() error: %v", err)
+	}
+	if err := client.ResizeOSWindow(ctx, &kitty.ResizeOSWindowRequest{
+		Match: kitty.MatchID(id), Action: kitty.ActionHide,
+	}); err != nil {
+		t.Fatalf("ResizeOSWindow(hide) error: %v", err)
+	}
+	if win, _ := srv.Window(id); win.Cols != 120 || win.Rows != 2 || !win.Hidden {
+		t.Errorf("window after resize and hide = %+v", win)
+	}
+
+	if err := client.CloseWindow(ctx, &kitty.CloseWindowRequest{Match: kitty.MatchID(id)}); err != nil {
+		t.Fatalf("CloseWindow() error: %v", err)
+	}
+	if _, ok := srv.Window(id); ok {
+		t.Error("window still open after CloseWindow()")
+	}
+
+	cmds := srv.Commands()
+	if len(cmds) != 5 || cmds[0].Cmd != "launch" || cmds[0].Version != kitty.ProtocolVersion {
+		t.Errorf("commands = %+v", cmds)
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	srv := kittytest.NewServer(t)
+	client, _ := kitty.NewClient(srv.Addr)
+
+	err := client.CloseWindow(context.Background(), &kitty.CloseWindowRequest{Match: "id:99"})
+	var kerr *kitty.Error
+	if !errors.As(err, &kerr) {
+		t.Fatalf("CloseWindow() error = %v, want *kitty.Error", err)
+	}
+	if kerr.Cmd != "close-window" || kerr.Message == "" {
+		t.Errorf("error = %+v", kerr)
+	}
+
+	err = client.CloseWindow(context.Background(), &kitty.CloseWindowRequest{Match: "id:99", IgnoreNoMatch: true})
+	if err != nil {
+		t.Errorf("CloseWindow(ignore_no_match) error: %v", err)
+	}
+}
+
+func TestClient_Password(t *testing.T) {
+	srv := kittytest.NewServer(t)
+	srv.RequirePassword("hunter2")
+	pubkey, err := kitty.ParsePublicKey(srv.PublicKey())
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error: %v", err)
+	}
+	ctx := context.Background()
+
+	plain, _ := kitty.NewClient(srv.Addr)
+	if _, err := plain.LS(ctx, nil); err == nil {
+		t.Error("LS() without a password should fail")
+	}
+
+	wrong, _ := kitty.NewClient(srv.Addr, kitty.WithPassword("guess", pubkey))
+	if _, err := wrong.LS(ctx, nil); err == nil {
+		t.Error("LS() with the wrong password should fail")
+	}
+
+	client, _ := kitty.NewClient(srv.Addr, kitty.WithPassword("hunter2", pubkey))
+	if _, err := client.LS(ctx, nil); err != nil {
+		t.Errorf("LS() with the password error: %v", err)
+	}
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	srv := kittytest.NewServer(t)
+
+	t.Setenv(kitty.EnvListenOn, "")
+	if _, err := kitty.NewClientFromEnv(); err == nil {
+		t.Error("NewClientFromEnv() without KITTY_LISTEN_ON should fail")
+	}
+
+	t.Setenv(kitty.EnvListenOn, srv.Addr)
+	t.Setenv(kitty.EnvPassword, "hunter2")
+	t.Setenv(kitty.EnvPublicKey, "")
+	if _, err := kitty.NewClientFromEnv(); err == nil {
+		t.Error("NewClientFromEnv() with a password and no public key should fail")
+	}
+
+	srv.RequirePassword("hunter2")
+	t.Setenv(kitty.EnvPublicKey, srv.PublicKey())
+	client, err := kitty.NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv() error: %v", err)
+	}
+	if _, err := client.LS(context.Background(), nil); err != nil {
+		t.Errorf("LS() error: %v", err)
+	}
+}
+
+func TestNewClient_InvalidAddress(t *testing.T) {
+	for _, addr := range []string{"", "/tmp/kitty", "fd:3", "unix:"} {
+		if _, err := kitty.NewClient(addr); err == nil {
+			t.Errorf("NewClient(%q) should fail", addr)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package kitty
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// encryptionProtocol is the only scheme kitty has: X25519 key agreement,
+// SHA-256 of the shared secret as an AES-256-GCM key
+const encryptionProtocol = "1"
+
+// ParsePublicKey decodes KITTY_PUBLIC_KEY, "1:<base85 X25519 key>"
+func ParsePublicKey(encoded string) (*ecdh.PublicKey, error) {
+	proto, key, ok := strings.Cut(encoded, ":")
+	if !ok {
+		return nil, fmt.Errorf("malformed kitty public key")
+	}
+	if proto != encryptionProtocol {
+		return nil, fmt.Errorf("unsupported kitty encryption protocol %q", proto)
+	}
+	raw, err := decodeBase85(key)
+	if err != nil {
+		return nil, fmt.Errorf("malformed kitty public key: %w", err)
+	}
+	return ecdh.X25519().NewPublicKey(raw)
+}
+
+// EncodePublicKey formats key the way kitty exports it in KITTY_PUBLIC_KEY
+func EncodePublicKey(key *ecdh.PublicKey) string {
+	return encryptionProtocol + ":" + encodeBase85(key.Bytes())
+}
+
+// encryptCommand seals cmd with password to kitty's key, using a fresh
+// key pair per command as kitten does
+func encryptCommand(cmd *Command, password string, kittyKey *ecdh.PublicKey) (*EncryptedCommand, error) {
+	sealed := *cmd
+	sealed.Password = password
+	sealed.Timestamp = time.Now().UnixNano()
+
+	plaintext, err := json.Marshal(&sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode command: %w", err)
+	}
+
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	aead, err := sharedCipher(private, kittyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, fmt.Errorf("failed to generate iv: %w", err)
+	}
+	out := aead.Seal(nil, iv, plaintext, nil)
+	ciphertext, tag := out[:len(out)-aead.Overhead()], out[len(out)-aead.Overhead():]
+
+	return &EncryptedCommand{
+		Version:   cmd.Version,
+		IV:        encodeBase85(iv),
+		Tag:       encodeBase85(tag),
+		Pubkey:    encodeBase85(private.PublicKey().Bytes()),
+		Encrypted: encodeBase85(ciphertext),
+		EncProto:  encryptionProtocol,
+	}, nil
+}
+
+// DecryptCommand opens an encrypted command with kitty's private key, as
+// kitty does on receipt. Used by fake kitty servers in tests.
+func DecryptCommand(ec *EncryptedCommand, kittyKey *ecdh.PrivateKey) (*Command, error) {
+	if ec.EncProto != "" && ec.EncProto != encryptionProtocol {
+		return nil, fmt.Errorf("unsupported encryption protocol %q", ec.EncProto)
+	}
+
+	fields := make([][]byte, 4)
+	for i, s := range []string{ec.Pubkey, ec.IV, ec.Tag, ec.Encrypted} {
+		b, err := decodeBase85(s)
+		if err != nil {
+			return nil, fmt.Errorf("malformed encrypted command: %w", err)
+		}
+		fields[i] = b
+	}
+	pubkey, iv, tag, ciphertext := fields[0], fields[1], fields[2], fields[3]
+
+	peer, err := ecdh.X25519().NewPublicKey(pubkey)
+	if err != nil {
+		return nil, fmt.Errorf("malformed encrypted command: %w", err)
+	}
+	aead, err := sharedCipher(kittyKey, peer)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aead.NonceSize() {
+		return nil, fmt.Errorf("malformed encrypted command: bad iv length %d", len(iv))
+	}
+
+	plaintext, err := aead.Open(nil, iv, append(ciphertext, tag...), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt command: %w", err)
+	}
+
+	var cmd Command
+	if err := json.Unmarshal(plaintext, &cmd); err != nil {
+		return nil, fmt.Errorf("failed to decode command: %w", err)
+	}
+	return &cmd, nil
+}
+
+func sharedCipher(private *ecdh.PrivateKey, peer *ecdh.PublicKey) (cipher.AEAD, error) {
+	secret, err := private.ECDH(peer)
+	if err != nil {
+		return nil, fmt.Errorf("key agreement failed: %w", err)
+	}
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// base85Alphabet is RFC 1924's, used by Python's base64.b85encode and so by
+// kitty
+const base85Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz!#$%&()*+-;<=>?@^_`{|}~"
+
+var base85Decode = func() (table [256]int) {
+	for i := range table {
+		table[i] = -1
+	}
+	for i := 0; i < len(base85Alphabet); i++ {
+		table[base85Alphabet[i]] = i
+	}
+	return table
+}()
+
+// encodeBase85 encodes without padding: a trailing group of n bytes becomes
+// n+1 characters
+func encodeBase85(data []byte) string {
+	var sb strings.Builder
+	sb.Grow((len(data)*5 + 3) / 4)
+
+	for len(data) > 0 {
+		var group [4]byte
+		n := copy(group[:], data)
+		data = data[n:]
+
+		v := uint32(group[0])<<24 | uint32(group[1])<<16 | uint32(group[2])<<8 | uint32(group[3])
+		var chars [5]byte
+		for i := 4; i >= 0; i-- {
+			chars[i] = base85Alphabet[v%85]
+			v /= 85
+		}
+		sb.Write(chars[:n+1])
+	}
+	return sb.String()
+}
+
+func decodeBase85(s string) ([]byte, error) {
+	out := make([]byte, 0, len(s)*4/5+4)
+
+	for len(s) > 0 {
+		n := min(len(s), 5)
+		if n == 1 {
+			return nil, fmt.Errorf("invalid base85 length")
+		}
+
+		var v uint64
+		for i := 0; i < 5; i++ {
+			digit := 84 // padding: the highest digit
+			if i < n {
+				digit = base85Decode[s[i]]
+				if digit < 0 {
+					return nil, fmt.Errorf("invalid base85 character %q", s[i])
+				}
+			}
+			v = v*85 + uint64(digit)
+		}
+		if v > 0xffffffff {
+			return nil, fmt.Errorf("base85 group overflows")
+		}
+
+		group := [4]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+		out = append(out, group[:n-1]...)
+		s = s[n:]
+	}
+	return out, nil
+}
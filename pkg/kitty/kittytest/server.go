@@ -0,0 +1,430 @@
+// Package kittytest is a fake kitty remote-control server for tests. It
+// keeps a table of windows that launch, ls, close-window and
+// resize-os-window act on, and records every command it receives.
+package kittytest
+
+import (
+	"bufio"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/starbased-co/shine/pkg/kitty"
+)
+
+// HandlerFunc answers a command. The returned data becomes the response's
+// data; an error becomes a failed response with its message.
+type HandlerFunc func(payload json.RawMessage) (any, error)
+
+// Window is a window the fake kitty has open
+type Window struct {
+	ID      int
+	PID     int
+	Title   string
+	Type    string
+	Args    []string
+	OSPanel []string
+	Cols    int
+	Rows    int
+	Hidden  bool
+}
+
+type Server struct {
+	// Addr is the listen_on address for kitty.NewClient
+	Addr string
+
+	listener net.Listener
+	private  *ecdh.PrivateKey
+	password string
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+	windows  map[int]*Window
+	nextID   int
+	commands []kitty.Command
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a fake kitty on a socket in a temporary directory. It is
+// closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("kittytest: failed to generate key: %v", err)
+	}
+
+	sockPath := filepath.Join(t.TempDir(), "kitty.sock")
+	l, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Fatalf("kittytest: failed to listen: %v", err)
+	}
+
+	s := &Server{
+		Addr:     "unix:" + sockPath,
+		listener: l,
+		private:  private,
+		handlers: make(map[string]HandlerFunc),
+		windows:  make(map[int]*Window),
+	}
+	s.handlers["ls"] = s.handleLS
+	s.handlers["launch"] = s.handleLaunch
+	s.handlers["close-window"] = s.handleCloseWindow
+	s.handlers["focus-window"] = s.handleFocusWindow
+	s.handlers["resize-os-window"] = s.handleResizeOSWindow
+
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// PublicKey is the server's key as kitty exports it in KITTY_PUBLIC_KEY
+func (s *Server) PublicKey() string {
+	return kitty.EncodePublicKey(s.private.PublicKey())
+}
+
+// RequirePassword makes the server reject commands that are not encrypted
+// with password, as kitty does with remote_control_password
+func (s *Server) RequirePassword(password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.password = password
+}
+
+// Handle replaces the handler for cmd
+func (s *Server) Handle(cmd string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[cmd] = fn
+}
+
+// Commands returns the commands received so far, decrypted
+func (s *Server) Commands() []kitty.Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]kitty.Command(nil), s.commands...)
+}
+
+// AddWindow opens a window directly, as if the user had
+func (s *Server) AddWindow(w Window) *Window {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addWindowLocked(w)
+}
+
+// Window returns a copy of an open window
+func (s *Server) Window(id int) (Window, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.windows[id]
+	if !ok {
+		return Window{}, false
+	}
+	return *w, true
+}
+
+// CloseWindow closes a window directly, as if the user had
+func (s *Server) CloseWindow(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.windows, id)
+}
+
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.serveConn(conn)
+		}()
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	for {
+		data, err := kitty.ReadFrame(r)
+		if err != nil {
+			return
+		}
+		resp := s.dispatch(data)
+		if resp == nil {
+			continue
+		}
+		if err := kitty.WriteFrame(conn, resp); err != nil {
+			return
+		}
+	}
+}
+
+// dispatch runs one framed command. A nil response means none was wanted.
+func (s *Server) dispatch(data []byte) *kitty.Response {
+	cmd, err := s.decode(data)
+	if err != nil {
+		return &kitty.Response{Error: err.Error()}
+	}
+
+	s.mu.Lock()
+	s.commands = append(s.commands, *cmd)
+	handler, ok := s.handlers[cmd.Cmd]
+	s.mu.Unlock()
+
+	if !ok {
+		return &kitty.Response{Error: fmt.Sprintf("Unknown remote control command: %s", cmd.Cmd)}
+	}
+
+	result, err := handler(cmd.Payload)
+	if cmd.NoResponse {
+		return nil
+	}
+	if err != nil {
+		return &kitty.Response{Error: err.Error()}
+	}
+
+	resp := &kitty.Response{OK: true}
+	if result != nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return &kitty.Response{Error: err.Error()}
+		}
+		resp.Data = raw
+	}
+	return resp
+}
+
+// decode parses a command, decrypting it if needed, and checks the password
+func (s *Server) decode(data []byte) (*kitty.Command, error) {
+	var probe struct {
+		Encrypted string `json:"encrypted"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("Invalid command: %v", err)
+	}
+
+	s.mu.Lock()
+	password := s.password
+	s.mu.Unlock()
+
+	if probe.Encrypted == "" {
+		if password != "" {
+			return nil, fmt.Errorf("Remote control is disallowed without a password")
+		}
+		var cmd kitty.Command
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			return nil, fmt.Errorf("Invalid command: %v", err)
+		}
+		return &cmd, nil
+	}
+
+	var ec kitty.EncryptedCommand
+	if err := json.Unmarshal(data, &ec); err != nil {
+		return nil, fmt.Errorf("Invalid command: %v", err)
+	}
+	cmd, err := kitty.DecryptCommand(&ec, s.private)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Password != password {
+		return nil, fmt.Errorf("Incorrect password")
+	}
+	return cmd, nil
+}
+
+func (s *Server) addWindowLocked(w Window) *Window {
+	s.nextID++
+	w.ID = s.nextID
+	if w.PID == 0 {
+		w.PID = 10000 + w.ID
+	}
+	win := &w
+	s.windows[w.ID] = win
+	return win
+}
+
+// match resolves a kitty match expression. Only id: and title: are
+// supported; an empty expression stands in for kitty's active window and
+// matches the newest one.
+func (s *Server) match(expr string) ([]*Window, error) {
+	if expr == "" {
+		ids := s.sortedIDs()
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("No active window")
+		}
+		return []*Window{s.windows[ids[len(ids)-1]]}, nil
+	}
+
+	field, value, _ := strings.Cut(expr, ":")
+
+	var matched []*Window
+	for _, id := range s.sortedIDs() {
+		w := s.windows[id]
+		switch field {
+		case "id":
+			if strconv.Itoa(w.ID) == value {
+				matched = append(matched, w)
+			}
+		case "title":
+			if strings.Contains(w.Title, value) {
+				matched = append(matched, w)
+			}
+		default:
+			return nil, fmt.Errorf("kittytest: unsupported match %q", expr)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("No matching windows for expression: %s", expr)
+	}
+	return matched, nil
+}
+
+func (s *Server) sortedIDs() []int {
+	ids := make([]int, 0, len(s.windows))
+	for id := range s.windows {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (s *Server) handleLS(payload json.RawMessage) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// One OS window per window, as kitty has for panels
+	osWindows := []kitty.OSWindow{}
+	for _, id := range s.sortedIDs() {
+		w := s.windows[id]
+		osWindows = append(osWindows, kitty.OSWindow{
+			ID: w.ID,
+			Tabs: []kitty.Tab{{
+				ID:    w.ID,
+				Title: w.Title,
+				Windows: []kitty.Window{{
+					ID:      w.ID,
+					Title:   w.Title,
+					PID:     w.PID,
+					Cmdline: w.Args,
+					Columns: w.Cols,
+					Lines:   w.Rows,
+				}},
+			}},
+		})
+	}
+
+	// kitty sends the listing as a string holding JSON
+	data, err := json.Marshal(osWindows)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (s *Server) handleLaunch(payload json.RawMessage) (any, error) {
+	var req kitty.LaunchRequest
+	if err := unmarshalPayload(payload, &req); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.addWindowLocked(Window{
+		Title:   req.WindowTitle,
+		Type:    req.Type,
+		Args:    req.Args,
+		OSPanel: req.OSPanel,
+	})
+	return strconv.Itoa(w.ID), nil
+}
+
+func (s *Server) handleCloseWindow(payload json.RawMessage) (any, error) {
+	var req kitty.CloseWindowRequest
+	if err := unmarshalPayload(payload, &req); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matched, err := s.match(req.Match)
+	if err != nil {
+		if req.IgnoreNoMatch {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, w := range matched {
+		delete(s.windows, w.ID)
+	}
+	return nil, nil
+}
+
+func (s *Server) handleFocusWindow(payload json.RawMessage) (any, error) {
+	var req kitty.FocusWindowRequest
+	if err := unmarshalPayload(payload, &req); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.match(req.Match)
+	return nil, err
+}
+
+func (s *Server) handleResizeOSWindow(payload json.RawMessage) (any, error) {
+	var req kitty.ResizeOSWindowRequest
+	if err := unmarshalPayload(payload, &req); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matched, err := s.match(req.Match)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range matched {
+		switch req.Action {
+		case "", kitty.ActionResize:
+			w.Cols, w.Rows = req.Width, req.Height
+		case kitty.ActionShow:
+			w.Hidden = false
+		case kitty.ActionHide:
+			w.Hidden = true
+		case kitty.ActionToggleVisibility:
+			w.Hidden = !w.Hidden
+		default:
+			return nil, fmt.Errorf("kittytest: unsupported action %q", req.Action)
+		}
+	}
+	return nil, nil
+}
+
+func unmarshalPayload(payload json.RawMessage, v any) error {
+	if len(payload) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("Invalid payload: %v", err)
+	}
+	return nil
+}
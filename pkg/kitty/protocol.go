@@ -0,0 +1,119 @@
+// Package kitty is a client for kitty's remote-control protocol, the one
+// kitten @ speaks. shine uses it to launch, list, resize and close panels
+// without forking kitten for every command.
+//
+// A command is a JSON object framed as ESC P @kitty-cmd <json> ESC \ and
+// written to kitty's listen_on socket; kitty answers with a response in the
+// same framing. With remote_control_password set, commands are encrypted to
+// kitty's public key.
+package kitty
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ProtocolVersion is the kitty version commands claim to come from. kitty
+// uses it for compatibility; 0.42 is the first with os-panel launches.
+var ProtocolVersion = [3]int{0, 42, 0}
+
+const (
+	frameStart = "\x1bP@kitty-cmd"
+	frameEnd   = "\x1b\\"
+)
+
+// Command is a remote-control request
+type Command struct {
+	Cmd        string          `json:"cmd"`
+	Version    [3]int          `json:"version"`
+	NoResponse bool            `json:"no_response,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+
+	// Only sent inside an EncryptedCommand
+	Password  string `json:"password,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"` // ns since the epoch
+}
+
+// EncryptedCommand carries an encrypted Command. Binary fields are base85.
+type EncryptedCommand struct {
+	Version   [3]int `json:"version"`
+	IV        string `json:"iv"`
+	Tag       string `json:"tag"`
+	Pubkey    string `json:"pubkey"`
+	Encrypted string `json:"encrypted"`
+	EncProto  string `json:"enc_proto,omitempty"`
+}
+
+// Response is kitty's answer to a Command
+type Response struct {
+	OK        bool            `json:"ok"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Traceback string          `json:"tb,omitempty"`
+}
+
+// Error is a command kitty rejected
+type Error struct {
+	Cmd     string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("kitty @ %s: %s", e.Cmd, e.Message)
+}
+
+// WriteFrame writes msg as a framed remote-control message
+func WriteFrame(w io.Writer, msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(frameStart) + len(data) + len(frameEnd))
+	buf.WriteString(frameStart)
+	buf.Write(data)
+	buf.WriteString(frameEnd)
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// ReadFrame reads one framed message and returns its JSON. Bytes before the
+// frame start are skipped. JSON never contains a raw ESC, so the first
+// ESC \ ends the frame.
+func ReadFrame(r *bufio.Reader) ([]byte, error) {
+	var msg []byte
+	for {
+		chunk, err := r.ReadBytes('\x1b')
+		if err != nil {
+			if err == io.EOF && len(msg)+len(chunk) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		msg = append(msg, chunk...)
+
+		next, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if next == '\\' {
+			break
+		}
+		r.UnreadByte()
+	}
+
+	msg = msg[:len(msg)-1] // the ESC of ESC \
+	i := bytes.Index(msg, []byte(frameStart))
+	if i < 0 {
+		return nil, fmt.Errorf("malformed frame: missing %q", frameStart[1:])
+	}
+	return msg[i+len(frameStart):], nil
+}
@@ -0,0 +1,111 @@
+package kitty
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &Command{Cmd: "ls", Version: ProtocolVersion, Payload: []byte(`{"match":"title:\u001b\\"}`)}
+	if err := WriteFrame(&buf, cmd); err != nil {
+		t.Fatalf("WriteFrame() error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "\x1bP@kitty-cmd{") || !strings.HasSuffix(buf.String(), "}\x1b\\") {
+		t.Fatalf("frame = %q", buf.String())
+	}
+
+	// Leading noise is skipped, and two frames read back in order
+	stream := "junk\x1b[0m" + buf.String() + buf.String()
+	r := bufio.NewReader(strings.NewReader(stream))
+	for i := 0; i < 2; i++ {
+		data, err := ReadFrame(r)
+		if err != nil {
+			t.Fatalf("ReadFrame() #%d error: %v", i, err)
+		}
+		if !bytes.HasPrefix(data, []byte(`{"cmd":"ls","version":[0,42,0]`)) {
+			t.Errorf("ReadFrame() #%d = %s", i, data)
+		}
+	}
+}
+
+func TestReadFrame_Truncated(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("\x1bP@kitty-cmd{\"ok\":true"))
+	if _, err := ReadFrame(r); err == nil {
+		t.Error("ReadFrame() on a truncated frame should fail")
+	}
+}
+
+func TestBase85(t *testing.T) {
+	// Vectors from Python's base64.b85encode
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"", ""},
+		{"h", "Xa"},
+		{"hello", "Xk~0{Zv"},
+		{"\x00\x00\x00\x00", "00000"},
+		{"\xff\xff\xff\xff\xff", "|NsC0{{"},
+	}
+
+	for _, tt := range tests {
+		if got := encodeBase85([]byte(tt.data)); got != tt.want {
+			t.Errorf("encodeBase85(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+		got, err := decodeBase85(tt.want)
+		if err != nil || string(got) != tt.data {
+			t.Errorf("decodeBase85(%q) = %q, %v, want %q", tt.want, got, err, tt.data)
+		}
+	}
+
+	if _, err := decodeBase85("ab\"cd"); err == nil {
+		t.Error("decodeBase85() should reject characters outside the alphabet")
+	}
+}
+
+func TestEncryptCommand_RoundTrip(t *testing.T) {
+	kittyKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := ParsePublicKey(EncodePublicKey(kittyKey.PublicKey()))
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error: %v", err)
+	}
+
+	cmd := &Command{Cmd: "ls", Version: ProtocolVersion, Payload: []byte(`{"self":true}`)}
+	ec, err := encryptCommand(cmd, "hunter2", pubkey)
+	if err != nil {
+		t.Fatalf("encryptCommand() error: %v", err)
+	}
+	if strings.Contains(ec.Encrypted, "hunter2") {
+		t.Error("password visible in the encrypted command")
+	}
+
+	got, err := DecryptCommand(ec, kittyKey)
+	if err != nil {
+		t.Fatalf("DecryptCommand() error: %v", err)
+	}
+	if got.Cmd != "ls" || got.Password != "hunter2" || got.Timestamp == 0 || string(got.Payload) != `{"self":true}` {
+		t.Errorf("DecryptCommand() = %+v", got)
+	}
+
+	// Another key cannot open it
+	other, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	if _, err := DecryptCommand(ec, other); err == nil {
+		t.Error("DecryptCommand() with the wrong key should fail")
+	}
+}
+
+func TestParsePublicKey_Invalid(t *testing.T) {
+	for _, encoded := range []string{"", "nocolon", "2:00000", "1:short"} {
+		if _, err := ParsePublicKey(encoded); err == nil {
+			t.Errorf("ParsePublicKey(%q) should fail", encoded)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package kitty
+
+import "strconv"
+
+// OSWindow is one entry of kitty @ ls: a top-level window and its tabs
+type OSWindow struct {
+	ID               int    `json:"id"`
+	PlatformWindowID int    `json:"platform_window_id,omitempty"`
+	IsActive         bool   `json:"is_active"`
+	IsFocused        bool   `json:"is_focused"`
+	LastFocused      bool   `json:"last_focused"`
+	WMClass          string `json:"wm_class,omitempty"`
+	WMName           string `json:"wm_name,omitempty"`
+	Tabs             []Tab  `json:"tabs"`
+}
+
+type Tab struct {
+	ID        int      `json:"id"`
+	IsActive  bool     `json:"is_active"`
+	IsFocused bool     `json:"is_focused"`
+	Title     string   `json:"title"`
+	Layout    string   `json:"layout,omitempty"`
+	Windows   []Window `json:"windows"`
+}
+
+type Window struct {
+	ID                  int               `json:"id"`
+	Title               string            `json:"title"`
+	PID                 int               `json:"pid"`
+	Cwd                 string            `json:"cwd,omitempty"`
+	Cmdline             []string          `json:"cmdline,omitempty"`
+	Env                 map[string]string `json:"env,omitempty"`
+	IsActive            bool              `json:"is_active"`
+	IsFocused           bool              `json:"is_focused"`
+	IsSelf              bool              `json:"is_self"`
+	Columns             int               `json:"columns"`
+	Lines               int               `json:"lines"`
+	ForegroundProcesses []Process         `json:"foreground_processes,omitempty"`
+	UserVars            map[string]string `json:"user_vars,omitempty"`
+}
+
+type Process struct {
+	PID     int      `json:"pid"`
+	Cmdline []string `json:"cmdline,omitempty"`
+	Cwd     string   `json:"cwd,omitempty"`
+}
+
+// Windows flattens ls output to every window in every tab
+func Windows(osWindows []OSWindow) []Window {
+	var windows []Window
+	for _, osWin := range osWindows {
+		for _, tab := range osWin.Tabs {
+			windows = append(windows, tab.Windows...)
+		}
+	}
+	return windows
+}
+
+// Command payloads. Field names are kitty's; empty fields take kitty's
+// defaults.
+
+type LSRequest struct {
+	Match      string `json:"match,omitempty"`
+	MatchTab   string `json:"match_tab,omitempty"`
+	AllEnvVars bool   `json:"all_env_vars,omitempty"`
+	Self       bool   `json:"self,omitempty"`
+}
+
+type LaunchRequest struct {
+	Args        []string `json:"args,omitempty"`
+	Type        string   `json:"type,omitempty"` // window, tab, os-window, os-panel, ...
+	Match       string   `json:"match,omitempty"`
+	WindowTitle string   `json:"window_title,omitempty"`
+	Cwd         string   `json:"cwd,omitempty"`
+	Env         []string `json:"env,omitempty"` // NAME=VALUE
+	KeepFocus   bool     `json:"keep_focus,omitempty"`
+	OSPanel     []string `json:"os_panel,omitempty"` // key=value panel settings, as --os-panel
+}
+
+type CloseWindowRequest struct {
+	Match         string `json:"match,omitempty"`
+	Self          bool   `json:"self,omitempty"`
+	IgnoreNoMatch bool   `json:"ignore_no_match,omitempty"`
+}
+
+type FocusWindowRequest struct {
+	Match string `json:"match,omitempty"`
+}
+
+// Actions for ResizeOSWindowRequest
+const (
+	ActionResize           = "resize"
+	ActionShow             = "show"
+	ActionHide             = "hide"
+	ActionToggleVisibility = "toggle-visibility"
+)
+
+type ResizeOSWindowRequest struct {
+	Match  string `json:"match,omitempty"`
+	Self   bool   `json:"self,omitempty"`
+	Action string `json:"action,omitempty"`
+	Unit   string `json:"unit,omitempty"` // cells or pixels
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// MatchID matches a window by ID in a request's Match field
+func MatchID(id int) string {
+	return "id:" + strconv.Itoa(id)
+}
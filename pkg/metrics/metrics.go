@@ -0,0 +1,278 @@
+// Package metrics keeps the counters, gauges and histograms shined and
+// prismctl export over service/metrics and renders them in the OpenMetrics
+// text format.
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Metric types
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Family is a metric with one sample per label set
+type Family struct {
+	Name    string   `json:"name"`
+	Help    string   `json:"help"`
+	Type    string   `json:"type"`
+	Samples []Sample `json:"samples"`
+}
+
+// Sample is one series of a family. Counters and gauges carry Value;
+// histograms carry Count, Sum and cumulative Buckets, with the +Inf bucket
+// implied by Count.
+type Sample struct {
+	Labels  map[string]string `json:"labels,omitempty"`
+	Value   float64           `json:"value,omitempty"`
+	Count   uint64            `json:"count,omitempty"`
+	Sum     float64           `json:"sum,omitempty"`
+	Buckets []Bucket          `json:"buckets,omitempty"`
+}
+
+type Bucket struct {
+	UpperBound float64 `json:"le"`
+	Count      uint64  `json:"count"`
+}
+
+// LatencyBuckets suit latencies from a millisecond to several seconds
+var LatencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds a process's metrics. The zero value is not usable; create
+// one with NewRegistry.
+type Registry struct {
+	mu         sync.Mutex
+	metrics    []*metric
+	collectors []func()
+
+	// gather serializes Gather, so that concurrent scrapes do not interleave
+	// one collector's Reset with another's Set
+	gather sync.Mutex
+}
+
+type metric struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	labels []string
+	value  float64
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(name, help, typ string, buckets []float64, labels []string) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range r.metrics {
+		if m.name == name {
+			panic(fmt.Sprintf("metrics: %s registered twice", name))
+		}
+	}
+
+	m := &metric{name: name, help: help, typ: typ, labels: labels, buckets: buckets, series: make(map[string]*series)}
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// Counter registers a counter. By OpenMetrics convention its name ends in
+// _total.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{r: r, m: r.register(name, help, TypeCounter, nil, labels)}
+}
+
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r: r, m: r.register(name, help, TypeGauge, nil, labels)}
+}
+
+// Histogram registers a histogram with the given ascending bucket bounds
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{r: r, m: r.register(name, help, TypeHistogram, buckets, labels)}
+}
+
+// OnGather registers fn to run before each Gather, to sample values that
+// are read rather than counted, such as process CPU time. Collectors never
+// run concurrently with each other or another Gather's snapshot.
+func (r *Registry) OnGather(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, fn)
+}
+
+// seriesFor returns the series for a label set, creating it on first use.
+// Assumes caller holds r.mu lock
+func (m *metric) seriesFor(values []string) *series {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", m.name, len(m.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{labels: slices.Clone(values)}
+		if m.typ == TypeHistogram {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+type Counter struct {
+	r *Registry
+	m *metric
+}
+
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+func (c *Counter) Add(v float64, labels ...string) {
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.m.seriesFor(labels).value += v
+}
+
+type Gauge struct {
+	r *Registry
+	m *metric
+}
+
+func (g *Gauge) Set(v float64, labels ...string) {
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	g.m.seriesFor(labels).value = v
+}
+
+// Reset drops every series, e.g. before resampling gauges for processes
+// that may have exited
+func (g *Gauge) Reset() {
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	clear(g.m.series)
+}
+
+type Histogram struct {
+	r *Registry
+	m *metric
+}
+
+func (h *Histogram) Observe(v float64, labels ...string) {
+	h.r.mu.Lock()
+	defer h.r.mu.Unlock()
+
+	s := h.m.seriesFor(labels)
+	s.count++
+	s.sum += v
+	if i, _ := slices.BinarySearch(h.m.buckets, v); i < len(s.counts) {
+		s.counts[i]++
+	}
+}
+
+// Gather runs the OnGather collectors and snapshots every metric. Series are
+// ordered by label values.
+func (r *Registry) Gather() []Family {
+	r.gather.Lock()
+	defer r.gather.Unlock()
+
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+
+	for _, fn := range collectors {
+		fn()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	families := make([]Family, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		family := Family{Name: m.name, Help: m.help, Type: m.typ, Samples: make([]Sample, 0, len(m.series))}
+
+		keys := make([]string, 0, len(m.series))
+		for key := range m.series {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			s := m.series[key]
+			sample := Sample{Value: s.value, Count: s.count, Sum: s.sum}
+			if len(m.labels) > 0 {
+				sample.Labels = make(map[string]string, len(m.labels))
+				for i, name := range m.labels {
+					sample.Labels[name] = s.labels[i]
+				}
+			}
+			if m.typ == TypeHistogram {
+				var cumulative uint64
+				sample.Buckets = make([]Bucket, len(m.buckets))
+				for i, bound := range m.buckets {
+					cumulative += s.counts[i]
+					sample.Buckets[i] = Bucket{UpperBound: bound, Count: cumulative}
+				}
+			}
+			family.Samples = append(family.Samples, sample)
+		}
+
+		families = append(families, family)
+	}
+	return families
+}
+
+// WithLabel returns families with a label added to every sample, e.g. the
+// panel a prismctl's metrics came from
+func WithLabel(families []Family, name, value string) []Family {
+	labelled := make([]Family, len(families))
+	for i, family := range families {
+		labelled[i] = family
+		labelled[i].Samples = make([]Sample, len(family.Samples))
+		for j, sample := range family.Samples {
+			labels := make(map[string]string, len(sample.Labels)+1)
+			for k, v := range sample.Labels {
+				labels[k] = v
+			}
+			labels[name] = value
+			sample.Labels = labels
+			labelled[i].Samples[j] = sample
+		}
+	}
+	return labelled
+}
+
+// Merge combines families with the same name, keeping the order in which
+// names first appear, so that each is exported once
+func Merge(sets ...[]Family) []Family {
+	var merged []Family
+	index := make(map[string]int)
+	for _, families := range sets {
+		for _, family := range families {
+			if i, ok := index[family.Name]; ok {
+				merged[i].Samples = append(merged[i].Samples, family.Samples...)
+				continue
+			}
+			index[family.Name] = len(merged)
+			family.Samples = slices.Clone(family.Samples)
+			merged = append(merged, family)
+		}
+	}
+	return merged
+}
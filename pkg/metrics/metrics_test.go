@@ -0,0 +1,277 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRegistry_Gather(t *testing.T) {
+	r := NewRegistry()
+	restarts := r.Counter("test_restarts_total", "Restarts", "prism")
+	rss := r.Gauge("test_rss_bytes", "Resident memory")
+	latency := r.Histogram("test_latency_seconds", "Latency", []float64{0.1, 1})
+
+	restarts.Inc("clock")
+	restarts.Inc("clock")
+	restarts.Add(3, "bar")
+	rss.Set(4096)
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+	latency.Observe(5)
+
+	families := r.Gather()
+	if len(families) != 3 {
+		t.Fatalf("Gather() returned %d families, want 3", len(families))
+	}
+
+	counter := families[0]
+	if counter.Type != TypeCounter || len(counter.Samples) != 2 {
+		t.Fatalf("counter = %+v", counter)
+	}
+	// Series are ordered by label values
+	if got := counter.Samples[0]; got.Labels["prism"] != "bar" || got.Value != 3 {
+		t.Errorf("first sample = %+v, want bar=3", got)
+	}
+	if got := counter.Samples[1]; got.Labels["prism"] != "clock" || got.Value != 2 {
+		t.Errorf("second sample = %+v, want clock=2", got)
+	}
+
+	if got := families[1].Samples[0]; got.Value != 4096 || got.Labels != nil {
+		t.Errorf("gauge sample = %+v, want unlabelled 4096", got)
+	}
+
+	hist := families[2].Samples[0]
+	if hist.Count != 3 || hist.Sum != 5.55 {
+		t.Errorf("histogram count/sum = %d/%v, want 3/5.55", hist.Count, hist.Sum)
+	}
+	want := []Bucket{{UpperBound: 0.1, Count: 1}, {UpperBound: 1, Count: 2}}
+	for i, b := range want {
+		if hist.Buckets[i] != b {
+			t.Errorf("bucket %d = %+v, want %+v", i, hist.Buckets[i], b)
+		}
+	}
+}
+
+func TestRegistry_OnGather(t *testing.T) {
+	r := NewRegistry()
+	g := r.Gauge("test_sampled", "Sampled value", "pid")
+	g.Set(1, "stale")
+
+	r.OnGather(func() {
+		g.Reset()
+		g.Set(2, "live")
+	})
+
+	samples := r.Gather()[0].Samples
+	if len(samples) != 1 || samples[0].Labels["pid"] != "live" || samples[0].Value != 2 {
+		t.Errorf("samples = %+v, want only live=2", samples)
+	}
+}
+
+func TestRegistry_ConcurrentGather(t *testing.T) {
+	r := NewRegistry()
+	g := r.Gauge("test_sampled", "Sampled value", "pid")
+	pids := []string{"1", "2", "3", "4"}
+
+	r.OnGather(func() {
+		g.Reset()
+		for _, pid := range pids {
+			runtime.Gosched()
+			g.Set(1, pid)
+		}
+	})
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if n := len(r.Gather()[0].Samples); n != len(pids) {
+					t.Errorf("gathered %d samples, want %d", n, len(pids))
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("test_errors_total", "RPC errors", "method").Inc(`prism/"up"`)
+	r.Histogram("test_swap_seconds", "Swap latency", []float64{0.5}).Observe(0.25)
+
+	var b strings.Builder
+	if err := WriteOpenMetrics(&b, r.Gather()); err != nil {
+		t.Fatalf("WriteOpenMetrics() error: %v", err)
+	}
+
+	want := `# TYPE test_errors counter
+# HELP test_errors RPC errors
+test_errors_total{method="prism/\"up\""} 1
+# TYPE test_swap_seconds histogram
+# HELP test_swap_seconds Swap latency
+test_swap_seconds_bucket{le="0.5"} 1
+test_swap_seconds_bucket{le="+Inf"} 1
+test_swap_seconds_count 1
+test_swap_seconds_sum 0.25
+# EOF
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteOpenMetrics() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMergeWithLabel(t *testing.T) {
+	shined := []Family{{Name: "a", Type: TypeCounter, Samples: []Sample{{Value: 1}}}}
+	panel := []Family{
+		{Name: "a", Type: TypeCounter, Samples: []Sample{{Labels: map[string]string{"x": "y"}, Value: 2}}},
+		{Name: "b", Type: TypeGauge, Samples: []Sample{{Value: 3}}},
+	}
+
+	labelled := WithLabel(panel, "panel", "clock")
+	if _, ok := panel[0].Samples[0].Labels["panel"]; ok {
+		t.Error("WithLabel() modified its input")
+	}
+
+	merged := Merge(shined, labelled)
+	if len(merged) != 2 || merged[0].Name != "a" || merged[1].Name != "b" {
+		t.Fatalf("Merge() = %+v, want families a, b", merged)
+	}
+	if len(merged[0].Samples) != 2 {
+		t.Fatalf("family a has %d samples, want 2", len(merged[0].Samples))
+	}
+	if got := merged[0].Samples[1].Labels; got["x"] != "y" || got["panel"] != "clock" {
+		t.Errorf("labels = %v, want x=y, panel=clock", got)
+	}
+	if len(shined[0].Samples) != 1 {
+		t.Error("Merge() modified its input")
+	}
+}
+
+func TestParseProcStat(t *testing.T) {
+	// comm containing spaces and parentheses
+	stat := "1234 (my (odd) prism) S 1 1234 1234 0 -1 4194560 500 0 0 0 " +
+		"250 150 0 0 20 0 1 0 100 123456789 300 18446744073709551615 0 0 0 0 0 0 0 0 0 0 0 0 17 3 0 0 0 0 0"
+
+	usage, err := parseProcStat(stat, 4096)
+	if err != nil {
+		t.Fatalf("parseProcStat() error: %v", err)
+	}
+	if usage.CPUSeconds != 4 {
+		t.Errorf("CPUSeconds = %v, want 4", usage.CPUSeconds)
+	}
+	if usage.RSSBytes != 300*4096 {
+		t.Errorf("RSSBytes = %d, want %d", usage.RSSBytes, 300*4096)
+	}
+	if usage.State != 'S' || usage.PPID != 1 || usage.Threads != 1 {
+		t.Errorf("State/PPID/Threads = %c/%d/%d, want S/1/1", usage.State, usage.PPID, usage.Threads)
+	}
+
+	if _, err := parseProcStat("1234 (truncated) S 1", 4096); err == nil {
+		t.Error("parseProcStat() accepted a truncated line")
+	}
+}
+
+func TestParseProcStatm(t *testing.T) {
+	rss, err := parseProcStatm("5000 300 100 10 0 200 0\n", 4096)
+	if err != nil {
+		t.Fatalf("parseProcStatm() error: %v", err)
+	}
+	if rss != 300*4096 {
+		t.Errorf("rss = %d, want %d", rss, 300*4096)
+	}
+
+	if _, err := parseProcStatm("5000", 4096); err == nil {
+		t.Error("parseProcStatm() accepted a truncated line")
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	// 10 ─┬─ 11 ── 13
+	//     └─ 12
+	// 20 ── 21
+	parents := map[int]int{10: 1, 11: 10, 12: 10, 13: 11, 20: 1, 21: 20}
+
+	got := Descendants(parents, 10)
+	slices.Sort(got)
+	if !slices.Equal(got, []int{11, 12, 13}) {
+		t.Errorf("Descendants(10) = %v, want [11 12 13]", got)
+	}
+	if got := Descendants(parents, 13); len(got) != 0 {
+		t.Errorf("Descendants(13) = %v, want none", got)
+	}
+}
+
+func TestReadProcessUsage_Self(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no /proc")
+	}
+
+	usage, err := ReadProcessUsage(os.Getpid())
+	if err != nil {
+		t.Fatalf("ReadProcessUsage() error: %v", err)
+	}
+	if usage.RSSBytes <= 0 {
+		t.Errorf("RSSBytes = %d, want > 0", usage.RSSBytes)
+	}
+	if usage.PPID != os.Getppid() || usage.Threads < 1 {
+		t.Errorf("PPID/Threads = %d/%d, want %d/>0", usage.PPID, usage.Threads, os.Getppid())
+	}
+
+	parents, err := ReadParents()
+	if err != nil {
+		t.Fatalf("ReadParents() error: %v", err)
+	}
+	if parents[os.Getpid()] != os.Getppid() {
+		t.Errorf("ReadParents()[self] = %d, want %d", parents[os.Getpid()], os.Getppid())
+	}
+}
+
+func TestServe(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "metrics.sock")
+
+	r := NewRegistry()
+	r.Counter("test_scrapes_total", "Scrapes").Inc()
+
+	srv, err := Serve(sockPath, r.Gather)
+	if err != nil {
+		t.Fatalf("Serve() error: %v", err)
+	}
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sockPath)
+		},
+	}}
+
+	resp, err := client.Get("http://shined/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "test_scrapes_total 1\n") || !strings.HasSuffix(string(body), "# EOF\n") {
+		t.Errorf("body = %q", body)
+	}
+
+	srv.Close()
+	if _, err := os.Stat(sockPath); !os.IsNotExist(err) {
+		t.Errorf("socket still exists after Close(): %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of the OpenMetrics text format
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// WriteOpenMetrics renders families in the OpenMetrics text format
+func WriteOpenMetrics(w io.Writer, families []Family) error {
+	bw := bufio.NewWriter(w)
+
+	for _, family := range families {
+		name := family.Name
+		if family.Type == TypeCounter {
+			name = strings.TrimSuffix(name, "_total")
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, family.Type)
+		if family.Help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", name, escapeHelp(family.Help))
+		}
+
+		for _, sample := range family.Samples {
+			switch family.Type {
+			case TypeHistogram:
+				for _, bucket := range sample.Buckets {
+					writeSample(bw, name+"_bucket", sample.Labels, "le", formatFloat(bucket.UpperBound), float64(bucket.Count))
+				}
+				writeSample(bw, name+"_bucket", sample.Labels, "le", "+Inf", float64(sample.Count))
+				writeSample(bw, name+"_count", sample.Labels, "", "", float64(sample.Count))
+				writeSample(bw, name+"_sum", sample.Labels, "", "", sample.Sum)
+			case TypeCounter:
+				writeSample(bw, name+"_total", sample.Labels, "", "", sample.Value)
+			default:
+				writeSample(bw, name, sample.Labels, "", "", sample.Value)
+			}
+		}
+	}
+
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+// writeSample writes one sample line, with an optional extra label such as
+// a histogram bucket's le
+func writeSample(w *bufio.Writer, name string, labels map[string]string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+
+	if len(names) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, k := range names {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", k, escapeLabel(labels[k]))
+		}
+		if extraName != "" {
+			if len(names) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+// Server serves the OpenMetrics text format over HTTP on a unix socket,
+// e.g. for curl --unix-socket or a scraper's unix socket support
+type Server struct {
+	sockPath string
+	http     *http.Server
+}
+
+// Serve starts serving gather's families on sockPath
+func Serve(sockPath string, gather func() []Family) (*Server, error) {
+	if err := os.Remove(sockPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", sockPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", sockPath, err)
+	}
+	if err := os.Chmod(sockPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		if err := WriteOpenMetrics(w, gather()); err != nil {
+			log.Printf("Metrics: failed to write response: %v", err)
+		}
+	})
+
+	s := &Server{sockPath: sockPath, http: &http.Server{Handler: mux}}
+	go func() {
+		if err := s.http.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Metrics: server error: %v", err)
+		}
+	}()
+
+	return s, nil
+}
+
+func (s *Server) Close() error {
+	err := s.http.Close()
+	os.Remove(s.sockPath)
+	return err
+}
+
+func (s *Server) SocketPath() string {
+	return s.sockPath
+}
@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// clockTicks is USER_HZ, the unit of CPU times in /proc/<pid>/stat. Linux
+// fixes it at 100 on every architecture shine runs on.
+const clockTicks = 100
+
+// ProcessUsage is a process's resource usage as read from /proc
+type ProcessUsage struct {
+	CPUSeconds float64 // user + system CPU time consumed
+	RSSBytes   int64   // resident set size
+	Threads    int     // number of threads
+	State      byte    // stat(5) state, e.g. 'R', 'S' or 'T'
+	PPID       int     // parent process ID
+}
+
+// ReadProcessUsage samples a process's CPU time, threads and state from
+// /proc/<pid>/stat and its resident memory from /proc/<pid>/statm
+func ReadProcessUsage(pid int) (ProcessUsage, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ProcessUsage{}, err
+	}
+	usage, err := parseProcStat(string(data), os.Getpagesize())
+	if err != nil {
+		return ProcessUsage{}, err
+	}
+
+	// statm is read separately; a process that exits in between keeps the
+	// RSS from stat
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid)); err == nil {
+		if rss, err := parseProcStatm(string(data), os.Getpagesize()); err == nil {
+			usage.RSSBytes = rss
+		}
+	}
+	return usage, nil
+}
+
+// parseProcStat extracts usage from a /proc/<pid>/stat line. The command
+// name may contain spaces and parentheses, so fields are counted from the
+// last ')'.
+func parseProcStat(stat string, pageSize int) (ProcessUsage, error) {
+	end := strings.LastIndexByte(stat, ')')
+	if end == -1 {
+		return ProcessUsage{}, fmt.Errorf("malformed stat: %q", stat)
+	}
+
+	// fields[0] is the state, field 3 of stat(5)
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return ProcessUsage{}, fmt.Errorf("malformed stat: %d fields", len(fields))
+	}
+
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return ProcessUsage{}, fmt.Errorf("invalid ppid: %w", err)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return ProcessUsage{}, fmt.Errorf("invalid utime: %w", err)
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return ProcessUsage{}, fmt.Errorf("invalid stime: %w", err)
+	}
+	threads, err := strconv.Atoi(fields[17])
+	if err != nil {
+		return ProcessUsage{}, fmt.Errorf("invalid num_threads: %w", err)
+	}
+	rss, err := strconv.ParseInt(fields[21], 10, 64)
+	if err != nil {
+		return ProcessUsage{}, fmt.Errorf("invalid rss: %w", err)
+	}
+
+	return ProcessUsage{
+		CPUSeconds: float64(utime+stime) / clockTicks,
+		RSSBytes:   rss * int64(pageSize),
+		Threads:    threads,
+		State:      fields[0][0],
+		PPID:       ppid,
+	}, nil
+}
+
+// parseProcStatm extracts resident memory, the second field, from a
+// /proc/<pid>/statm line
+func parseProcStatm(statm string, pageSize int) (int64, error) {
+	fields := strings.Fields(statm)
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed statm: %q", statm)
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid resident: %w", err)
+	}
+	return pages * int64(pageSize), nil
+}
+
+// ReadParents maps every process in /proc to its parent. Processes that
+// exit during the scan are skipped.
+func ReadParents() (map[int]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[int]int, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			continue
+		}
+		usage, err := parseProcStat(string(data), 0)
+		if err != nil {
+			continue
+		}
+		parents[pid] = usage.PPID
+	}
+	return parents, nil
+}
+
+// Descendants returns the children, grandchildren and so on of pid
+func Descendants(parents map[int]int, pid int) []int {
+	children := make(map[int][]int)
+	for child, parent := range parents {
+		children[parent] = append(children[parent], child)
+	}
+
+	var found []int
+	queue := []int{pid}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, child := range children[next] {
+			found = append(found, child)
+			queue = append(queue, child)
+		}
+	}
+	return found
+}
+
+// ProcessStateName describes a stat(5) process state
+func ProcessStateName(state byte) string {
+	switch state {
+	case 'R':
+		return "running"
+	case 'S':
+		return "sleeping"
+	case 'D':
+		return "disk-sleep"
+	case 'T':
+		return "stopped"
+	case 't':
+		return "tracing-stop"
+	case 'Z':
+		return "zombie"
+	case 'X', 'x':
+		return "dead"
+	case 'I':
+		return "idle"
+	case 0:
+		return ""
+	}
+	return string(state)
+}
@@ -6,6 +6,8 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+
+	"github.com/starbased-co/shine/pkg/kitty"
 )
 
 type LayerType int
@@ -376,6 +378,7 @@ func (c *Config) calculateMargins() (top, left, bottom, right int, err error) {
 	return top, left, bottom, right, nil
 }
 
+// ToPanelArgs returns kitten arguments launching componentPath in this panel
 func (c *Config) ToPanelArgs(componentPath string) []string {
 	args := []string{
 		"@",
@@ -383,6 +386,31 @@ func (c *Config) ToPanelArgs(componentPath string) []string {
 		"--type=os-panel",
 	}
 
+	for _, prop := range c.osPanelProps() {
+		args = append(args, "--os-panel", prop)
+	}
+
+	if c.WindowTitle != "" {
+		args = append(args, "--title", c.WindowTitle)
+	}
+
+	args = append(args, componentPath)
+
+	return args
+}
+
+// ToLaunchRequest is ToPanelArgs as a remote-control launch command
+func (c *Config) ToLaunchRequest(args ...string) *kitty.LaunchRequest {
+	return &kitty.LaunchRequest{
+		Type:        "os-panel",
+		Args:        args,
+		WindowTitle: c.WindowTitle,
+		OSPanel:     c.osPanelProps(),
+	}
+}
+
+// osPanelProps returns the key=value settings for kitty's --os-panel
+func (c *Config) osPanelProps() []string {
 	panelProps := []string{}
 
 	edgeStr := c.originToEdge()
@@ -421,16 +449,5 @@ func (c *Config) ToPanelArgs(componentPath string) []string {
 		panelProps = append(panelProps, fmt.Sprintf("output-name=%s", c.OutputName))
 	}
 
-	for _, prop := range panelProps {
-		args = append(args, "--os-panel", prop)
-	}
-
-	if c.WindowTitle != "" {
-		args = append(args, "--title", c.WindowTitle)
-	}
-
-	args = append(args, componentPath)
-
-	return args
+	return panelProps
 }
-
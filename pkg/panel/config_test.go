@@ -1,6 +1,7 @@
 package panel
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -454,6 +455,38 @@ func TestToPanelArgs_WithTitle(t *testing.T) {
 	}
 }
 
+func TestToLaunchRequest(t *testing.T) {
+	cfg := &Config{
+		Type:        LayerShellPanel,
+		Origin:      OriginTopRight,
+		Width:       Dimension{Value: 400, IsPixels: true},
+		Height:      Dimension{Value: 2},
+		FocusPolicy: FocusOnDemand,
+		WindowTitle: "clock",
+	}
+
+	req := cfg.ToLaunchRequest("/usr/bin/prismctl", "clock-0")
+
+	if req.Type != "os-panel" || req.WindowTitle != "clock" {
+		t.Errorf("ToLaunchRequest() = %+v", req)
+	}
+	if len(req.Args) != 2 || req.Args[0] != "/usr/bin/prismctl" || req.Args[1] != "clock-0" {
+		t.Errorf("ToLaunchRequest() args = %v", req.Args)
+	}
+
+	// The panel settings are the same ones kitten gets as --os-panel
+	args := cfg.ToPanelArgs("/usr/bin/prismctl")
+	var props []string
+	for i, arg := range args {
+		if arg == "--os-panel" && i+1 < len(args) {
+			props = append(props, args[i+1])
+		}
+	}
+	if strings.Join(req.OSPanel, " ") != strings.Join(props, " ") {
+		t.Errorf("ToLaunchRequest() os_panel = %v, want %v", req.OSPanel, props)
+	}
+}
+
 func TestOriginCenterSized(t *testing.T) {
 	t.Run("String conversion", func(t *testing.T) {
 		if OriginCenterSized.String() != "center-sized" {
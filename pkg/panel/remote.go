@@ -1,94 +1,42 @@
 package panel
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net"
-	"os/exec"
+
+	"github.com/starbased-co/shine/pkg/kitty"
 )
 
+// RemoteControl drives the kitty listening on socketPath
 type RemoteControl struct {
-	socketPath string
+	client *kitty.Client
 }
 
-func NewRemoteControl(socketPath string) *RemoteControl {
-	return &RemoteControl{
-		socketPath: socketPath,
+func NewRemoteControl(socketPath string) (*RemoteControl, error) {
+	client, err := kitty.NewClient("unix:" + socketPath)
+	if err != nil {
+		return nil, err
 	}
+	return &RemoteControl{client: client}, nil
 }
 
 func (rc *RemoteControl) ToggleVisibility() error {
-	conn, err := net.Dial("unix", rc.socketPath)
-	if err != nil {
-		return fmt.Errorf("failed to connect to %s: %w", rc.socketPath, err)
-	}
-	defer conn.Close()
-
-	payload := map[string]interface{}{
-		"cmd":    "resize-os-window",
-		"action": "toggle-visibility",
-	}
-
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to encode command: %w", err)
-	}
-
-	_, err = conn.Write(data)
-	if err != nil {
-		return fmt.Errorf("failed to send command: %w", err)
-	}
-
-	return nil
+	return rc.resize(kitty.ActionToggleVisibility)
 }
 
 func (rc *RemoteControl) Show() error {
-	conn, err := net.Dial("unix", rc.socketPath)
-	if err != nil {
-		return fmt.Errorf("failed to connect to %s: %w", rc.socketPath, err)
-	}
-	defer conn.Close()
-
-	payload := map[string]interface{}{
-		"cmd":    "resize-os-window",
-		"action": "show",
-	}
-
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to encode command: %w", err)
-	}
-
-	_, err = conn.Write(data)
-	if err != nil {
-		return fmt.Errorf("failed to send command: %w", err)
-	}
-
-	return nil
+	return rc.resize(kitty.ActionShow)
 }
 
 func (rc *RemoteControl) Hide() error {
-	conn, err := net.Dial("unix", rc.socketPath)
-	if err != nil {
-		return fmt.Errorf("failed to connect to %s: %w", rc.socketPath, err)
-	}
-	defer conn.Close()
-
-	payload := map[string]interface{}{
-		"cmd":    "resize-os-window",
-		"action": "hide",
-	}
-
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to encode command: %w", err)
-	}
+	return rc.resize(kitty.ActionHide)
+}
 
-	_, err = conn.Write(data)
+func (rc *RemoteControl) resize(action string) error {
+	err := rc.client.ResizeOSWindow(context.Background(), &kitty.ResizeOSWindowRequest{Action: action})
 	if err != nil {
-		return fmt.Errorf("failed to send command: %w", err)
+		return fmt.Errorf("failed to %s panel: %w", action, err)
 	}
-
 	return nil
 }
 
@@ -98,10 +46,8 @@ type WindowInfo struct {
 }
 
 func (rc *RemoteControl) CloseWindow(windowTitle string) error {
-	cmd := exec.Command("kitty", "@", "--to", "unix:"+rc.socketPath,
-		"close-window", "--match", "title:"+windowTitle)
-
-	if err := cmd.Run(); err != nil {
+	err := rc.client.CloseWindow(context.Background(), &kitty.CloseWindowRequest{Match: "title:" + windowTitle})
+	if err != nil {
 		return fmt.Errorf("failed to close window %s: %w", windowTitle, err)
 	}
 
@@ -109,10 +55,8 @@ func (rc *RemoteControl) CloseWindow(windowTitle string) error {
 }
 
 func (rc *RemoteControl) FocusWindow(windowTitle string) error {
-	cmd := exec.Command("kitty", "@", "--to", "unix:"+rc.socketPath,
-		"focus-window", "--match", "title:"+windowTitle)
-
-	if err := cmd.Run(); err != nil {
+	err := rc.client.FocusWindow(context.Background(), &kitty.FocusWindowRequest{Match: "title:" + windowTitle})
+	if err != nil {
 		return fmt.Errorf("failed to focus window %s: %w", windowTitle, err)
 	}
 
@@ -120,27 +64,14 @@ func (rc *RemoteControl) FocusWindow(windowTitle string) error {
 }
 
 func (rc *RemoteControl) ListWindows() ([]WindowInfo, error) {
-	cmd := exec.Command("kitty", "@", "--to", "unix:"+rc.socketPath, "ls")
-	output, err := cmd.Output()
+	osWindows, err := rc.client.LS(context.Background(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list windows: %w", err)
 	}
 
-	var osWindows []struct {
-		Tabs []struct {
-			Windows []WindowInfo `json:"windows"`
-		} `json:"tabs"`
-	}
-
-	if err := json.Unmarshal(output, &osWindows); err != nil {
-		return nil, fmt.Errorf("failed to parse window list: %w", err)
-	}
-
 	var windows []WindowInfo
-	for _, osWin := range osWindows {
-		for _, tab := range osWin.Tabs {
-			windows = append(windows, tab.Windows...)
-		}
+	for _, win := range kitty.Windows(osWindows) {
+		windows = append(windows, WindowInfo{ID: win.ID, Title: win.Title})
 	}
 
 	return windows, nil
@@ -53,6 +53,11 @@ func PrismSocket(instance string) string {
 	return filepath.Join(RuntimeDir(), fmt.Sprintf("prism-%s.sock", instance))
 }
 
+// PrismAttachSocket is the streaming socket `shine attach` connects to
+func PrismAttachSocket(instance string) string {
+	return filepath.Join(RuntimeDir(), fmt.Sprintf("prism-%s.attach.sock", instance))
+}
+
 func PrismState(instance string) string {
 	return filepath.Join(RuntimeDir(), fmt.Sprintf("prism-%s.state", instance))
 }
@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// The attach protocol streams a panel's foreground prism over prismctl's
+// attach socket. It is not JSON-RPC: the client sends one AttachRequest line,
+// prismctl answers with one AttachResponse line, and the connection then
+// carries raw terminal bytes, prism output to the client and, unless
+// read-only, client input to the foreground prism. When the panel is resized
+// the output carries an AttachResizeNotice followed by a repaint.
+
+// AttachResizeSequence starts the notice prismctl writes into an attached
+// stream when the panel is resized: the sequence, ";<cols>;<rows>" and BEL
+const AttachResizeSequence = "\x1b]777;shine;resize"
+
+// AttachResizeNotice returns the in-band notice of a resize to cols x rows
+func AttachResizeNotice(cols, rows int) string {
+	return fmt.Sprintf("%s;%d;%d\x07", AttachResizeSequence, cols, rows)
+}
+
+type AttachRequest struct {
+	ReadOnly bool `json:"read_only,omitempty"`
+}
+
+type AttachResponse struct {
+	Prism string `json:"prism,omitempty"` // foreground prism, empty while idle
+	Cols  int    `json:"cols"`
+	Rows  int    `json:"rows"`
+	Error string `json:"error,omitempty"`
+}
+
+// AttachConn is an attached stream. Reads return prism output; writes are
+// delivered to the foreground prism as input.
+type AttachConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *AttachConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// DialAttach connects to a prismctl attach socket and completes the handshake
+func DialAttach(sockPath string, req *AttachRequest, timeout time.Duration) (*AttachConn, *AttachResponse, error) {
+	conn, err := net.DialTimeout("unix", sockPath, timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s: %w", sockPath, err)
+	}
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := WriteAttachMessage(conn, req); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	var resp AttachResponse
+	if err := ReadAttachMessage(r, &resp); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	if resp.Error != "" {
+		conn.Close()
+		return nil, nil, fmt.Errorf("attach refused: %s", resp.Error)
+	}
+
+	return &AttachConn{Conn: conn, r: r}, &resp, nil
+}
+
+// WriteAttachMessage writes one handshake message as a JSON line
+func WriteAttachMessage(w io.Writer, msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode attach message: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to send attach message: %w", err)
+	}
+	return nil
+}
+
+// ReadAttachMessage reads one handshake JSON line. r must be buffered so
+// that stream bytes following the line are not lost.
+func ReadAttachMessage(r *bufio.Reader, msg any) error {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read attach message: %w", err)
+	}
+	if err := json.Unmarshal(line, msg); err != nil {
+		return fmt.Errorf("invalid attach message: %w", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/creachadair/jrpc2"
@@ -11,10 +12,12 @@ import (
 )
 
 type Client struct {
-	sockPath string
-	conn     net.Conn
-	client   *jrpc2.Client
-	timeout  time.Duration
+	sockPath     string
+	conn         net.Conn
+	client       *jrpc2.Client
+	timeout      time.Duration
+	onDisconnect func(error)
+	closing      atomic.Bool
 }
 
 type ClientOption func(*Client)
@@ -25,6 +28,15 @@ func WithTimeout(d time.Duration) ClientOption {
 	}
 }
 
+// WithOnDisconnect registers fn to be called when the server side of the
+// connection goes away. It is not called after Close. fn runs on the
+// client's reader goroutine and must not block.
+func WithOnDisconnect(fn func(err error)) ClientOption {
+	return func(c *Client) {
+		c.onDisconnect = fn
+	}
+}
+
 func NewClient(sockPath string, opts ...ClientOption) (*Client, error) {
 	c := &Client{
 		sockPath: sockPath,
@@ -40,14 +52,26 @@ func NewClient(sockPath string, opts ...ClientOption) (*Client, error) {
 		return nil, fmt.Errorf("failed to connect to %s: %w", sockPath, err)
 	}
 
+	var clientOpts *jrpc2.ClientOptions
+	if c.onDisconnect != nil {
+		clientOpts = &jrpc2.ClientOptions{
+			OnStop: func(_ *jrpc2.Client, err error) {
+				if !c.closing.Load() {
+					c.onDisconnect(err)
+				}
+			},
+		}
+	}
+
 	ch := channel.Line(conn, conn)
 	c.conn = conn
-	c.client = jrpc2.NewClient(ch, nil)
+	c.client = jrpc2.NewClient(ch, clientOpts)
 
 	return c, nil
 }
 
 func (c *Client) Close() error {
+	c.closing.Store(true)
 	if c.client != nil {
 		c.client.Close()
 	}
@@ -101,6 +125,30 @@ func (c *PrismClient) Bg(ctx context.Context, name string) (*BgResult, error) {
 	return &result, err
 }
 
+func (c *PrismClient) Restart(ctx context.Context, name string) (*RestartResult, error) {
+	var result RestartResult
+	err := c.Call(ctx, "prism/restart", &RestartRequest{Name: name}, &result)
+	return &result, err
+}
+
+func (c *PrismClient) Send(ctx context.Context, req *SendRequest) (*SendResult, error) {
+	var result SendResult
+	err := c.Call(ctx, "prism/send", req, &result)
+	return &result, err
+}
+
+func (c *PrismClient) Capture(ctx context.Context, req *CaptureRequest) (*CaptureResult, error) {
+	var result CaptureResult
+	err := c.Call(ctx, "prism/capture", req, &result)
+	return &result, err
+}
+
+func (c *PrismClient) Signal(ctx context.Context, name, signal string) (*SignalResult, error) {
+	var result SignalResult
+	err := c.Call(ctx, "prism/signal", &SignalRequest{Name: name, Signal: signal}, &result)
+	return &result, err
+}
+
 func (c *PrismClient) List(ctx context.Context) (*ListResult, error) {
 	var result ListResult
 	err := c.Call(ctx, "prism/list", nil, &result)
@@ -119,9 +167,27 @@ func (c *PrismClient) Shutdown(ctx context.Context, graceful bool) (*ShutdownRes
 	return &result, err
 }
 
-func (c *PrismClient) Configure(ctx context.Context, apps []AppInfo) (*ConfigureResult, error) {
+func (c *PrismClient) Resize(ctx context.Context, cols, rows int) (*ResizeResult, error) {
+	var result ResizeResult
+	err := c.Call(ctx, "service/resize", &ResizeRequest{Cols: cols, Rows: rows}, &result)
+	return &result, err
+}
+
+func (c *PrismClient) Metrics(ctx context.Context) (*MetricsResult, error) {
+	var result MetricsResult
+	err := c.Call(ctx, "service/metrics", nil, &result)
+	return &result, err
+}
+
+func (c *PrismClient) Notice(ctx context.Context, text string) (*NoticeResult, error) {
+	var result NoticeResult
+	err := c.Call(ctx, "service/notice", &NoticeRequest{Text: text}, &result)
+	return &result, err
+}
+
+func (c *PrismClient) Configure(ctx context.Context, req *ConfigureRequest) (*ConfigureResult, error) {
 	var result ConfigureResult
-	err := c.Call(ctx, "prism/configure", &ConfigureRequest{Apps: apps}, &result)
+	err := c.Call(ctx, "prism/configure", req, &result)
 	return &result, err
 }
 
@@ -149,24 +215,108 @@ func (c *ShinedClient) SpawnPanel(ctx context.Context, config map[string]any) (*
 	return &result, err
 }
 
+func (c *ShinedClient) RegisterPanel(ctx context.Context, req *PanelRegisterRequest) (*PanelRegisterResult, error) {
+	var result PanelRegisterResult
+	err := c.Call(ctx, "panel/register", req, &result)
+	return &result, err
+}
+
 func (c *ShinedClient) KillPanel(ctx context.Context, instance string) (*PanelKillResult, error) {
 	var result PanelKillResult
 	err := c.Call(ctx, "panel/kill", &PanelKillRequest{Instance: instance}, &result)
 	return &result, err
 }
 
+func (c *ShinedClient) ShowPanel(ctx context.Context, instance string) (*PanelWindowResult, error) {
+	var result PanelWindowResult
+	err := c.Call(ctx, "panel/show", &PanelWindowRequest{Instance: instance}, &result)
+	return &result, err
+}
+
+func (c *ShinedClient) HidePanel(ctx context.Context, instance string) (*PanelWindowResult, error) {
+	var result PanelWindowResult
+	err := c.Call(ctx, "panel/hide", &PanelWindowRequest{Instance: instance}, &result)
+	return &result, err
+}
+
+func (c *ShinedClient) ResizePanel(ctx context.Context, instance string, cols, rows int) (*PanelWindowResult, error) {
+	var result PanelWindowResult
+	err := c.Call(ctx, "panel/resize", &PanelResizeRequest{Instance: instance, Cols: cols, Rows: rows}, &result)
+	return &result, err
+}
+
 func (c *ShinedClient) Status(ctx context.Context) (*ServiceStatusResult, error) {
 	var result ServiceStatusResult
 	err := c.Call(ctx, "service/status", nil, &result)
 	return &result, err
 }
 
+func (c *ShinedClient) Metrics(ctx context.Context) (*MetricsResult, error) {
+	var result MetricsResult
+	err := c.Call(ctx, "service/metrics", nil, &result)
+	return &result, err
+}
+
 func (c *ShinedClient) Reload(ctx context.Context) (*ConfigReloadResult, error) {
 	var result ConfigReloadResult
 	err := c.Call(ctx, "config/reload", nil, &result)
 	return &result, err
 }
 
+func (c *ShinedClient) PrismUp(ctx context.Context, panel, name string) (*UpResult, error) {
+	var result UpResult
+	err := c.Call(ctx, "prism/up", &PanelPrismRequest{Panel: panel, Name: name}, &result)
+	return &result, err
+}
+
+func (c *ShinedClient) PrismDown(ctx context.Context, panel, name string) (*DownResult, error) {
+	var result DownResult
+	err := c.Call(ctx, "prism/down", &PanelPrismRequest{Panel: panel, Name: name}, &result)
+	return &result, err
+}
+
+func (c *ShinedClient) PrismFg(ctx context.Context, panel, name string) (*FgResult, error) {
+	var result FgResult
+	err := c.Call(ctx, "prism/fg", &PanelPrismRequest{Panel: panel, Name: name}, &result)
+	return &result, err
+}
+
+func (c *ShinedClient) PrismBg(ctx context.Context, panel, name string) (*BgResult, error) {
+	var result BgResult
+	err := c.Call(ctx, "prism/bg", &PanelPrismRequest{Panel: panel, Name: name}, &result)
+	return &result, err
+}
+
+func (c *ShinedClient) PrismRestart(ctx context.Context, panel, name string) (*RestartResult, error) {
+	var result RestartResult
+	err := c.Call(ctx, "prism/restart", &PanelPrismRequest{Panel: panel, Name: name}, &result)
+	return &result, err
+}
+
+func (c *ShinedClient) PrismSignal(ctx context.Context, panel, name, signal string) (*SignalResult, error) {
+	var result SignalResult
+	err := c.Call(ctx, "prism/signal", &PanelSignalRequest{Panel: panel, Name: name, Signal: signal}, &result)
+	return &result, err
+}
+
+func (c *ShinedClient) PrismSend(ctx context.Context, req *PanelSendRequest) (*SendResult, error) {
+	var result SendResult
+	err := c.Call(ctx, "prism/send", req, &result)
+	return &result, err
+}
+
+func (c *ShinedClient) PrismCapture(ctx context.Context, req *PanelCaptureRequest) (*CaptureResult, error) {
+	var result CaptureResult
+	err := c.Call(ctx, "prism/capture", req, &result)
+	return &result, err
+}
+
+func (c *ShinedClient) PrismList(ctx context.Context, panel string) (*ListResult, error) {
+	var result ListResult
+	err := c.Call(ctx, "prism/list", &PanelRequest{Panel: panel}, &result)
+	return &result, err
+}
+
 func (c *ShinedClient) NotifyPrismStarted(ctx context.Context, panel, name string, pid int) error {
 	return c.Notify(ctx, "prism/started", &PrismStartedNotification{
 		Panel: panel,
@@ -183,15 +333,34 @@ func (c *ShinedClient) NotifyPrismStopped(ctx context.Context, panel, name strin
 	})
 }
 
-func (c *ShinedClient) NotifyPrismCrashed(ctx context.Context, panel, name string, exitCode, signal int) error {
+func (c *ShinedClient) NotifyPrismCrashed(ctx context.Context, panel, name string, exitCode, signal int, reason string) error {
 	return c.Notify(ctx, "prism/crashed", &PrismCrashedNotification{
 		Panel:    panel,
 		Name:     name,
 		ExitCode: exitCode,
 		Signal:   signal,
+		Reason:   reason,
 	})
 }
 
+func (c *ShinedClient) NotifyPrismHealth(ctx context.Context, panel, name, status, message string) error {
+	return c.Notify(ctx, "prism/health", &PrismHealthNotification{
+		Panel:   panel,
+		Name:    name,
+		Status:  status,
+		Message: message,
+	})
+}
+
+// NotifyPanelClosing waits for shined to acknowledge, unlike the other
+// notifications, so the reason is recorded before prismctl's socket closes
+func (c *ShinedClient) NotifyPanelClosing(ctx context.Context, panel, reason string) error {
+	return c.Call(ctx, "panel/closing", &PanelClosingNotification{
+		Panel:  panel,
+		Reason: reason,
+	}, nil)
+}
+
 func (c *ShinedClient) NotifyForegroundChanged(ctx context.Context, panel, from, to string) error {
 	return c.Notify(ctx, "foreground/changed", &ForegroundChangedNotification{
 		Panel: panel,
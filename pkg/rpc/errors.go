@@ -1,6 +1,8 @@
 package rpc
 
 import (
+	"errors"
+
 	"github.com/creachadair/jrpc2"
 )
 
@@ -67,3 +69,13 @@ func ErrInternal(err error) error {
 func ErrNotImplemented(method string) error {
 	return jrpc2.Errorf(CodeNotImplemented, "method not implemented: %s", method)
 }
+
+// ErrorCode returns the JSON-RPC error code carried by err, or 0 if err is
+// not a JSON-RPC error
+func ErrorCode(err error) int {
+	var rpcErr *jrpc2.Error
+	if errors.As(err, &rpcErr) {
+		return int(rpcErr.Code)
+	}
+	return 0
+}
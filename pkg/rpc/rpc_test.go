@@ -166,6 +166,62 @@ func TestServerStop(t *testing.T) {
 	}
 }
 
+func TestClientOnDisconnect(t *testing.T) {
+	tmpDir := t.TempDir()
+	sockPath := filepath.Join(tmpDir, "test.sock")
+
+	srv := NewServer(sockPath, handler.Map{}, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+
+	disconnected := make(chan struct{}, 1)
+	client, err := NewClient(sockPath, WithOnDisconnect(func(err error) {
+		disconnected <- struct{}{}
+	}))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	// Let the server register the connection before stopping it
+	time.Sleep(10 * time.Millisecond)
+	srv.Stop(context.Background())
+
+	select {
+	case <-disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnDisconnect not called after server stopped")
+	}
+}
+
+func TestClientCloseDoesNotFireOnDisconnect(t *testing.T) {
+	tmpDir := t.TempDir()
+	sockPath := filepath.Join(tmpDir, "test.sock")
+
+	srv := NewServer(sockPath, handler.Map{}, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer srv.Stop(context.Background())
+
+	fired := make(chan struct{}, 1)
+	client, err := NewClient(sockPath, WithOnDisconnect(func(err error) {
+		fired <- struct{}{}
+	}))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+
+	client.Close()
+
+	select {
+	case <-fired:
+		t.Error("OnDisconnect called after Close")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 func TestPrismClientMethods(t *testing.T) {
 	tmpDir := t.TempDir()
 	sockPath := filepath.Join(tmpDir, "test.sock")
@@ -412,3 +468,51 @@ func TestErrorCodes(t *testing.T) {
 		})
 	}
 }
+
+func TestInstrument(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "test.sock")
+
+	type call struct {
+		method string
+		failed bool
+	}
+	calls := make(chan call, 2)
+
+	mux := Instrument(handler.Map{
+		"ok": handler.New(func(ctx context.Context) (string, error) {
+			return "fine", nil
+		}),
+		"fail": handler.New(func(ctx context.Context) (string, error) {
+			return "", ErrInternal(nil)
+		}),
+	}, func(method string, elapsed time.Duration, err error) {
+		calls <- call{method, err != nil}
+	})
+
+	srv := NewServer(sockPath, mux, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer srv.Stop(context.Background())
+
+	client, err := NewClient(sockPath)
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	var result string
+	if err := client.Call(context.Background(), "ok", nil, &result); err != nil || result != "fine" {
+		t.Fatalf("ok call = %q, %v", result, err)
+	}
+	if got := <-calls; got != (call{"ok", false}) {
+		t.Errorf("observed %+v, want ok succeeded", got)
+	}
+
+	if err := client.Call(context.Background(), "fail", nil, &result); err == nil {
+		t.Fatal("fail call succeeded")
+	}
+	if got := <-calls; got != (call{"fail", true}) {
+		t.Errorf("observed %+v, want fail failed", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/creachadair/jrpc2"
 	"github.com/creachadair/jrpc2/channel"
@@ -145,6 +146,21 @@ func (s *Server) Running() bool {
 	return s.running
 }
 
+// Instrument wraps every handler in mux to report how long each call took,
+// e.g. to record per-method RPC latency
+func Instrument(mux handler.Map, observe func(method string, elapsed time.Duration, err error)) handler.Map {
+	wrapped := make(handler.Map, len(mux))
+	for method, h := range mux {
+		wrapped[method] = func(ctx context.Context, req *jrpc2.Request) (any, error) {
+			start := time.Now()
+			result, err := h(ctx, req)
+			observe(method, time.Since(start), err)
+			return result, err
+		}
+	}
+	return wrapped
+}
+
 func Handler[P, R any](fn func(context.Context, *P) (R, error)) handler.Func {
 	return handler.New(fn)
 }
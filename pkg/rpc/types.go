@@ -1,19 +1,45 @@
 package rpc
 
+import "github.com/starbased-co/shine/pkg/metrics"
+
 type PrismInfo struct {
-	Name     string `json:"name"`
-	PID      int    `json:"pid"`
-	State    string `json:"state"`     // "fg" or "bg"
-	UptimeMs int64  `json:"uptime_ms"` // milliseconds since start
-	Restarts int    `json:"restarts"`  // restart count
+	Name          string      `json:"name"`
+	PID           int         `json:"pid"`
+	State         string      `json:"state"`                    // "fg" or "bg"
+	UptimeMs      int64       `json:"uptime_ms"`                // milliseconds since start
+	Restarts      int         `json:"restarts"`                 // restart count
+	Starting      bool        `json:"starting,omitempty"`       // launched but not yet ready
+	Health        string      `json:"health,omitempty"`         // HealthStarting, HealthHealthy, HealthUnhealthy; empty if unchecked
+	HealthMessage string      `json:"health_message,omitempty"` // reason for the last failed check
+	Usage         *PrismUsage `json:"usage,omitempty"`          // resource usage, nil until first sampled
+}
+
+// PrismUsage is a prism's resource usage, sampled from /proc. CPU, memory
+// and threads cover the prism and every process it started.
+type PrismUsage struct {
+	CPUPercent float64 `json:"cpu_percent"` // over the last sample interval; may exceed 100 on several cores
+	RSSBytes   int64   `json:"rss_bytes"`   // resident memory
+	Threads    int     `json:"threads"`     // threads across the process tree
+	Children   int     `json:"children"`    // descendant processes
+	ProcState  string  `json:"proc_state"`  // state of the prism's own process, e.g. "running" or "stopped"
 }
 
+// Prism health states reported by prismctl health checks
+const (
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
 type PanelInfo struct {
-	Instance string `json:"instance"` // unique panel identifier
-	Name     string `json:"name"`     // human-readable name
-	PID      int    `json:"pid"`      // prismctl process PID
-	Socket   string `json:"socket"`   // path to prismctl socket
-	Healthy  bool   `json:"healthy"`  // health check status
+	Instance      string `json:"instance"`                  // unique panel identifier
+	Name          string `json:"name"`                      // human-readable name
+	PID           int    `json:"pid"`                       // prismctl process PID
+	Socket        string `json:"socket"`                    // path to prismctl socket
+	Healthy       bool   `json:"healthy"`                   // cached result of the last health check
+	LastCheckMs   int64  `json:"last_check_ms,omitempty"`   // unix ms of the last health check
+	LastHealthyMs int64  `json:"last_healthy_ms,omitempty"` // unix ms of the last passing check
+	HealthError   string `json:"health_error,omitempty"`    // reason for the last failed check
 }
 
 type UpRequest struct {
@@ -51,21 +77,180 @@ type BgResult struct {
 	WasBg bool `json:"was_bg"` // true if already background (idempotent)
 }
 
-type AppInfo struct {
+type RestartRequest struct {
+	Name string `json:"name"`
+}
+
+type RestartResult struct {
+	PID      int `json:"pid"`      // PID of the relaunched prism
+	Restarts int `json:"restarts"` // restart count after this restart
+}
+
+type SignalRequest struct {
+	Name   string `json:"name"`
+	Signal string `json:"signal"` // name ("SIGUSR1", "USR1") or number
+}
+
+type SignalResult struct {
+	PID    int    `json:"pid"`    // PID the signal was delivered to
+	Signal string `json:"signal"` // canonical signal name
+}
+
+// SendRequest writes input into a prism's PTY. Text is written first
+// (wrapped in bracketed-paste markers when Paste is set), then Keys, then Data.
+type SendRequest struct {
+	Name  string   `json:"name"`
+	Text  string   `json:"text,omitempty"`  // literal text
+	Keys  []string `json:"keys,omitempty"`  // key names ("Enter", "C-c", "Up"); unknown names are sent as text
+	Data  []byte   `json:"data,omitempty"`  // raw bytes, base64 in JSON
+	Paste bool     `json:"paste,omitempty"` // wrap Text in bracketed-paste markers
+}
+
+type SendResult struct {
+	Bytes int `json:"bytes"` // bytes written to the PTY
+}
+
+// CaptureRequest asks for a prism's rendered screen. Name defaults to the
+// foreground prism.
+type CaptureRequest struct {
+	Name       string `json:"name,omitempty"`
+	Format     string `json:"format,omitempty"`     // "text" (default), "ansi", "html" or "svg"
+	Scrollback bool   `json:"scrollback,omitempty"` // include lines scrolled off the top
+}
+
+type CaptureResult struct {
 	Name    string `json:"name"`
-	Path    string `json:"path"`    // resolved binary path
-	Enabled bool   `json:"enabled"`
+	Format  string `json:"format"`
+	Cols    int    `json:"cols"`
+	Rows    int    `json:"rows"`
+	Content string `json:"content"`
 }
 
+// PanelPrismRequest addresses a prism inside a panel via shined
+type PanelPrismRequest struct {
+	Panel string `json:"panel"` // panel instance
+	Name  string `json:"name"`  // prism name
+}
+
+type PanelSignalRequest struct {
+	Panel  string `json:"panel"`
+	Name   string `json:"name"`
+	Signal string `json:"signal"`
+}
+
+type PanelSendRequest struct {
+	Panel string   `json:"panel"`
+	Name  string   `json:"name"`
+	Text  string   `json:"text,omitempty"`
+	Keys  []string `json:"keys,omitempty"`
+	Data  []byte   `json:"data,omitempty"`
+	Paste bool     `json:"paste,omitempty"`
+}
+
+type PanelCaptureRequest struct {
+	Panel      string `json:"panel"`
+	Name       string `json:"name,omitempty"`
+	Format     string `json:"format,omitempty"`
+	Scrollback bool   `json:"scrollback,omitempty"`
+}
+
+type PanelRequest struct {
+	Panel string `json:"panel"`
+}
+
+type AppInfo struct {
+	Name    string           `json:"name"`
+	Path    string           `json:"path"` // resolved binary path
+	Args    []string         `json:"args,omitempty"`
+	Env     []string         `json:"env,omitempty"` // KEY=VALUE, added to prismctl's environment
+	Enabled bool             `json:"enabled"`
+	Sandbox *SandboxSpec     `json:"sandbox,omitempty"`
+	Health  *HealthCheckSpec `json:"health,omitempty"`
+	Ready   *ReadySpec       `json:"ready,omitempty"`
+	Lazy    bool             `json:"lazy,omitempty"` // registered, launched on first prism/fg
+}
+
+// ReadySpec is the resolved form of an app's [ready] section. An app with
+// one counts as started only once it signals readiness.
+type ReadySpec struct {
+	Type      string `json:"type"`       // ReadyNotify or ReadyOSC
+	TimeoutMs int64  `json:"timeout_ms"` // startup fails if not ready by then
+}
+
+// Ready signal types
+const (
+	ReadyNotify = "notify" // sd_notify READY=1 datagram on $NOTIFY_SOCKET
+	ReadyOSC    = "osc"    // ReadyOSCSequence written to the terminal
+)
+
+// ReadyOSCSequence signals readiness when written to a prism's terminal,
+// terminated by BEL or ST. Terminals ignore it.
+const ReadyOSCSequence = "\x1b]777;shine;ready"
+
+// HealthCheckSpec is the resolved form of an app's [health] section.
+// All configured checks must pass for the app to be healthy.
+type HealthCheckSpec struct {
+	Command        string `json:"command,omitempty"`          // run via sh -c, exit 0 = healthy
+	File           string `json:"file,omitempty"`             // liveness file path
+	MaxAgeMs       int64  `json:"max_age_ms,omitempty"`       // max age of File's mtime
+	OutputWithinMs int64  `json:"output_within_ms,omitempty"` // PTY output required within this window
+	IntervalMs     int64  `json:"interval_ms"`
+	TimeoutMs      int64  `json:"timeout_ms"`
+	Retries        int    `json:"retries"` // consecutive failures before unhealthy
+	Restart        bool   `json:"restart,omitempty"`
+}
+
+// SandboxSpec is the resolved form of a prism's [sandbox] section.
+// Zero values impose no limit.
+type SandboxSpec struct {
+	MemoryBytes    int64    `json:"memory_bytes,omitempty"`     // RLIMIT_AS
+	CPUSeconds     uint64   `json:"cpu_seconds,omitempty"`      // RLIMIT_CPU
+	OpenFiles      uint64   `json:"open_files,omitempty"`       // RLIMIT_NOFILE
+	MemoryMaxBytes int64    `json:"memory_max_bytes,omitempty"` // cgroup memory.max
+	CPUMaxPercent  int      `json:"cpu_max_percent,omitempty"`  // cgroup cpu.max, percent of one CPU
+	NoNewPrivs     bool     `json:"no_new_privs,omitempty"`
+	NoNetwork      bool     `json:"no_network,omitempty"`
+	ReadOnlyHome   bool     `json:"read_only_home,omitempty"`
+	WritablePaths  []string `json:"writable_paths,omitempty"`
+}
+
+// ConfigureRequest lists a prism's apps in launch order. It is the whole
+// desired app set: prismctl stops registered apps that are not listed,
+// restarts those whose launch spec changed and starts new ones.
 type ConfigureRequest struct {
-	Apps []AppInfo `json:"apps"`
+	Apps    []AppInfo `json:"apps"`
+	Idle    *IdleSpec `json:"idle,omitempty"`
+	Default string    `json:"default,omitempty"` // app brought to the foreground once all are started
+}
+
+// IdleSpec is what prismctl shows while no prism is in the foreground.
+// Path takes precedence over Text.
+type IdleSpec struct {
+	Text string `json:"text,omitempty"`
+	Path string `json:"path,omitempty"` // binary name or path run as the idle screen
 }
 
 type ConfigureResult struct {
-	Started []string `json:"started"` // apps that were started
-	Failed  []string `json:"failed"`  // apps that failed to start
+	Apps []AppResult `json:"apps"` // listed apps in request order, then stopped apps
+}
+
+// AppResult is what prism/configure did with one app
+type AppResult struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`           // one of the App* actions
+	Reason string `json:"reason,omitempty"` // why, e.g. "new", "args changed", or the error
 }
 
+// prism/configure actions
+const (
+	AppStarted    = "started"    // launched
+	AppRestarted  = "restarted"  // relaunched because its launch spec changed
+	AppUnchanged  = "unchanged"  // left running as it was
+	AppRegistered = "registered" // lazy, launched on first prism/fg
+	AppStopped    = "stopped"    // no longer listed
+	AppFailed     = "failed"     // could not be started or restarted
+)
+
 type ListResult struct {
 	Prisms []PrismInfo `json:"prisms"`
 }
@@ -73,6 +258,33 @@ type ListResult struct {
 type HealthResult struct {
 	Healthy    bool `json:"healthy"`
 	PrismCount int  `json:"prism_count"`
+	Headless   bool `json:"headless,omitempty"` // running on a virtual terminal
+}
+
+// ResizeRequest sets the virtual terminal size of a headless prismctl
+type ResizeRequest struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+}
+
+type ResizeResult struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+}
+
+// MetricsResult is a service/metrics snapshot
+type MetricsResult struct {
+	Families []metrics.Family `json:"families"`
+}
+
+// NoticeRequest shows text in the panel in place of the foreground prism,
+// which is sent to the background. Bringing a prism forward clears it.
+type NoticeRequest struct {
+	Text string `json:"text"`
+}
+
+type NoticeResult struct {
+	Background string `json:"background,omitempty"` // prism sent to the background, if any
 }
 
 type ShutdownRequest struct {
@@ -96,6 +308,19 @@ type PanelSpawnResult struct {
 	Socket   string `json:"socket"`
 }
 
+// PanelRegisterRequest is sent by prismctl on startup to complete the spawn
+// handshake with shined
+type PanelRegisterRequest struct {
+	Instance string `json:"instance"`
+	PID      int    `json:"pid"`                 // prismctl process PID
+	WindowID string `json:"window_id,omitempty"` // $KITTY_WINDOW_ID
+	Socket   string `json:"socket"`              // prismctl RPC socket
+}
+
+type PanelRegisterResult struct {
+	Registered bool `json:"registered"` // false if shined was not expecting this instance
+}
+
 type PanelKillRequest struct {
 	Instance string `json:"instance"`
 }
@@ -104,10 +329,29 @@ type PanelKillResult struct {
 	Killed bool `json:"killed"`
 }
 
+// PanelWindowRequest names the panel for panel/show and panel/hide
+type PanelWindowRequest struct {
+	Instance string `json:"instance"`
+}
+
+type PanelResizeRequest struct {
+	Instance string `json:"instance"`
+	Cols     int    `json:"cols"`
+	Rows     int    `json:"rows"`
+}
+
+type PanelWindowResult struct {
+	OK bool `json:"ok"`
+}
+
 type ServiceStatusResult struct {
 	Panels  []PanelInfo `json:"panels"`
 	Uptime  int64       `json:"uptime_ms"`
 	Version string      `json:"version"`
+	Backend string      `json:"backend,omitempty"` // panel backend: kitty or local
+
+	Starting      bool     `json:"starting,omitempty"`       // still spawning configured panels
+	StartupErrors []string `json:"startup_errors,omitempty"` // configured panels that failed to spawn
 }
 
 type ConfigReloadResult struct {
@@ -132,8 +376,44 @@ type PrismCrashedNotification struct {
 	Name     string `json:"name"`
 	ExitCode int    `json:"exit_code"`
 	Signal   int    `json:"signal,omitempty"`
+	Reason   string `json:"reason,omitempty"` // one of the CrashReason* constants, or empty for an ordinary crash
+}
+
+// Crash reasons reported when prismctl kills a prism: for exceeding a
+// sandbox limit or for not becoming ready
+const (
+	CrashReasonMemoryLimit  = "memory-limit"
+	CrashReasonCPULimit     = "cpu-limit"
+	CrashReasonStartTimeout = "start-timeout" // killed for not becoming ready in time
+)
+
+type PrismHealthNotification struct {
+	Panel   string `json:"panel"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`            // HealthHealthy or HealthUnhealthy
+	Message string `json:"message,omitempty"` // reason for the failed check
 }
 
+// PanelClosingNotification is sent by prismctl just before it exits so
+// shined can tell a deliberate close from a crash
+type PanelClosingNotification struct {
+	Panel  string `json:"panel"`
+	Reason string `json:"reason"` // one of the PanelExit* reasons prismctl reports
+}
+
+// Panel exit reasons. prismctl reports the first four via panel/closing;
+// shined infers the rest when a panel dies without reporting.
+const (
+	PanelExitWindowClosed     = "window-closed"     // kitty window closed (SIGHUP)
+	PanelExitTerminated       = "terminated"        // SIGTERM or SIGINT
+	PanelExitEmpty            = "empty"             // last prism exited
+	PanelExitRequested        = "requested"         // service/shutdown RPC
+	PanelExitCrashed          = "crashed"           // died without reporting
+	PanelExitUnresponsive     = "unresponsive"      // failed health checks
+	PanelExitKittyExited      = "kitty-exited"      // kitty itself is gone
+	PanelExitDependencyFailed = "dependency-failed" // a required prism failed
+)
+
 type ForegroundChangedNotification struct {
 	Panel string `json:"panel"`
 	From  string `json:"from"` // previous foreground prism
@@ -0,0 +1,250 @@
+package screen
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Capture formats
+const (
+	FormatText = "text" // plain text, trailing blanks trimmed
+	FormatANSI = "ansi" // text with SGR sequences for colors and attributes
+	FormatHTML = "html" // <pre> block with inline styles
+	FormatSVG  = "svg"  // standalone SVG image
+)
+
+// SVG cell geometry in pixels
+const (
+	svgCellWidth  = 9
+	svgCellHeight = 18
+	svgFontSize   = 15
+	svgBaseline   = 14
+)
+
+// Render captures the screen in the given format, including the scrollback
+// above the visible grid when scrollback is true
+func (s *Screen) Render(format string, scrollback bool) (string, error) {
+	lines := s.Lines(scrollback)
+	cols, _ := s.Size()
+
+	switch format {
+	case FormatText, "":
+		return renderText(lines), nil
+	case FormatANSI:
+		return renderANSI(lines), nil
+	case FormatHTML:
+		return renderHTML(lines), nil
+	case FormatSVG:
+		return renderSVG(lines, cols), nil
+	}
+	return "", fmt.Errorf("unknown capture format: %s", format)
+}
+
+// run is a stretch of cells on one line sharing a style
+type run struct {
+	col   int // starting column
+	width int // columns covered
+	text  string
+	style Style
+}
+
+// runs splits a line into styled runs, skipping the trailing halves of wide
+// characters. Blank cells are spaces.
+func runs(line []Cell) []run {
+	var out []run
+	var b strings.Builder
+	cur := run{}
+
+	flush := func() {
+		if cur.width > 0 {
+			cur.text = b.String()
+			out = append(out, cur)
+		}
+		b.Reset()
+	}
+
+	for col, cell := range line {
+		if cell.Width == 0 {
+			cur.width++
+			continue
+		}
+		if cur.width == 0 || cell.Style != cur.style {
+			flush()
+			cur = run{col: col, style: cell.Style}
+		}
+		if cell.Rune == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteRune(cell.Rune)
+		}
+		cur.width++
+	}
+	flush()
+
+	return out
+}
+
+// trimTrailingBlankLines drops empty lines after the last line with content
+func trimTrailingBlankLines(lines []string) []string {
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func renderText(lines [][]Cell) string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		var b strings.Builder
+		for _, cell := range line {
+			switch {
+			case cell.Width == 0:
+			case cell.Rune == 0:
+				b.WriteByte(' ')
+			default:
+				b.WriteRune(cell.Rune)
+			}
+		}
+		out[i] = strings.TrimRight(b.String(), " ")
+	}
+	return strings.Join(trimTrailingBlankLines(out), "\n") + "\n"
+}
+
+func renderANSI(lines [][]Cell) string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		var b strings.Builder
+		styled := false
+		rs := runs(line)
+
+		// Trailing unstyled blanks carry no information
+		if n := len(rs); n > 0 && rs[n-1].style == (Style{}) {
+			rs[n-1].text = strings.TrimRight(rs[n-1].text, " ")
+		}
+
+		for _, r := range rs {
+			if sgr := r.style.SGR(); sgr != "" {
+				b.WriteString("\x1b[0;" + sgr + "m")
+				styled = true
+			} else if styled {
+				b.WriteString("\x1b[0m")
+				styled = false
+			}
+			b.WriteString(r.text)
+		}
+		if styled {
+			b.WriteString("\x1b[0m")
+		}
+		out[i] = b.String()
+	}
+	return strings.Join(trimTrailingBlankLines(out), "\n") + "\n"
+}
+
+// cssStyle returns inline CSS for a run, omitting default colors
+func cssStyle(st Style) string {
+	fg, bg := st.Colors()
+
+	var props []string
+	if fg != DefaultForeground {
+		props = append(props, "color:"+fg.Hex())
+	}
+	if bg != DefaultBackground {
+		props = append(props, "background-color:"+bg.Hex())
+	}
+	if st.Bold {
+		props = append(props, "font-weight:bold")
+	}
+	if st.Italic {
+		props = append(props, "font-style:italic")
+	}
+
+	var decorations []string
+	if st.Underline {
+		decorations = append(decorations, "underline")
+	}
+	if st.Strike {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) > 0 {
+		props = append(props, "text-decoration:"+strings.Join(decorations, " "))
+	}
+
+	return strings.Join(props, ";")
+}
+
+func renderHTML(lines [][]Cell) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<pre class="shine-capture" style="color:%s;background-color:%s;font-family:monospace">`,
+		DefaultForeground.Hex(), DefaultBackground.Hex())
+
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		for _, r := range runs(line) {
+			text := html.EscapeString(r.text)
+			if css := cssStyle(r.style); css != "" {
+				fmt.Fprintf(&b, `<span style="%s">%s</span>`, css, text)
+			} else {
+				b.WriteString(text)
+			}
+		}
+	}
+
+	b.WriteString("</pre>\n")
+	return b.String()
+}
+
+func renderSVG(lines [][]Cell, cols int) string {
+	width := cols * svgCellWidth
+	height := len(lines) * svgCellHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", DefaultBackground.Hex())
+	fmt.Fprintf(&b, `<g font-family="monospace" font-size="%d" xml:space="preserve">`+"\n", svgFontSize)
+
+	for row, line := range lines {
+		y := row * svgCellHeight
+		for _, r := range runs(line) {
+			fg, bg := r.style.Colors()
+			x := r.col * svgCellWidth
+			w := r.width * svgCellWidth
+
+			if bg != DefaultBackground {
+				fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+					x, y, w, svgCellHeight, bg.Hex())
+			}
+
+			if strings.TrimSpace(r.text) == "" && !r.style.Underline && !r.style.Strike {
+				continue
+			}
+
+			attrs := fmt.Sprintf(`x="%d" y="%d" fill="%s" textLength="%d" lengthAdjust="spacingAndGlyphs"`,
+				x, y+svgBaseline, fg.Hex(), w)
+			if r.style.Bold {
+				attrs += ` font-weight="bold"`
+			}
+			if r.style.Italic {
+				attrs += ` font-style="italic"`
+			}
+			var decorations []string
+			if r.style.Underline {
+				decorations = append(decorations, "underline")
+			}
+			if r.style.Strike {
+				decorations = append(decorations, "line-through")
+			}
+			if len(decorations) > 0 {
+				attrs += fmt.Sprintf(` text-decoration="%s"`, strings.Join(decorations, " "))
+			}
+
+			fmt.Fprintf(&b, "<text %s>%s</text>\n", attrs, html.EscapeString(r.text))
+		}
+	}
+
+	b.WriteString("</g>\n</svg>\n")
+	return b.String()
+}
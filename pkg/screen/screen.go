@@ -0,0 +1,610 @@
+// Package screen is a minimal terminal emulator. It parses a prism's PTY
+// output into a grid of styled cells so prismctl can capture what a panel
+// shows as text, ANSI, HTML or SVG without a compositor.
+//
+// It implements what TUI apps commonly rely on: cursor movement, erase,
+// insert/delete, scroll regions, SGR colors and attributes, autowrap and the
+// alternate screen. Everything else is parsed and ignored.
+package screen
+
+import (
+	"sync"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-runewidth"
+)
+
+type ColorKind uint8
+
+const (
+	ColorDefault ColorKind = iota
+	ColorIndexed
+	ColorRGB
+)
+
+type Color struct {
+	Kind    ColorKind
+	Index   uint8 // ColorIndexed
+	R, G, B uint8 // ColorRGB
+}
+
+type Style struct {
+	FG, BG    Color
+	Bold      bool
+	Faint     bool
+	Italic    bool
+	Underline bool
+	Blink     bool
+	Reverse   bool
+	Hidden    bool
+	Strike    bool
+}
+
+type Cell struct {
+	Rune  rune // 0 for a blank cell
+	Width int  // 1 or 2; 0 for the trailing half of a wide character
+	Style Style
+}
+
+type Screen struct {
+	mu            sync.Mutex
+	cols, rows    int
+	main, alt     [][]Cell
+	grid          [][]Cell // active buffer, main or alt
+	altActive     bool
+	scrollback    [][]Cell
+	maxScrollback int
+
+	x, y     int
+	wrapNext bool // cursor is past the last column, wrap before the next print
+	autowrap bool
+	pen      Style
+	top      int // scroll region, inclusive
+	bottom   int
+
+	savedX, savedY int
+	savedPen       Style
+
+	parser *ansi.Parser
+}
+
+// New returns a blank screen of cols × rows that keeps up to scrollback lines
+// scrolled off the top of the main buffer
+func New(cols, rows, scrollback int) *Screen {
+	cols, rows = max(cols, 1), max(rows, 1)
+
+	s := &Screen{
+		cols:          cols,
+		rows:          rows,
+		main:          blankLines(cols, rows),
+		alt:           blankLines(cols, rows),
+		maxScrollback: scrollback,
+		autowrap:      true,
+		bottom:        rows - 1,
+	}
+	s.grid = s.main
+
+	s.parser = ansi.NewParser()
+	s.parser.SetHandler(ansi.Handler{
+		Print:     s.print,
+		Execute:   s.execute,
+		HandleCsi: s.csi,
+		HandleEsc: s.esc,
+	})
+
+	return s
+}
+
+// Write feeds PTY output to the emulator. It never fails.
+func (s *Screen) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parser.Parse(p)
+	return len(p), nil
+}
+
+func (s *Screen) Size() (cols, rows int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cols, s.rows
+}
+
+// Resize changes the grid size. When rows shrink, lines above the cursor
+// move into scrollback so the cursor line stays visible. Scrollback lines
+// take the new width too.
+func (s *Screen) Resize(cols, rows int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cols, rows = max(cols, 1), max(rows, 1)
+	if cols == s.cols && rows == s.rows {
+		return
+	}
+
+	if drop := s.y - (rows - 1); drop > 0 {
+		if !s.altActive {
+			s.pushScrollback(s.grid[:drop])
+		}
+		s.grid = s.grid[drop:]
+		s.y -= drop
+	}
+
+	s.grid = resizeLines(s.grid, cols, rows)
+	for i, line := range s.scrollback {
+		s.scrollback[i] = resizeLine(line, cols)
+	}
+	if s.altActive {
+		s.alt = s.grid
+		s.main = resizeLines(s.main, cols, rows)
+	} else {
+		s.main = s.grid
+		s.alt = resizeLines(s.alt, cols, rows)
+	}
+
+	s.cols, s.rows = cols, rows
+	s.top, s.bottom = 0, rows-1
+	s.x = min(s.x, cols-1)
+	s.y = min(s.y, rows-1)
+	s.wrapNext = false
+}
+
+// Lines returns a copy of the visible grid, preceded by the scrollback when
+// scrollback is true
+func (s *Screen) Lines(scrollback bool) [][]Cell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lines [][]Cell
+	if scrollback {
+		lines = append(lines, copyLines(s.scrollback)...)
+	}
+	return append(lines, copyLines(s.grid)...)
+}
+
+// Cursor returns the cursor position (0-based column and row)
+func (s *Screen) Cursor() (x, y int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.x, s.y
+}
+
+func (s *Screen) print(r rune) {
+	width := runewidth.RuneWidth(r)
+	if width == 0 {
+		// Combining characters and zero-width joiners are not tracked
+		return
+	}
+	if width > s.cols {
+		// A wide character cannot fit a one-column screen at all
+		return
+	}
+
+	if s.wrapNext && s.autowrap {
+		s.x = 0
+		s.lineFeed()
+	}
+	s.wrapNext = false
+
+	if s.x+width > s.cols {
+		if !s.autowrap {
+			s.x = s.cols - width
+		} else {
+			s.x = 0
+			s.lineFeed()
+		}
+	}
+
+	line := s.grid[s.y]
+	splitWide(line, s.x)
+	if width == 2 {
+		splitWide(line, s.x+1)
+	}
+	line[s.x] = Cell{Rune: r, Width: width, Style: s.pen}
+	if width == 2 {
+		line[s.x+1] = Cell{Width: 0, Style: s.pen}
+	}
+
+	s.x += width
+	if s.x >= s.cols {
+		s.x = s.cols - 1
+		s.wrapNext = true
+	}
+}
+
+// splitWide blanks the other half of a wide character about to be
+// overwritten at x, so no lead cell is left without its continuation or
+// continuation without its lead
+func splitWide(line []Cell, x int) {
+	switch line[x].Width {
+	case 0:
+		if x > 0 {
+			line[x-1] = Cell{Width: 1, Style: line[x-1].Style}
+		}
+	case 2:
+		if x+1 < len(line) {
+			line[x+1] = Cell{Width: 1, Style: line[x+1].Style}
+		}
+	}
+}
+
+func (s *Screen) execute(b byte) {
+	switch b {
+	case ansi.CR:
+		s.x = 0
+		s.wrapNext = false
+	case ansi.LF, ansi.VT, ansi.FF:
+		s.lineFeed()
+	case ansi.BS:
+		if s.x > 0 {
+			s.x--
+		}
+		s.wrapNext = false
+	case ansi.HT:
+		s.x = min((s.x/8+1)*8, s.cols-1)
+		s.wrapNext = false
+	}
+}
+
+func (s *Screen) esc(cmd ansi.Cmd) {
+	if cmd.Intermediate() != 0 {
+		// Charset designation and the like
+		return
+	}
+
+	switch cmd.Final() {
+	case '7':
+		s.saveCursor()
+	case '8':
+		s.restoreCursor()
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.x = 0
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		s.reset()
+	}
+}
+
+func (s *Screen) csi(cmd ansi.Cmd, params ansi.Params) {
+	param := func(i, def int) int {
+		v, _, _ := params.Param(i, def)
+		return v
+	}
+	// count is a movement or repeat parameter, where 0 means 1
+	count := func(i int) int {
+		return max(param(i, 1), 1)
+	}
+
+	if cmd.Prefix() == '?' {
+		s.privateMode(cmd.Final(), params)
+		return
+	}
+	if cmd.Prefix() != 0 || cmd.Intermediate() != 0 {
+		return
+	}
+
+	switch cmd.Final() {
+	case 'A':
+		s.moveTo(s.x, s.y-count(0))
+	case 'B':
+		s.moveTo(s.x, s.y+count(0))
+	case 'C':
+		s.moveTo(s.x+count(0), s.y)
+	case 'D':
+		s.moveTo(s.x-count(0), s.y)
+	case 'E':
+		s.moveTo(0, s.y+count(0))
+	case 'F':
+		s.moveTo(0, s.y-count(0))
+	case 'G', '`':
+		s.moveTo(count(0)-1, s.y)
+	case 'd':
+		s.moveTo(s.x, count(0)-1)
+	case 'H', 'f':
+		s.moveTo(count(1)-1, count(0)-1)
+	case 'J':
+		s.eraseDisplay(param(0, 0))
+	case 'K':
+		s.eraseLine(param(0, 0))
+	case 'L':
+		s.insertLines(count(0))
+	case 'M':
+		s.deleteLines(count(0))
+	case '@':
+		s.insertChars(count(0))
+	case 'P':
+		s.deleteChars(count(0))
+	case 'X':
+		s.eraseChars(count(0))
+	case 'S':
+		s.scrollUp(count(0))
+	case 'T':
+		s.scrollDown(count(0))
+	case 'm':
+		s.sgr(params)
+	case 'r':
+		top, bottom := count(0)-1, param(1, s.rows)-1
+		if bottom <= 0 || bottom >= s.rows {
+			bottom = s.rows - 1
+		}
+		if top < bottom {
+			s.top, s.bottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.saveCursor()
+	case 'u':
+		s.restoreCursor()
+	}
+}
+
+func (s *Screen) privateMode(final byte, params ansi.Params) {
+	set := final == 'h'
+	if !set && final != 'l' {
+		return
+	}
+
+	params.ForEach(0, func(_, mode int, _ bool) {
+		switch mode {
+		case 7:
+			s.autowrap = set
+		case 47, 1047:
+			s.setAltScreen(set)
+		case 1049:
+			if set {
+				s.saveCursor()
+				s.setAltScreen(true)
+				clearLines(s.alt, Style{})
+			} else {
+				s.setAltScreen(false)
+				s.restoreCursor()
+			}
+		}
+	})
+}
+
+func (s *Screen) setAltScreen(on bool) {
+	if on == s.altActive {
+		return
+	}
+	s.altActive = on
+	if on {
+		s.grid = s.alt
+	} else {
+		s.grid = s.main
+	}
+	s.wrapNext = false
+}
+
+func (s *Screen) moveTo(x, y int) {
+	s.x = clamp(x, 0, s.cols-1)
+	s.y = clamp(y, 0, s.rows-1)
+	s.wrapNext = false
+}
+
+func (s *Screen) lineFeed() {
+	s.wrapNext = false
+	if s.y == s.bottom {
+		s.scrollUp(1)
+	} else if s.y < s.rows-1 {
+		s.y++
+	}
+}
+
+func (s *Screen) reverseIndex() {
+	s.wrapNext = false
+	if s.y == s.top {
+		s.scrollDown(1)
+	} else if s.y > 0 {
+		s.y--
+	}
+}
+
+// scrollUp moves the scroll region up n lines. Lines leaving the top of the
+// main screen go to scrollback.
+func (s *Screen) scrollUp(n int) {
+	n = min(n, s.bottom-s.top+1)
+	region := s.grid[s.top : s.bottom+1]
+
+	if s.top == 0 && !s.altActive {
+		s.pushScrollback(region[:n])
+	}
+
+	copy(region, region[n:])
+	for i := len(region) - n; i < len(region); i++ {
+		region[i] = blankLine(s.cols, s.eraseStyle())
+	}
+}
+
+func (s *Screen) scrollDown(n int) {
+	n = min(n, s.bottom-s.top+1)
+	region := s.grid[s.top : s.bottom+1]
+
+	copy(region[n:], region)
+	for i := 0; i < n; i++ {
+		region[i] = blankLine(s.cols, s.eraseStyle())
+	}
+}
+
+func (s *Screen) insertLines(n int) {
+	if s.y < s.top || s.y > s.bottom {
+		return
+	}
+	top := s.top
+	s.top = s.y
+	s.scrollDown(n)
+	s.top = top
+	s.x = 0
+	s.wrapNext = false
+}
+
+func (s *Screen) deleteLines(n int) {
+	if s.y < s.top || s.y > s.bottom {
+		return
+	}
+	top := s.top
+	s.top = s.y
+	n = min(n, s.bottom-s.top+1)
+	region := s.grid[s.top : s.bottom+1]
+	copy(region, region[n:])
+	for i := len(region) - n; i < len(region); i++ {
+		region[i] = blankLine(s.cols, s.eraseStyle())
+	}
+	s.top = top
+	s.x = 0
+	s.wrapNext = false
+}
+
+func (s *Screen) insertChars(n int) {
+	line := s.grid[s.y]
+	n = min(n, s.cols-s.x)
+	copy(line[s.x+n:], line[s.x:])
+	fill(line[s.x:s.x+n], s.eraseStyle())
+	s.wrapNext = false
+}
+
+func (s *Screen) deleteChars(n int) {
+	line := s.grid[s.y]
+	n = min(n, s.cols-s.x)
+	copy(line[s.x:], line[s.x+n:])
+	fill(line[s.cols-n:], s.eraseStyle())
+	s.wrapNext = false
+}
+
+func (s *Screen) eraseChars(n int) {
+	n = min(n, s.cols-s.x)
+	fill(s.grid[s.y][s.x:s.x+n], s.eraseStyle())
+	s.wrapNext = false
+}
+
+func (s *Screen) eraseLine(mode int) {
+	line := s.grid[s.y]
+	switch mode {
+	case 0:
+		fill(line[s.x:], s.eraseStyle())
+	case 1:
+		fill(line[:s.x+1], s.eraseStyle())
+	case 2:
+		fill(line, s.eraseStyle())
+	}
+	s.wrapNext = false
+}
+
+func (s *Screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseLine(0)
+		clearLines(s.grid[s.y+1:], s.eraseStyle())
+	case 1:
+		s.eraseLine(1)
+		clearLines(s.grid[:s.y], s.eraseStyle())
+	case 2:
+		clearLines(s.grid, s.eraseStyle())
+	case 3:
+		s.scrollback = nil
+	}
+	s.wrapNext = false
+}
+
+// eraseStyle is the style of erased cells: blank with the current background
+func (s *Screen) eraseStyle() Style {
+	return Style{BG: s.pen.BG}
+}
+
+func (s *Screen) saveCursor() {
+	s.savedX, s.savedY, s.savedPen = s.x, s.y, s.pen
+}
+
+func (s *Screen) restoreCursor() {
+	s.pen = s.savedPen
+	s.moveTo(s.savedX, s.savedY)
+}
+
+func (s *Screen) reset() {
+	s.main = blankLines(s.cols, s.rows)
+	s.alt = blankLines(s.cols, s.rows)
+	s.grid = s.main
+	s.altActive = false
+	s.scrollback = nil
+	s.x, s.y = 0, 0
+	s.wrapNext = false
+	s.autowrap = true
+	s.pen = Style{}
+	s.top, s.bottom = 0, s.rows-1
+}
+
+func (s *Screen) pushScrollback(lines [][]Cell) {
+	if s.maxScrollback <= 0 {
+		return
+	}
+	s.scrollback = append(s.scrollback, copyLines(lines)...)
+	if over := len(s.scrollback) - s.maxScrollback; over > 0 {
+		s.scrollback = append(s.scrollback[:0:0], s.scrollback[over:]...)
+	}
+}
+
+func blankLine(cols int, style Style) []Cell {
+	line := make([]Cell, cols)
+	fill(line, style)
+	return line
+}
+
+func blankLines(cols, rows int) [][]Cell {
+	lines := make([][]Cell, rows)
+	for i := range lines {
+		lines[i] = blankLine(cols, Style{})
+	}
+	return lines
+}
+
+func fill(cells []Cell, style Style) {
+	for i := range cells {
+		cells[i] = Cell{Width: 1, Style: style}
+	}
+}
+
+func clearLines(lines [][]Cell, style Style) {
+	for _, line := range lines {
+		fill(line, style)
+	}
+}
+
+func copyLines(lines [][]Cell) [][]Cell {
+	out := make([][]Cell, len(lines))
+	for i, line := range lines {
+		out[i] = append([]Cell(nil), line...)
+	}
+	return out
+}
+
+func resizeLines(lines [][]Cell, cols, rows int) [][]Cell {
+	out := make([][]Cell, rows)
+	for i := range out {
+		if i >= len(lines) {
+			out[i] = blankLine(cols, Style{})
+			continue
+		}
+		out[i] = resizeLine(lines[i], cols)
+	}
+	return out
+}
+
+// resizeLine truncates or pads line to cols. A wide character cut in half
+// at the new edge is blanked.
+func resizeLine(line []Cell, cols int) []Cell {
+	if len(line) < cols {
+		return append(line, blankLine(cols-len(line), Style{})...)
+	}
+	line = line[:cols:cols]
+	if last := &line[cols-1]; last.Width == 2 {
+		*last = Cell{Width: 1, Style: last.Style}
+	}
+	return line
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
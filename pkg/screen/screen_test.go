@@ -0,0 +1,237 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+)
+
+func write(s *Screen, data string) {
+	s.Write([]byte(data))
+}
+
+func text(t *testing.T, s *Screen, scrollback bool) string {
+	t.Helper()
+	out, err := s.Render(FormatText, scrollback)
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	return out
+}
+
+func TestScreen_PrintAndWrap(t *testing.T) {
+	s := New(5, 3, 0)
+	write(s, "hello world")
+
+	if got, want := text(t, s, false), "hello\n worl\nd\n"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	if x, y := s.Cursor(); x != 1 || y != 2 {
+		t.Errorf("cursor = %d,%d, want 1,2", x, y)
+	}
+}
+
+func TestScreen_CursorAndErase(t *testing.T) {
+	s := New(10, 3, 0)
+	write(s, "aaaaaaaaaa\r\nbbbbbbbbbb\r\ncccccccccc")
+	write(s, "\x1b[2;4H\x1b[K")  // erase to end of row 2 from column 4
+	write(s, "\x1b[1;1H\x1b[2P") // delete two chars on row 1
+	write(s, "\x1b[3;3H\x1b[1K") // erase to start of row 3 through column 3
+
+	if got, want := text(t, s, false), "aaaaaaaa\nbbb\n   ccccccc\n"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+
+	write(s, "\x1b[2J")
+	if got := text(t, s, false); got != "\n" {
+		t.Errorf("after ED 2 text = %q, want empty", got)
+	}
+}
+
+func TestScreen_ScrollbackAndRegion(t *testing.T) {
+	s := New(4, 2, 10)
+	write(s, "one\r\ntwo\r\nsix")
+
+	if got, want := text(t, s, false), "two\nsix\n"; got != want {
+		t.Errorf("visible = %q, want %q", got, want)
+	}
+	if got, want := text(t, s, true), "one\ntwo\nsix\n"; got != want {
+		t.Errorf("with scrollback = %q, want %q", got, want)
+	}
+
+	// A scroll region confined to row 2 must not touch row 1 or scrollback
+	r := New(4, 3, 10)
+	write(r, "hdr\x1b[2;3r\x1b[2;1Ha\r\nb\r\nc")
+	if got, want := text(t, r, true), "hdr\nb\nc\n"; got != want {
+		t.Errorf("region = %q, want %q", got, want)
+	}
+}
+
+func TestScreen_AltScreen(t *testing.T) {
+	s := New(6, 2, 10)
+	write(s, "shell")
+	write(s, "\x1b[?1049h\x1b[Htui")
+
+	if got := text(t, s, false); got != "tui\n" {
+		t.Errorf("alt screen = %q, want %q", got, "tui\n")
+	}
+
+	write(s, "\x1b[?1049l")
+	if got := text(t, s, false); got != "shell\n" {
+		t.Errorf("main screen = %q, want %q", got, "shell\n")
+	}
+	if x, y := s.Cursor(); x != 5 || y != 0 {
+		t.Errorf("restored cursor = %d,%d, want 5,0", x, y)
+	}
+}
+
+func TestScreen_WideCharacters(t *testing.T) {
+	s := New(4, 1, 0)
+	write(s, "a世b")
+
+	if got, want := text(t, s, false), "a世b\n"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	lines := s.Lines(false)
+	if lines[0][1].Width != 2 || lines[0][2].Width != 0 {
+		t.Errorf("wide cell widths = %d,%d, want 2,0", lines[0][1].Width, lines[0][2].Width)
+	}
+}
+
+func TestScreen_WideCharacterOneColumn(t *testing.T) {
+	tests := []struct {
+		name   string
+		screen func() *Screen
+	}{
+		{"new", func() *Screen { return New(1, 3, 0) }},
+		{"resized", func() *Screen {
+			s := New(4, 3, 0)
+			write(s, "ab")
+			s.Resize(1, 3)
+			return s
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.screen()
+			write(s, "\x1b[H字a\x1b[?7l世b")
+
+			// The wide characters are dropped rather than printed off screen
+			if got, want := text(t, s, false), "b\n"; got != want {
+				t.Errorf("text = %q, want %q", got, want)
+			}
+			if x, _ := s.Cursor(); x != 0 {
+				t.Errorf("cursor x = %d, want 0", x)
+			}
+		})
+	}
+}
+
+func TestScreen_OverwriteWideHalf(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"lead", "a世b\x1b[2Gx", "ax b\n"},
+		{"continuation", "a世b\x1b[3Gx", "a xb\n"},
+		{"wide over continuation", "世世\x1b[2G界", " 界\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(4, 1, 0)
+			write(s, tt.input)
+
+			if got := text(t, s, false); got != tt.want {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+			line := s.Lines(false)[0]
+			for x, cell := range line {
+				lead := x > 0 && line[x-1].Width == 2
+				if (cell.Width == 0) != lead {
+					t.Errorf("cell %d width %d does not pair with cell %d width %d", x, cell.Width, x-1, line[max(x-1, 0)].Width)
+				}
+			}
+		})
+	}
+}
+
+func TestScreen_SGR(t *testing.T) {
+	s := New(20, 1, 0)
+	write(s, "\x1b[1;31mA\x1b[0m\x1b[38;5;208mB\x1b[38:2::10:20:30mC\x1b[48;2;1;2;3mD\x1b[4:0;7mE")
+
+	cells := s.Lines(false)[0]
+	tests := []struct {
+		col  int
+		want Style
+	}{
+		{0, Style{Bold: true, FG: Color{Kind: ColorIndexed, Index: 1}}},
+		{1, Style{FG: Color{Kind: ColorIndexed, Index: 208}}},
+		{2, Style{FG: Color{Kind: ColorRGB, R: 10, G: 20, B: 30}}},
+		{3, Style{FG: Color{Kind: ColorRGB, R: 10, G: 20, B: 30}, BG: Color{Kind: ColorRGB, R: 1, G: 2, B: 3}}},
+		{4, Style{FG: Color{Kind: ColorRGB, R: 10, G: 20, B: 30}, BG: Color{Kind: ColorRGB, R: 1, G: 2, B: 3}, Reverse: true}},
+	}
+	for _, tt := range tests {
+		if got := cells[tt.col].Style; got != tt.want {
+			t.Errorf("col %d style = %+v, want %+v", tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestScreen_Resize(t *testing.T) {
+	s := New(5, 3, 10)
+	write(s, "a\r\nb\r\nc")
+	s.Resize(3, 2)
+
+	if cols, rows := s.Size(); cols != 3 || rows != 2 {
+		t.Errorf("Size() = %d,%d, want 3,2", cols, rows)
+	}
+	if got, want := text(t, s, true), "a\nb\nc\n"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	if got, want := text(t, s, false), "b\nc\n"; got != want {
+		t.Errorf("visible = %q, want %q", got, want)
+	}
+}
+
+func TestScreen_ResizeScrollback(t *testing.T) {
+	s := New(4, 1, 10)
+	write(s, "abcd\r\na世\r\nx")
+
+	s.Resize(2, 1)
+	for i, line := range s.Lines(true) {
+		if len(line) != 2 {
+			t.Errorf("line %d has %d cells, want 2", i, len(line))
+		}
+	}
+	// The wide character cut at the new edge is blanked
+	if got, want := text(t, s, true), "ab\na\nx\n"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
+
+func TestRender_Formats(t *testing.T) {
+	s := New(8, 2, 0)
+	write(s, "\x1b[1;32mok\x1b[0m <x>")
+
+	ansiOut, _ := s.Render(FormatANSI, false)
+	if want := "\x1b[0;1;32mok\x1b[0m <x>\n"; ansiOut != want {
+		t.Errorf("ansi = %q, want %q", ansiOut, want)
+	}
+
+	htmlOut, _ := s.Render(FormatHTML, false)
+	if !strings.Contains(htmlOut, `<span style="color:#00ff00;font-weight:bold">ok</span> &lt;x&gt;`) {
+		t.Errorf("html = %q, missing styled span", htmlOut)
+	}
+
+	svgOut, _ := s.Render(FormatSVG, false)
+	if !strings.HasPrefix(svgOut, `<svg xmlns="http://www.w3.org/2000/svg" width="72" height="36"`) {
+		t.Errorf("svg header = %q", svgOut)
+	}
+	if !strings.Contains(svgOut, `fill="#00ff00"`) || !strings.Contains(svgOut, "&lt;x&gt;") {
+		t.Errorf("svg = %q, missing styled or escaped text", svgOut)
+	}
+
+	if _, err := s.Render("png", false); err == nil {
+		t.Error("Render() with unknown format should fail")
+	}
+}
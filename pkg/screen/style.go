@@ -0,0 +1,248 @@
+package screen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Default colors used when rendering HTML and SVG
+var (
+	DefaultForeground = RGB{0xdd, 0xdd, 0xdd}
+	DefaultBackground = RGB{0x00, 0x00, 0x00}
+)
+
+type RGB struct {
+	R, G, B uint8
+}
+
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// ansi16 is the xterm palette for the 16 basic colors
+var ansi16 = [16]RGB{
+	{0x00, 0x00, 0x00}, {0xcd, 0x00, 0x00}, {0x00, 0xcd, 0x00}, {0xcd, 0xcd, 0x00},
+	{0x00, 0x00, 0xee}, {0xcd, 0x00, 0xcd}, {0x00, 0xcd, 0xcd}, {0xe5, 0xe5, 0xe5},
+	{0x7f, 0x7f, 0x7f}, {0xff, 0x00, 0x00}, {0x00, 0xff, 0x00}, {0xff, 0xff, 0x00},
+	{0x5c, 0x5c, 0xff}, {0xff, 0x00, 0xff}, {0x00, 0xff, 0xff}, {0xff, 0xff, 0xff},
+}
+
+// PaletteRGB resolves an indexed color using the xterm 256-color palette
+func PaletteRGB(index uint8) RGB {
+	switch {
+	case index < 16:
+		return ansi16[index]
+	case index < 232:
+		levels := [6]uint8{0, 95, 135, 175, 215, 255}
+		i := index - 16
+		return RGB{levels[i/36], levels[(i/6)%6], levels[i%6]}
+	default:
+		v := 8 + 10*(index-232)
+		return RGB{v, v, v}
+	}
+}
+
+// resolve returns the color's RGB value, or def for the default color
+func (c Color) resolve(def RGB) RGB {
+	switch c.Kind {
+	case ColorIndexed:
+		return PaletteRGB(c.Index)
+	case ColorRGB:
+		return RGB{c.R, c.G, c.B}
+	}
+	return def
+}
+
+// Colors returns the foreground and background a cell is drawn with, after
+// applying reverse video, faint and hidden
+func (st Style) Colors() (fg, bg RGB) {
+	fg, bg = st.FG.resolve(DefaultForeground), st.BG.resolve(DefaultBackground)
+	if st.Bold && st.FG.Kind == ColorIndexed && st.FG.Index < 8 {
+		fg = PaletteRGB(st.FG.Index + 8)
+	}
+	if st.Reverse {
+		fg, bg = bg, fg
+	}
+	if st.Faint {
+		fg = RGB{fg.R / 2, fg.G / 2, fg.B / 2}
+	}
+	if st.Hidden {
+		fg = bg
+	}
+	return fg, bg
+}
+
+// SGR returns the parameters of an SGR sequence that sets this style from a
+// reset state, e.g. "1;38;5;208". Empty for the default style.
+func (st Style) SGR() string {
+	var params []string
+	flag := func(on bool, code string) {
+		if on {
+			params = append(params, code)
+		}
+	}
+	flag(st.Bold, "1")
+	flag(st.Faint, "2")
+	flag(st.Italic, "3")
+	flag(st.Underline, "4")
+	flag(st.Blink, "5")
+	flag(st.Reverse, "7")
+	flag(st.Hidden, "8")
+	flag(st.Strike, "9")
+
+	if c := st.FG.sgr(30, 90, 38); c != "" {
+		params = append(params, c)
+	}
+	if c := st.BG.sgr(40, 100, 48); c != "" {
+		params = append(params, c)
+	}
+
+	return strings.Join(params, ";")
+}
+
+func (c Color) sgr(base, brightBase, extended int) string {
+	switch c.Kind {
+	case ColorIndexed:
+		if c.Index < 8 {
+			return strconv.Itoa(base + int(c.Index))
+		}
+		if c.Index < 16 {
+			return strconv.Itoa(brightBase + int(c.Index) - 8)
+		}
+		return fmt.Sprintf("%d;5;%d", extended, c.Index)
+	case ColorRGB:
+		return fmt.Sprintf("%d;2;%d;%d;%d", extended, c.R, c.G, c.B)
+	}
+	return ""
+}
+
+// sgr applies Select Graphic Rendition parameters to the pen
+func (s *Screen) sgr(params ansi.Params) {
+	if len(params) == 0 {
+		s.pen = Style{}
+		return
+	}
+
+	for i := 0; i < len(params); i++ {
+		code, hasMore, _ := params.Param(i, 0)
+
+		switch {
+		case code == 0:
+			s.pen = Style{}
+		case code == 1:
+			s.pen.Bold = true
+		case code == 2:
+			s.pen.Faint = true
+		case code == 3:
+			s.pen.Italic = true
+		case code == 4:
+			// 4:0 turns underline off; other styles (curly, dotted) are underline
+			s.pen.Underline = true
+			if hasMore {
+				i++
+				if style, _, _ := params.Param(i, 1); style == 0 {
+					s.pen.Underline = false
+				}
+			}
+		case code == 5 || code == 6:
+			s.pen.Blink = true
+		case code == 7:
+			s.pen.Reverse = true
+		case code == 8:
+			s.pen.Hidden = true
+		case code == 9:
+			s.pen.Strike = true
+		case code == 21:
+			s.pen.Underline = true
+		case code == 22:
+			s.pen.Bold, s.pen.Faint = false, false
+		case code == 23:
+			s.pen.Italic = false
+		case code == 24:
+			s.pen.Underline = false
+		case code == 25:
+			s.pen.Blink = false
+		case code == 27:
+			s.pen.Reverse = false
+		case code == 28:
+			s.pen.Hidden = false
+		case code == 29:
+			s.pen.Strike = false
+		case code >= 30 && code <= 37:
+			s.pen.FG = Color{Kind: ColorIndexed, Index: uint8(code - 30)}
+		case code == 38:
+			s.pen.FG, i = extendedColor(params, i)
+		case code == 39:
+			s.pen.FG = Color{}
+		case code >= 40 && code <= 47:
+			s.pen.BG = Color{Kind: ColorIndexed, Index: uint8(code - 40)}
+		case code == 48:
+			s.pen.BG, i = extendedColor(params, i)
+		case code == 49:
+			s.pen.BG = Color{}
+		case code >= 90 && code <= 97:
+			s.pen.FG = Color{Kind: ColorIndexed, Index: uint8(code - 90 + 8)}
+		case code >= 100 && code <= 107:
+			s.pen.BG = Color{Kind: ColorIndexed, Index: uint8(code - 100 + 8)}
+		}
+	}
+}
+
+// extendedColor parses a 38/48 color starting at params[i], in either the
+// semicolon form (38;5;n, 38;2;r;g;b) or the colon form (38:5:n,
+// 38:2::r:g:b). Returns the color and the index of the last parameter used.
+func extendedColor(params ansi.Params, i int) (Color, int) {
+	_, colon, _ := params.Param(i, 0)
+
+	var args []int
+	if colon {
+		// Sub-parameters run until one without the has-more flag
+		for j := i + 1; j < len(params); j++ {
+			v, more, _ := params.Param(j, 0)
+			args = append(args, v)
+			i = j
+			if !more {
+				break
+			}
+		}
+	} else {
+		kind, _, _ := params.Param(i+1, 0)
+		n := 0
+		switch kind {
+		case 5:
+			n = 2
+		case 2:
+			n = 4
+		}
+		for j := i + 1; j < len(params) && j <= i+n; j++ {
+			v, _, _ := params.Param(j, 0)
+			args = append(args, v)
+		}
+		i += len(args)
+	}
+
+	if len(args) == 0 {
+		return Color{}, i
+	}
+
+	switch args[0] {
+	case 5:
+		if len(args) >= 2 {
+			return Color{Kind: ColorIndexed, Index: uint8(args[1])}, i
+		}
+	case 2:
+		rgb := args[1:]
+		// The colon form may carry a color space ID before r:g:b
+		if colon && len(rgb) >= 4 {
+			rgb = rgb[1:]
+		}
+		if len(rgb) >= 3 {
+			return Color{Kind: ColorRGB, R: uint8(rgb[0]), G: uint8(rgb[1]), B: uint8(rgb[2])}, i
+		}
+	}
+
+	return Color{}, i
+}
@@ -253,6 +253,86 @@ func TestPrismStateWriterRemovePrism(t *testing.T) {
 	}
 }
 
+func TestPrismStateWriterRestartPrism(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "test.state")
+
+	writer, err := NewPrismStateWriter(statePath)
+	if err != nil {
+		t.Fatalf("NewPrismStateWriter() error: %v", err)
+	}
+	defer writer.Remove()
+
+	writer.AddPrism("clock", 1001, true)
+	writer.AddPrism("bar", 1002, false)
+
+	writer.RestartPrism("bar", 2002, 3)
+
+	reader, err := OpenPrismStateReader(statePath)
+	if err != nil {
+		t.Fatalf("OpenPrismStateReader() error: %v", err)
+	}
+	defer reader.Close()
+
+	state, _ := reader.Read()
+
+	bar := state.Prisms[1]
+	if bar.GetName() != "bar" {
+		t.Fatalf("Prisms[1] = %q, want bar (slot preserved)", bar.GetName())
+	}
+	if bar.PID != 2002 {
+		t.Errorf("PID = %d, want 2002", bar.PID)
+	}
+	if bar.Restarts != 3 {
+		t.Errorf("Restarts = %d, want 3", bar.Restarts)
+	}
+	if state.Prisms[0].PID != 1001 {
+		t.Errorf("clock PID = %d, want 1001 (untouched)", state.Prisms[0].PID)
+	}
+}
+
+func TestPrismStateWriterSetUsage(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "test.state")
+
+	writer, err := NewPrismStateWriter(statePath)
+	if err != nil {
+		t.Fatalf("NewPrismStateWriter() error: %v", err)
+	}
+	defer writer.Remove()
+
+	writer.AddPrism("clock", 1001, true)
+	writer.SetUsage("clock", PrismUsage{CPUPercent: 12.34, RSSBytes: 8 << 20, Threads: 3, Children: 1, ProcState: 'T'})
+
+	reader, err := OpenPrismStateReader(statePath)
+	if err != nil {
+		t.Fatalf("OpenPrismStateReader() error: %v", err)
+	}
+	defer reader.Close()
+
+	state, _ := reader.Read()
+	clock := state.Prisms[0]
+	if !clock.Sampled() {
+		t.Fatal("Sampled() = false after SetUsage")
+	}
+	if clock.CPUPercent() != 12.3 {
+		t.Errorf("CPUPercent() = %v, want 12.3", clock.CPUPercent())
+	}
+	if clock.RSSBytes() != 8<<20 {
+		t.Errorf("RSSBytes() = %d, want %d", clock.RSSBytes(), 8<<20)
+	}
+	if clock.Threads != 3 || clock.Children != 1 || clock.ProcState != 'T' {
+		t.Errorf("Threads/Children/ProcState = %d/%d/%c, want 3/1/T", clock.Threads, clock.Children, clock.ProcState)
+	}
+
+	// A restart is a new process; its usage is unknown until resampled
+	writer.RestartPrism("clock", 2001, 1)
+	state, _ = reader.Read()
+	if state.Prisms[0].Sampled() {
+		t.Error("Sampled() = true after RestartPrism")
+	}
+}
+
 func TestConcurrentReads(t *testing.T) {
 	tmpDir := t.TempDir()
 	statePath := filepath.Join(tmpDir, "test.state")
@@ -450,8 +530,8 @@ func TestStructSizes(t *testing.T) {
 		size int
 		want int
 	}{
-		{"PrismEntry", int(PrismEntrySize), 80},
-		{"PrismRuntimeState", int(PrismRuntimeStateSize), 1424},
+		{"PrismEntry", int(PrismEntrySize), 96},
+		{"PrismRuntimeState", int(PrismRuntimeStateSize), 1680},
 		{"PanelEntry", int(PanelEntrySize), 136},
 		{"ShinedState", int(ShinedStateSize), 4368},
 	}
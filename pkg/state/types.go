@@ -7,9 +7,9 @@ import (
 )
 
 const (
-	PrismEntrySize        = 80   // bytes per prism entry
+	PrismEntrySize        = 96   // bytes per prism entry
 	MaxPrisms             = 16   // max prisms per prismctl instance
-	PrismRuntimeStateSize = 1424 // total size of PrismRuntimeState (includes alignment padding)
+	PrismRuntimeStateSize = 1680 // total size of PrismRuntimeState (includes alignment padding)
 
 	PanelEntrySize       = 136  // bytes per panel entry
 	MaxPanels            = 32   // max panels
@@ -44,6 +44,14 @@ type PrismEntry struct {
 	Restarts uint8     // 1 byte: restart count (capped at 255)
 	_padding [2]byte   // 2 bytes: padding for alignment
 	StartMs  int64     // 8 bytes: unix ms when started
+
+	// Resource usage of the prism's process tree, zero until first sampled
+	RSSKiB    uint32  // 4 bytes: resident memory in KiB
+	CPUTenths uint16  // 2 bytes: CPU% ×10 over the last sample interval
+	Threads   uint16  // 2 bytes: threads across the process tree
+	Children  uint16  // 2 bytes: descendant processes
+	ProcState uint8   // 1 byte: stat(5) state of the prism process, e.g. 'S' or 'T'
+	_padding2 [5]byte // 5 bytes: padding for alignment
 }
 
 func (e *PrismEntry) GetName() string {
@@ -72,6 +80,27 @@ func (e *PrismEntry) Uptime() time.Duration {
 	return time.Duration(time.Now().UnixMilli()-e.StartMs) * time.Millisecond
 }
 
+// Sampled reports whether resource usage has been recorded for the entry
+func (e *PrismEntry) Sampled() bool {
+	return e.ProcState != 0
+}
+
+func (e *PrismEntry) CPUPercent() float64 {
+	return float64(e.CPUTenths) / 10
+}
+
+func (e *PrismEntry) RSSBytes() int64 {
+	return int64(e.RSSKiB) * 1024
+}
+
+func (e *PrismEntry) clearUsage() {
+	e.RSSKiB = 0
+	e.CPUTenths = 0
+	e.Threads = 0
+	e.Children = 0
+	e.ProcState = 0
+}
+
 func (e *PrismEntry) IsActive() bool {
 	return e.PID != 0
 }
@@ -84,7 +113,7 @@ type PrismRuntimeState struct {
 	FgPrism     [63]byte         // 63 bytes: foreground prism name
 	PrismCount  uint8            // 1 byte: number of active prisms
 	_padding    [3]byte          // 3 bytes: padding for alignment
-	Prisms      [16]PrismEntry   // 16 * 80 = 1280 bytes
+	Prisms      [16]PrismEntry   // 16 * 96 = 1536 bytes
 }
 
 func (s *PrismRuntimeState) GetInstance() string {
@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -116,6 +117,57 @@ func (w *PrismStateWriter) SetForeground(name string) {
 	w.endWrite()
 }
 
+// RestartPrism records a new process for an existing prism entry, keeping its slot
+func (w *PrismStateWriter) RestartPrism(name string, pid int32, restarts uint8) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.beginWrite()
+
+	for i := 0; i < int(w.ptr.PrismCount); i++ {
+		if w.ptr.Prisms[i].GetName() == name {
+			w.ptr.Prisms[i].PID = pid
+			w.ptr.Prisms[i].Restarts = restarts
+			w.ptr.Prisms[i].StartMs = time.Now().UnixMilli()
+			w.ptr.Prisms[i].clearUsage()
+			break
+		}
+	}
+
+	w.endWrite()
+}
+
+// PrismUsage is the resource usage recorded in a prism entry
+type PrismUsage struct {
+	CPUPercent float64
+	RSSBytes   int64
+	Threads    int
+	Children   int
+	ProcState  byte // stat(5) state character
+}
+
+// SetUsage records the latest resource usage sample for a prism
+func (w *PrismStateWriter) SetUsage(name string, usage PrismUsage) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.beginWrite()
+
+	for i := 0; i < int(w.ptr.PrismCount); i++ {
+		if w.ptr.Prisms[i].GetName() == name {
+			entry := &w.ptr.Prisms[i]
+			entry.RSSKiB = uint32(min(usage.RSSBytes/1024, math.MaxUint32))
+			entry.CPUTenths = uint16(min(math.Round(usage.CPUPercent*10), math.MaxUint16))
+			entry.Threads = uint16(min(usage.Threads, math.MaxUint16))
+			entry.Children = uint16(min(usage.Children, math.MaxUint16))
+			entry.ProcState = usage.ProcState
+			break
+		}
+	}
+
+	w.endWrite()
+}
+
 func (w *PrismStateWriter) AddPrism(name string, pid int32, fg bool) (int, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()